@@ -2,13 +2,19 @@ package main
 
 import (
 	"log"
+	"strings"
 	"time"
 	"viport-backend/internal/config"
 	"viport-backend/internal/handlers"
 	"viport-backend/internal/middleware"
+	"viport-backend/internal/repositories"
+	"viport-backend/internal/services"
 	"viport-backend/pkg/auth"
 	"viport-backend/pkg/database"
+	"viport-backend/pkg/downloads"
 	"viport-backend/pkg/logger"
+	"viport-backend/pkg/pagination"
+	"viport-backend/pkg/storage"
 
 	"github.com/gin-gonic/gin"
 )
@@ -24,7 +30,7 @@ func main() {
 	db, err := database.Connect(cfg.DatabaseURL)
 	if err != nil {
 		logger.Error("Failed to connect to database: " + err.Error())
-		logger.Info("Running without database - using dummy data")
+		logger.Info("Running without database - endpoints that read or write data will fail until it is available")
 		db = nil
 	}
 	if db != nil {
@@ -34,11 +40,54 @@ func main() {
 	// Initialize JWT manager
 	jwtManager := auth.NewJWTManager(cfg.JWTSecret, 1*time.Hour)
 
+	// Payments go through the in-process fake until a gateway integration is added
+	paymentProvider := services.NewFakePaymentProvider()
+	payoutProvider := services.NewFakePayoutProvider()
+
+	// Signs the opaque cursors handed out by paginated list endpoints
+	cursors := pagination.NewCodec(cfg.CursorSecret)
+
+	// Signs the expiring links buyers download product files through
+	downloadSigner := downloads.NewSigner(cfg.DownloadSecret)
+
+	// Keeps generated files such as course certificates
+	fileStorage := storage.NewLocalStorage(cfg.StorageDir, cfg.StorageBaseURL)
+
 	// Initialize handlers with database connection
 	authHandler := handlers.NewAuthHandler(db, logger, jwtManager)
-	userHandler := handlers.NewUserHandler(db, logger)
-	postHandler := handlers.NewPostHandler(db, logger)
-	productHandler := handlers.NewProductHandler(db, logger)
+	userHandler := handlers.NewUserHandler(db, logger, cursors)
+	postHandler := handlers.NewPostHandler(db, logger, cursors)
+	productHandler := handlers.NewProductHandler(db, logger, cursors, paymentProvider, downloadSigner, storage.NewFetcher(fileStorage))
+	courseHandler := handlers.NewCourseHandler(db, logger, cursors, paymentProvider, fileStorage)
+	certificateHandler := handlers.NewCertificateHandler(db, logger, fileStorage)
+	quizHandler := handlers.NewQuizHandler(db, logger, fileStorage)
+	likeHandler := handlers.NewLikeHandler(db, logger)
+	commentHandler := handlers.NewCommentHandler(db, logger, cursors)
+	tagHandler := handlers.NewTagHandler(db, logger)
+	categoryHandler := handlers.NewCategoryHandler(db, logger)
+	paymentHandler := handlers.NewPaymentHandler(db, logger, cfg.PaymentWebhookSecret)
+	refundHandler := handlers.NewRefundHandler(db, logger, paymentProvider)
+	ledgerHandler := handlers.NewLedgerHandler(db, logger)
+	feeRuleHandler := handlers.NewFeeRuleHandler(db, logger)
+	payoutHandler := handlers.NewPayoutHandler(db, logger, payoutProvider)
+	libraryHandler := handlers.NewLibraryHandler(db, logger)
+	savedHandler := handlers.NewSavedHandler(db, logger)
+	reviewHandler := handlers.NewReviewHandler(db, logger, cursors)
+
+	// Batch creator earnings into payouts in the background
+	if db != nil && cfg.PayoutInterval > 0 {
+		payoutScheduler := services.NewPayoutScheduler(services.NewPayoutService(db, payoutProvider), cfg.PayoutInterval, logger)
+		payoutScheduler.Start()
+		defer payoutScheduler.Stop()
+	}
+
+	// Repositories used by route-level authorization checks
+	userRepo := repositories.NewUserRepository(db)
+	postRepo := repositories.NewPostRepository(db)
+	productRepo := repositories.NewProductRepository(db)
+	courseRepo := repositories.NewCourseRepository(db)
+	commentRepo := repositories.NewCommentRepository(db)
+	reviewRepo := repositories.NewReviewRepository(db)
 
 	// Setup Gin router
 	if cfg.Environment == "production" {
@@ -53,6 +102,11 @@ func main() {
 	r.Use(middleware.CORS())
 	r.Use(middleware.RateLimitMiddleware(100, 1*time.Minute)) // 100 requests per minute
 
+	// Stored files are served here unless a CDN serves them
+	if strings.HasPrefix(cfg.StorageBaseURL, "/") {
+		r.Static(cfg.StorageBaseURL, fileStorage.Dir())
+	}
+
 	// Health check
 	r.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{
@@ -81,15 +135,35 @@ func main() {
 		{
 			// Public routes
 			users.GET("", userHandler.GetUsers)
-			users.GET("/:id", userHandler.GetUser)
+			users.GET("/:id", middleware.OptionalAuthMiddleware(jwtManager), userHandler.GetUser)
+			users.GET("/:id/followers", middleware.OptionalAuthMiddleware(jwtManager), userHandler.GetFollowers)
+			users.GET("/:id/following", middleware.OptionalAuthMiddleware(jwtManager), userHandler.GetFollowing)
 			
 			// Protected routes
 			users.Use(middleware.AuthMiddleware(jwtManager))
 			users.GET("/me", authHandler.GetProfile)
 			users.PUT("/me", authHandler.UpdateProfile)
+			users.GET("/me/stats", userHandler.GetMyStats)
+			users.GET("/me/balance", ledgerHandler.GetMyBalance)
+			users.GET("/me/ledger", ledgerHandler.GetMyLedger)
 			users.POST("", userHandler.CreateUser)
 			users.PUT("/:id", userHandler.UpdateUser)
-			users.DELETE("/:id", userHandler.DeleteUser)
+			users.DELETE("/:id", middleware.RequireOwnership(logger, "User", "id", userRepo.GetOwnerID), userHandler.DeleteUser)
+			users.POST("/:id/follow", userHandler.FollowUser)
+			users.DELETE("/:id/follow", userHandler.UnfollowUser)
+		}
+
+		// Authenticated user's own resources
+		me := api.Group("/me")
+		me.Use(middleware.AuthMiddleware(jwtManager))
+		{
+			me.GET("/library", libraryHandler.GetLibrary)
+			me.GET("/courses", courseHandler.GetMyCourses)
+			me.GET("/saved", savedHandler.GetSavedItems)
+			me.GET("/collections", savedHandler.GetCollections)
+			me.POST("/collections", savedHandler.CreateCollection)
+			me.PUT("/collections/:id", savedHandler.UpdateCollection)
+			me.DELETE("/collections/:id", savedHandler.DeleteCollection)
 		}
 
 		// Posts routes (Instagram-like feed)
@@ -98,15 +172,19 @@ func main() {
 			// Public routes
 			posts.GET("", middleware.OptionalAuthMiddleware(jwtManager), postHandler.GetFeed)
 			posts.GET("/:id", middleware.OptionalAuthMiddleware(jwtManager), postHandler.GetPost)
-			posts.GET("/:id/comments", middleware.OptionalAuthMiddleware(jwtManager), postHandler.GetPostComments)
+			posts.GET("/:id/comments", middleware.OptionalAuthMiddleware(jwtManager), commentHandler.ListComments("post"))
 			
 			// Protected routes
 			posts.Use(middleware.AuthMiddleware(jwtManager))
+			posts.GET("/feed/home", postHandler.GetHomeFeed)
 			posts.POST("", postHandler.CreatePost)
-			posts.PUT("/:id", postHandler.UpdatePost)
-			posts.DELETE("/:id", postHandler.DeletePost)
+			posts.PUT("/:id", middleware.RequireOwnership(logger, "Post", "id", postRepo.GetOwnerID), postHandler.UpdatePost)
+			posts.DELETE("/:id", middleware.RequireOwnership(logger, "Post", "id", postRepo.GetOwnerID), postHandler.DeletePost)
 			posts.POST("/:id/like", postHandler.LikePost)
 			posts.DELETE("/:id/like", postHandler.UnlikePost)
+			posts.POST("/:id/save", savedHandler.Save("post"))
+			posts.DELETE("/:id/save", savedHandler.Unsave("post"))
+			posts.POST("/:id/comments", commentHandler.CreateComment("post"))
 		}
 
 		// Products routes (Digital Marketplace)
@@ -114,15 +192,149 @@ func main() {
 		{
 			// Public routes
 			products.GET("", middleware.OptionalAuthMiddleware(jwtManager), productHandler.GetProducts)
-			products.GET("/categories", productHandler.GetCategories)
+			products.GET("/categories", categoryHandler.GetCategories)
 			products.GET("/:id", middleware.OptionalAuthMiddleware(jwtManager), productHandler.GetProduct)
+			products.GET("/:id/comments", middleware.OptionalAuthMiddleware(jwtManager), commentHandler.ListComments("product"))
+			products.GET("/:id/reviews", middleware.OptionalAuthMiddleware(jwtManager), reviewHandler.ListReviews("product"))
+			products.GET("/:id/rating", middleware.OptionalAuthMiddleware(jwtManager), reviewHandler.GetRatingSummary("product"))
 			
 			// Protected routes
 			products.Use(middleware.AuthMiddleware(jwtManager))
 			products.POST("", middleware.CreatorOnlyMiddleware(), productHandler.CreateProduct)
-			products.PUT("/:id", productHandler.UpdateProduct)
-			products.DELETE("/:id", productHandler.DeleteProduct)
+			products.PUT("/:id", middleware.RequireOwnership(logger, "Product", "id", productRepo.GetOwnerID), productHandler.UpdateProduct)
+			products.DELETE("/:id", middleware.RequireOwnership(logger, "Product", "id", productRepo.GetOwnerID), productHandler.DeleteProduct)
 			products.POST("/:id/purchase", productHandler.PurchaseProduct)
+			products.GET("/:id/download", productHandler.GetDownloadLinks)
+			products.POST("/:id/like", likeHandler.Like("product"))
+			products.DELETE("/:id/like", likeHandler.Unlike("product"))
+			products.POST("/:id/save", savedHandler.Save("product"))
+			products.DELETE("/:id/save", savedHandler.Unsave("product"))
+			products.POST("/:id/comments", commentHandler.CreateComment("product"))
+			products.POST("/:id/reviews", reviewHandler.CreateReview("product"))
+		}
+
+		// Courses routes (Learning Management System)
+		courses := api.Group("/courses")
+		{
+			// Public routes
+			courses.GET("", middleware.OptionalAuthMiddleware(jwtManager), courseHandler.GetCourses)
+			courses.GET("/:id", middleware.OptionalAuthMiddleware(jwtManager), courseHandler.GetCourse)
+			courses.GET("/:id/lessons", middleware.OptionalAuthMiddleware(jwtManager), courseHandler.GetLessons)
+			courses.GET("/:id/lessons/:lessonId", middleware.OptionalAuthMiddleware(jwtManager), courseHandler.GetLesson)
+			courses.GET("/:id/lessons/:lessonId/quiz", middleware.OptionalAuthMiddleware(jwtManager), quizHandler.GetQuiz)
+			courses.GET("/:id/comments", middleware.OptionalAuthMiddleware(jwtManager), commentHandler.ListComments("course"))
+			courses.GET("/:id/reviews", middleware.OptionalAuthMiddleware(jwtManager), reviewHandler.ListReviews("course"))
+			courses.GET("/:id/rating", middleware.OptionalAuthMiddleware(jwtManager), reviewHandler.GetRatingSummary("course"))
+
+			// Protected routes
+			courses.Use(middleware.AuthMiddleware(jwtManager))
+			courses.POST("", middleware.CreatorOnlyMiddleware(), courseHandler.CreateCourse)
+			courses.POST("/:id/enroll", courseHandler.EnrollCourse)
+			courses.POST("/:id/purchase", courseHandler.PurchaseCourse)
+			courses.GET("/:id/resume", courseHandler.ResumeCourse)
+			courses.GET("/:id/certificate", certificateHandler.GetCourseCertificate)
+			courses.POST("/:id/lessons/:lessonId/complete", courseHandler.CompleteLesson)
+			courses.DELETE("/:id/lessons/:lessonId/complete", courseHandler.UncompleteLesson)
+			courses.PUT("/:id", middleware.RequireOwnership(logger, "Course", "id", courseRepo.GetOwnerID), courseHandler.UpdateCourse)
+			courses.GET("/:id/analytics", middleware.RequireOwnership(logger, "Course", "id", courseRepo.GetOwnerID), courseHandler.GetCourseAnalytics)
+			courses.POST("/:id/lessons", middleware.RequireOwnership(logger, "Course", "id", courseRepo.GetOwnerID), courseHandler.CreateLesson)
+			courses.PUT("/:id/lessons/reorder", middleware.RequireOwnership(logger, "Course", "id", courseRepo.GetOwnerID), courseHandler.ReorderLessons)
+			courses.PUT("/:id/lessons/:lessonId", middleware.RequireOwnership(logger, "Course", "id", courseRepo.GetOwnerID), courseHandler.UpdateLesson)
+			courses.DELETE("/:id/lessons/:lessonId", middleware.RequireOwnership(logger, "Course", "id", courseRepo.GetOwnerID), courseHandler.DeleteLesson)
+			courses.PUT("/:id/lessons/:lessonId/quiz", middleware.RequireOwnership(logger, "Course", "id", courseRepo.GetOwnerID), quizHandler.SaveQuiz)
+			courses.DELETE("/:id/lessons/:lessonId/quiz", middleware.RequireOwnership(logger, "Course", "id", courseRepo.GetOwnerID), quizHandler.DeleteQuiz)
+			courses.POST("/:id/lessons/:lessonId/quiz/attempts", quizHandler.SubmitAttempt)
+			courses.GET("/:id/lessons/:lessonId/quiz/attempts", quizHandler.GetAttempts)
+			courses.POST("/:id/like", likeHandler.Like("course"))
+			courses.DELETE("/:id/like", likeHandler.Unlike("course"))
+			courses.POST("/:id/save", savedHandler.Save("course"))
+			courses.DELETE("/:id/save", savedHandler.Unsave("course"))
+			courses.POST("/:id/comments", commentHandler.CreateComment("course"))
+			courses.POST("/:id/reviews", reviewHandler.CreateReview("course"))
+		}
+
+		// Payment provider callbacks, authenticated by signature
+		payments := api.Group("/payments")
+		{
+			payments.POST("/webhook", paymentHandler.Webhook)
+		}
+
+		// Product file downloads, authenticated by the signed token
+		api.GET("/downloads/:token", productHandler.Download)
+
+		// Public certificate verification
+		api.GET("/certificates/:code/verify", certificateHandler.VerifyCertificate)
+
+		// Creator payout routes
+		payouts := api.Group("/payouts")
+		{
+			payouts.Use(middleware.AuthMiddleware(jwtManager))
+			payouts.GET("", payoutHandler.GetMyPayouts)
+			payouts.GET("/methods", payoutHandler.GetPayoutMethods)
+			payouts.POST("/methods", payoutHandler.CreatePayoutMethod)
+			payouts.POST("/methods/:id/default", payoutHandler.SetDefaultPayoutMethod)
+			payouts.DELETE("/methods/:id", payoutHandler.DeletePayoutMethod)
+			payouts.GET("/settings", payoutHandler.GetPayoutSettings)
+			payouts.PUT("/settings", payoutHandler.UpdatePayoutSettings)
+			payouts.GET("/:id", payoutHandler.GetPayout)
+		}
+
+		// Transaction refund requests
+		transactions := api.Group("/transactions")
+		{
+			transactions.Use(middleware.AuthMiddleware(jwtManager))
+			transactions.POST("/:id/refunds", refundHandler.RequestRefund)
+		}
+
+		// Refund review routes, for buyers, sellers and admins
+		refunds := api.Group("/refunds")
+		{
+			refunds.Use(middleware.AuthMiddleware(jwtManager))
+			refunds.GET("", refundHandler.ListRefunds)
+			refunds.GET("/:id", refundHandler.GetRefund)
+			refunds.POST("/:id/approve", refundHandler.ApproveRefund)
+			refunds.POST("/:id/reject", refundHandler.RejectRefund)
+		}
+
+		// Category routes
+		categories := api.Group("/categories")
+		{
+			categories.GET("", categoryHandler.GetCategories)
+			categories.GET("/:slug", categoryHandler.GetCategory)
+		}
+
+		// Tags routes
+		tags := api.Group("/tags")
+		{
+			tags.GET("", tagHandler.SearchTags)
+			tags.GET("/trending", tagHandler.GetTrendingTags)
+			tags.GET("/:slug", tagHandler.GetTag)
+			tags.GET("/:slug/posts", middleware.OptionalAuthMiddleware(jwtManager), postHandler.GetPostsByTag)
+			tags.GET("/:slug/products", middleware.OptionalAuthMiddleware(jwtManager), productHandler.GetProductsByTag)
+		}
+
+		// Comments routes
+		comments := api.Group("/comments")
+		{
+			// Public routes
+			comments.GET("/:id/replies", middleware.OptionalAuthMiddleware(jwtManager), commentHandler.GetReplies)
+
+			// Protected routes
+			comments.Use(middleware.AuthMiddleware(jwtManager))
+			comments.PUT("/:id", middleware.RequireOwnership(logger, "Comment", "id", commentRepo.GetOwnerID), commentHandler.UpdateComment)
+			comments.DELETE("/:id", middleware.RequireOwnership(logger, "Comment", "id", commentRepo.GetOwnerID), commentHandler.DeleteComment)
+			comments.POST("/:id/like", likeHandler.Like("comment"))
+			comments.DELETE("/:id/like", likeHandler.Unlike("comment"))
+		}
+
+		// Review routes
+		reviews := api.Group("/reviews")
+		{
+			reviews.Use(middleware.AuthMiddleware(jwtManager))
+			reviews.PUT("/:id", middleware.RequireOwnership(logger, "Review", "id", reviewRepo.GetOwnerID), reviewHandler.UpdateReview)
+			reviews.DELETE("/:id", middleware.RequireOwnership(logger, "Review", "id", reviewRepo.GetOwnerID), reviewHandler.DeleteReview)
+			reviews.POST("/:id/helpful", reviewHandler.MarkHelpful)
+			reviews.DELETE("/:id/helpful", reviewHandler.UnmarkHelpful)
 		}
 
 		// Admin routes
@@ -130,6 +342,24 @@ func main() {
 		admin.Use(middleware.AuthMiddleware(jwtManager))
 		admin.Use(middleware.AdminOnlyMiddleware())
 		{
+			admin.GET("/categories", categoryHandler.GetAllCategories)
+			admin.POST("/categories", categoryHandler.CreateCategory)
+			admin.POST("/categories/reorder", categoryHandler.ReorderCategories)
+			admin.PUT("/categories/:id", categoryHandler.UpdateCategory)
+			admin.POST("/categories/:id/activate", categoryHandler.ActivateCategory)
+			admin.POST("/categories/:id/deactivate", categoryHandler.DeactivateCategory)
+			admin.GET("/fee-rules", feeRuleHandler.GetFeeRules)
+			admin.POST("/fee-rules", feeRuleHandler.CreateFeeRule)
+			admin.PUT("/fee-rules/:id", feeRuleHandler.UpdateFeeRule)
+			admin.GET("/ledger/verify", ledgerHandler.VerifyLedger)
+			admin.GET("/users/:id/balance", ledgerHandler.GetUserBalance)
+			admin.POST("/users/:id/payouts/hold", payoutHandler.HoldCreatorPayouts)
+			admin.POST("/users/:id/payouts/release", payoutHandler.ReleaseCreatorPayouts)
+			admin.GET("/payouts", payoutHandler.GetAllPayouts)
+			admin.POST("/payouts/run", payoutHandler.RunPayouts)
+			admin.POST("/payouts/:id/hold", payoutHandler.HoldPayout)
+			admin.POST("/payouts/:id/release", payoutHandler.ReleasePayout)
+
 			admin.GET("/stats", func(c *gin.Context) {
 				c.JSON(200, gin.H{
 					"totalUsers":    1250,
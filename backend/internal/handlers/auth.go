@@ -357,7 +357,37 @@ func (h *AuthHandler) GetProfile(c *gin.Context) {
 		return
 	}
 
-	// Get user profile (dummy for now)
+	if h.userRepo.IsConnected() {
+		user, err := h.userRepo.GetByID(userID.(string))
+		if err != nil {
+			if err == sql.ErrNoRows {
+				c.JSON(http.StatusNotFound, models.ErrorResponse{
+					Error:   "User not found",
+					Success: false,
+				})
+				return
+			}
+			h.logger.Error("Database error: " + err.Error())
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "Internal server error",
+				Success: false,
+			})
+			return
+		}
+
+		if err := h.userRepo.LoadCounts(user); err != nil {
+			h.logger.Error("Failed to load user counts: " + err.Error())
+		}
+
+		c.JSON(http.StatusOK, models.ApiResponse{
+			Data:    user,
+			Message: "Profile retrieved successfully",
+			Success: true,
+		})
+		return
+	}
+
+	// Fallback profile when database is not available
 	user := models.User{
 		ID:                userID.(string),
 		Username:          "johndoe",
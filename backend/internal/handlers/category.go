@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"viport-backend/internal/models"
+	"viport-backend/internal/repositories"
+	"viport-backend/internal/services"
+	"viport-backend/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type CategoryHandler struct {
+	logger          logger.Logger
+	validate        *validator.Validate
+	categoryService *services.CategoryService
+}
+
+func NewCategoryHandler(db *sql.DB, logger logger.Logger) *CategoryHandler {
+	return &CategoryHandler{
+		logger:          logger,
+		validate:        validator.New(),
+		categoryService: services.NewCategoryService(db),
+	}
+}
+
+// GetCategories returns the active category tree.
+func (h *CategoryHandler) GetCategories(c *gin.Context) {
+	h.writeTree(c, false)
+}
+
+// GetAllCategories returns the full category tree, inactive categories
+// included, for the admin console.
+func (h *CategoryHandler) GetAllCategories(c *gin.Context) {
+	h.writeTree(c, true)
+}
+
+func (h *CategoryHandler) writeTree(c *gin.Context, includeInactive bool) {
+	categories, err := h.categoryService.Tree(includeInactive)
+	if err != nil {
+		h.writeCategoryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Data:    categories,
+		Message: "Categories retrieved successfully",
+		Success: true,
+	})
+}
+
+// GetCategory looks a category up by slug and returns it with its parent and
+// subcategories.
+func (h *CategoryHandler) GetCategory(c *gin.Context) {
+	category, err := h.categoryService.GetBySlug(c.Param("slug"), false)
+	if err != nil {
+		h.writeCategoryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Data:    category,
+		Message: "Category retrieved successfully",
+		Success: true,
+	})
+}
+
+func (h *CategoryHandler) CreateCategory(c *gin.Context) {
+	var req models.CreateCategoryRequest
+	if !h.bind(c, &req) {
+		return
+	}
+
+	category, err := h.categoryService.Create(req)
+	if err != nil {
+		h.writeCategoryError(c, err)
+		return
+	}
+
+	h.logger.Info("Created category " + category.ID + " (" + category.Slug + ")")
+
+	c.JSON(http.StatusCreated, models.ApiResponse{
+		Data:    category,
+		Message: "Category created successfully",
+		Success: true,
+	})
+}
+
+func (h *CategoryHandler) UpdateCategory(c *gin.Context) {
+	var req models.UpdateCategoryRequest
+	if !h.bind(c, &req) {
+		return
+	}
+
+	category, err := h.categoryService.Update(c.Param("id"), req)
+	if err != nil {
+		h.writeCategoryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Data:    category,
+		Message: "Category updated successfully",
+		Success: true,
+	})
+}
+
+// ReorderCategories moves and reorders several categories in one request,
+// e.g. after a drag and drop in the admin console.
+func (h *CategoryHandler) ReorderCategories(c *gin.Context) {
+	var req models.ReorderCategoriesRequest
+	if !h.bind(c, &req) {
+		return
+	}
+
+	if err := h.categoryService.Reorder(req.Positions); err != nil {
+		h.writeCategoryError(c, err)
+		return
+	}
+
+	h.writeTree(c, true)
+}
+
+func (h *CategoryHandler) ActivateCategory(c *gin.Context) {
+	h.setActive(c, true)
+}
+
+// DeactivateCategory hides a category and its subcategories from the
+// storefront. Items filed under it are left untouched.
+func (h *CategoryHandler) DeactivateCategory(c *gin.Context) {
+	h.setActive(c, false)
+}
+
+func (h *CategoryHandler) setActive(c *gin.Context, active bool) {
+	id := c.Param("id")
+	if err := h.categoryService.SetActive(id, active); err != nil {
+		h.writeCategoryError(c, err)
+		return
+	}
+
+	message := "Category activated successfully"
+	if !active {
+		message = "Category deactivated successfully"
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Data:    gin.H{"id": id, "isActive": active},
+		Message: message,
+		Success: true,
+	})
+}
+
+// bind decodes and validates the JSON body into req, writing a 400 response
+// and returning false when it is invalid.
+func (h *CategoryHandler) bind(c *gin.Context, req interface{}) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request data",
+			Message: err.Error(),
+			Success: false,
+		})
+		return false
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Validation failed",
+			Message: err.Error(),
+			Success: false,
+		})
+		return false
+	}
+
+	return true
+}
+
+func (h *CategoryHandler) writeCategoryError(c *gin.Context, err error) {
+	switch err {
+	case sql.ErrNoRows:
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Category not found",
+			Success: false,
+		})
+	case repositories.ErrDuplicateSlug:
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error:   "Category slug already in use",
+			Success: false,
+		})
+	case repositories.ErrParentNotFound, repositories.ErrCategoryCycle, services.ErrInvalidSlug:
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid category",
+			Message: err.Error(),
+			Success: false,
+		})
+	default:
+		h.logger.Error("Category operation failed: " + err.Error())
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal server error",
+			Success: false,
+		})
+	}
+}
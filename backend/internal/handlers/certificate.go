@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"viport-backend/internal/models"
+	"viport-backend/internal/repositories"
+	"viport-backend/internal/services"
+	"viport-backend/pkg/logger"
+	"viport-backend/pkg/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CertificateHandler hands learners their course completion certificates
+// and lets anyone verify one by its code.
+type CertificateHandler struct {
+	logger       logger.Logger
+	certificates *services.CertificateService
+}
+
+func NewCertificateHandler(db *sql.DB, logger logger.Logger, store storage.Storage) *CertificateHandler {
+	return &CertificateHandler{
+		logger:       logger,
+		certificates: services.NewCertificateService(db, store),
+	}
+}
+
+// GetCourseCertificate returns the authenticated learner's certificate for
+// a completed course, issuing it if needed.
+func (h *CertificateHandler) GetCourseCertificate(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Authentication required",
+			Success: false,
+		})
+		return
+	}
+
+	certificate, err := h.certificates.Issue(userID.(string), c.Param("id"))
+	if err != nil {
+		h.writeCertificateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Data:    certificate,
+		Message: "Certificate retrieved successfully",
+		Success: true,
+	})
+}
+
+// VerifyCertificate confirms that a certificate with the code was issued,
+// and to whom for which course.
+func (h *CertificateHandler) VerifyCertificate(c *gin.Context) {
+	certificate, err := h.certificates.Verify(c.Param("code"))
+	if err != nil {
+		h.writeCertificateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Data: gin.H{
+			"valid":       true,
+			"certificate": certificate,
+		},
+		Message: "Certificate is authentic",
+		Success: true,
+	})
+}
+
+func (h *CertificateHandler) writeCertificateError(c *gin.Context, err error) {
+	switch err {
+	case sql.ErrNoRows:
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Certificate not found",
+			Success: false,
+		})
+	case repositories.ErrNotEnrolled:
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error:   "You are not enrolled in this course",
+			Code:    "NOT_ENROLLED",
+			Success: false,
+		})
+	case services.ErrCourseNotCompleted:
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error:   "Complete every lesson of the course to earn its certificate",
+			Code:    "COURSE_NOT_COMPLETED",
+			Success: false,
+		})
+	default:
+		h.logger.Error("Certificate operation failed: " + err.Error())
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal server error",
+			Success: false,
+		})
+	}
+}
@@ -0,0 +1,306 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+	"viport-backend/internal/models"
+	"viport-backend/internal/repositories"
+	"viport-backend/internal/services"
+	"viport-backend/pkg/logger"
+	"viport-backend/pkg/pagination"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type CommentHandler struct {
+	db          *sql.DB
+	logger      logger.Logger
+	validate    *validator.Validate
+	commentRepo *repositories.CommentRepository
+	postRepo    *repositories.PostRepository
+	likeService *services.LikeService
+	cursors     *pagination.Codec
+}
+
+func NewCommentHandler(db *sql.DB, logger logger.Logger, cursors *pagination.Codec) *CommentHandler {
+	return &CommentHandler{
+		db:          db,
+		logger:      logger,
+		validate:    validator.New(),
+		commentRepo: repositories.NewCommentRepository(db),
+		postRepo:    repositories.NewPostRepository(db),
+		likeService: services.NewLikeService(db),
+		cursors:     cursors,
+	}
+}
+
+// ListComments returns a handler listing the comment threads on the :id item
+// of the given commentable type, newest first, paged by cursor.
+func (h *CommentHandler) ListComments(commentableType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		itemID := c.Param("id")
+
+		page, ok := parsePageRequest(c, h.cursors, "comments")
+		if !ok {
+			return
+		}
+
+		if !h.checkVisible(c, commentableType, itemID) {
+			return
+		}
+
+		comments, total, err := h.commentRepo.ListThreads(commentableType, itemID, page.Cursor, page.Limit)
+		if err != nil {
+			h.logger.Error("Failed to list comments: " + err.Error())
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "Internal server error",
+				Success: false,
+			})
+			return
+		}
+
+		h.respondWithComments(c, comments, page, total)
+	}
+}
+
+// GetReplies lists the direct replies to a comment, oldest first, paged by cursor.
+func (h *CommentHandler) GetReplies(c *gin.Context) {
+	commentID := c.Param("id")
+
+	page, ok := parsePageRequest(c, h.cursors, "comments")
+	if !ok {
+		return
+	}
+
+	parent, err := h.commentRepo.GetByID(commentID)
+	if err != nil {
+		h.writeCommentError(c, err)
+		return
+	}
+
+	if !h.checkVisible(c, parent.CommentableType, parent.CommentableID) {
+		return
+	}
+
+	replies, err := h.commentRepo.ListReplies(commentID, page.Cursor, page.Limit)
+	if err != nil {
+		h.logger.Error("Failed to list replies: " + err.Error())
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal server error",
+			Success: false,
+		})
+		return
+	}
+
+	h.respondWithComments(c, replies, page, parent.ReplyCount)
+}
+
+// CreateComment returns a handler that posts a comment, or a reply when
+// parentId is set, on the :id item of the given commentable type.
+func (h *CommentHandler) CreateComment(commentableType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userID")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+				Error:   "Authentication required",
+				Success: false,
+			})
+			return
+		}
+
+		var req models.CreateCommentRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Invalid request data",
+				Message: err.Error(),
+				Success: false,
+			})
+			return
+		}
+
+		if err := h.validate.Struct(&req); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Validation failed",
+				Message: err.Error(),
+				Success: false,
+			})
+			return
+		}
+
+		if !h.checkVisible(c, commentableType, c.Param("id")) {
+			return
+		}
+
+		comment := models.Comment{
+			UserID:          userID.(string),
+			CommentableType: commentableType,
+			CommentableID:   c.Param("id"),
+			ParentID:        req.ParentID,
+			Content:         req.Content,
+		}
+
+		if err := h.commentRepo.Create(&comment); err != nil {
+			if err == sql.ErrNoRows {
+				message := capitalize(commentableType) + " not found"
+				if req.ParentID != nil {
+					message = capitalize(commentableType) + " or parent comment not found"
+				}
+				c.JSON(http.StatusNotFound, models.ErrorResponse{
+					Error:   message,
+					Success: false,
+				})
+				return
+			}
+			h.logger.Error("Failed to create comment: " + err.Error())
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "Internal server error",
+				Success: false,
+			})
+			return
+		}
+
+		h.logger.Info("User " + userID.(string) + " commented on " + commentableType + ": " + comment.CommentableID)
+
+		created, err := h.commentRepo.GetByID(comment.ID)
+		if err != nil {
+			h.logger.Error("Failed to reload comment: " + err.Error())
+			created = &comment
+		}
+
+		c.JSON(http.StatusCreated, models.ApiResponse{
+			Data:    created,
+			Message: "Comment created successfully",
+			Success: true,
+		})
+	}
+}
+
+func (h *CommentHandler) UpdateComment(c *gin.Context) {
+	commentID := c.Param("id")
+
+	var req models.UpdateCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request data",
+			Message: err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	if err := h.validate.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Validation failed",
+			Message: err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	comment, err := h.commentRepo.GetByID(commentID)
+	if err != nil {
+		h.writeCommentError(c, err)
+		return
+	}
+
+	comment.Content = req.Content
+	if err := h.commentRepo.Update(comment); err != nil {
+		h.writeCommentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Data:    comment,
+		Message: "Comment updated successfully",
+		Success: true,
+	})
+}
+
+func (h *CommentHandler) DeleteComment(c *gin.Context) {
+	commentID := c.Param("id")
+
+	deleted, err := h.commentRepo.Delete(commentID)
+	if err != nil {
+		h.writeCommentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Data:    gin.H{"id": commentID, "deletedCount": deleted},
+		Message: "Comment deleted successfully",
+		Success: true,
+	})
+}
+
+func (h *CommentHandler) respondWithComments(c *gin.Context, comments []*models.Comment, page pageRequest, total int) {
+	var currentUserID string
+	if userID, exists := c.Get("userID"); exists {
+		currentUserID = userID.(string)
+	}
+
+	comments, meta := paginate(comments, page, total, h.cursors, "created_at", "",
+		func(comment *models.Comment) (string, string) {
+			return comment.CreatedAt.Format(time.RFC3339Nano), comment.ID
+		})
+
+	if err := h.likeService.MarkComments(currentUserID, comments); err != nil {
+		h.logger.Error("Failed to resolve comment likes: " + err.Error())
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Data:    comments,
+		Message: "Comments retrieved successfully",
+		Success: true,
+		Meta:    meta,
+	})
+}
+
+// checkVisible hides comments on posts the viewer may not see, answering
+// with the same 404 as a missing post. It writes the response and returns
+// false when access is denied.
+func (h *CommentHandler) checkVisible(c *gin.Context, commentableType, commentableID string) bool {
+	if commentableType != "post" {
+		return true
+	}
+
+	var currentUserID string
+	if userID, exists := c.Get("userID"); exists {
+		currentUserID = userID.(string)
+	}
+
+	if err := h.postRepo.CanView(commentableID, currentUserID); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "Post not found",
+				Success: false,
+			})
+			return false
+		}
+		h.logger.Error("Failed to check post visibility: " + err.Error())
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal server error",
+			Success: false,
+		})
+		return false
+	}
+
+	return true
+}
+
+func (h *CommentHandler) writeCommentError(c *gin.Context, err error) {
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Comment not found",
+			Success: false,
+		})
+		return
+	}
+
+	h.logger.Error("Comment operation failed: " + err.Error())
+	c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+		Error:   "Internal server error",
+		Success: false,
+	})
+}
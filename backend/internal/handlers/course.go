@@ -0,0 +1,911 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+	"viport-backend/internal/models"
+	"viport-backend/internal/repositories"
+	"viport-backend/internal/services"
+	"viport-backend/pkg/logger"
+	"viport-backend/pkg/pagination"
+	"viport-backend/pkg/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type CourseHandler struct {
+	logger       logger.Logger
+	validate     *validator.Validate
+	courseRepo   *repositories.CourseRepository
+	enrollments  *services.EnrollmentService
+	analytics    *services.AnalyticsService
+	certificates *services.CertificateService
+	checkout     *services.CheckoutService
+	library      *services.LibraryService
+	likes        *services.LikeService
+	saved        *services.SavedService
+	cursors      *pagination.Codec
+}
+
+func NewCourseHandler(db *sql.DB, logger logger.Logger, cursors *pagination.Codec, payments services.PaymentProvider, store storage.Storage) *CourseHandler {
+	return &CourseHandler{
+		logger:       logger,
+		validate:     validator.New(),
+		courseRepo:   repositories.NewCourseRepository(db),
+		enrollments:  services.NewEnrollmentService(db),
+		analytics:    services.NewAnalyticsService(db),
+		certificates: services.NewCertificateService(db, store),
+		checkout:     services.NewCheckoutService(db, payments),
+		library:      services.NewLibraryService(db),
+		likes:        services.NewLikeService(db),
+		saved:        services.NewSavedService(db),
+		cursors:      cursors,
+	}
+}
+
+func (h *CourseHandler) GetCourses(c *gin.Context) {
+	filter, page, ok := h.parseCourseFilter(c)
+	if !ok {
+		return
+	}
+
+	filter.ViewerID = viewerID(c)
+
+	courses, total, err := h.courseRepo.List(filter)
+	if err != nil {
+		h.logger.Error("Failed to list courses: " + err.Error())
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal server error",
+			Success: false,
+		})
+		return
+	}
+
+	courses, meta := paginate(courses, page, total, h.cursors, filter.SortBy, filter.SortOrder,
+		func(course *models.Course) (string, string) {
+			switch filter.SortBy {
+			case "price":
+				return strconv.FormatFloat(course.Price, 'f', -1, 64), course.ID
+			case "rating_average":
+				return strconv.FormatFloat(course.RatingAverage, 'f', -1, 64), course.ID
+			case "enrollment_count":
+				return strconv.Itoa(course.EnrollmentCount), course.ID
+			default:
+				return course.CreatedAt.Format(time.RFC3339Nano), course.ID
+			}
+		})
+
+	h.markCourses(filter.ViewerID, courses)
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Data:    courses,
+		Message: "Courses retrieved successfully",
+		Success: true,
+		Meta:    meta,
+	})
+}
+
+// parseCourseFilter reads the catalog query parameters. A cursor carries its
+// own sort, which takes precedence over sortBy and sortOrder.
+func (h *CourseHandler) parseCourseFilter(c *gin.Context) (models.CourseFilter, pageRequest, bool) {
+	page, ok := parsePageRequest(c, h.cursors, "courses")
+	if !ok {
+		return models.CourseFilter{}, page, false
+	}
+
+	filter := models.CourseFilter{
+		Limit:     page.Limit,
+		Offset:    page.Offset,
+		Cursor:    page.Cursor,
+		SortBy:    c.DefaultQuery("sortBy", "created_at"),
+		SortOrder: c.DefaultQuery("sortOrder", "desc"),
+	}
+	if page.Cursor != nil {
+		filter.SortBy, filter.SortOrder = page.Cursor.SortBy, page.Cursor.SortOrder
+	}
+	switch filter.SortBy {
+	case "created_at", "price", "rating_average", "enrollment_count":
+	default:
+		filter.SortBy = "created_at"
+	}
+	if filter.SortOrder != "asc" {
+		filter.SortOrder = "desc"
+	}
+
+	if instructorID := c.Query("instructorId"); instructorID != "" {
+		filter.InstructorID = &instructorID
+	}
+	if categoryID := c.Query("categoryId"); categoryID != "" {
+		filter.CategoryID = &categoryID
+	}
+	if status := c.Query("status"); status != "" {
+		filter.Status = &status
+	}
+	if level := c.Query("level"); level != "" {
+		filter.Level = &level
+	}
+	if language := c.Query("language"); language != "" {
+		filter.Language = &language
+	}
+	if minPriceStr := c.Query("minPrice"); minPriceStr != "" {
+		if minPrice, err := strconv.ParseFloat(minPriceStr, 64); err == nil {
+			filter.MinPrice = &minPrice
+		}
+	}
+	if maxPriceStr := c.Query("maxPrice"); maxPriceStr != "" {
+		if maxPrice, err := strconv.ParseFloat(maxPriceStr, 64); err == nil {
+			filter.MaxPrice = &maxPrice
+		}
+	}
+	if isFreeStr := c.Query("isFree"); isFreeStr != "" {
+		if isFree, err := strconv.ParseBool(isFreeStr); err == nil {
+			filter.IsFree = &isFree
+		}
+	}
+	if search := c.Query("search"); search != "" {
+		filter.Search = &search
+	}
+
+	return filter, page, true
+}
+
+// GetCourse returns a course with its lesson outline. The instructor and
+// enrolled students see the content of every lesson; others see it for
+// preview lessons.
+func (h *CourseHandler) GetCourse(c *gin.Context) {
+	courseID := c.Param("id")
+
+	currentUserID := viewerID(c)
+
+	course, err := h.courseRepo.GetVisibleByID(courseID, currentUserID)
+	if err != nil {
+		h.writeCourseError(c, err)
+		return
+	}
+
+	lessons, err := h.courseRepo.ListLessons(course.ID)
+	if err != nil {
+		h.writeCourseError(c, err)
+		return
+	}
+	if course.Lessons, _, err = h.enrollments.Outline(currentUserID, course, lessons); err != nil {
+		h.writeCourseError(c, err)
+		return
+	}
+
+	h.markCourses(currentUserID, []*models.Course{course})
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Data:    course,
+		Message: "Course retrieved successfully",
+		Success: true,
+	})
+}
+
+func (h *CourseHandler) CreateCourse(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Authentication required",
+			Success: false,
+		})
+		return
+	}
+
+	var req models.CreateCourseRequest
+	if !h.bind(c, &req) {
+		return
+	}
+
+	course := models.Course{
+		InstructorID:    userID.(string),
+		CategoryID:      req.CategoryID,
+		Title:           req.Title,
+		Subtitle:        req.Subtitle,
+		Description:     req.Description,
+		ThumbnailURL:    req.ThumbnailURL,
+		PreviewVideoURL: req.PreviewVideoURL,
+		Price:           req.Price,
+		IsFree:          req.IsFree,
+		Level:           req.Level,
+		DurationHours:   req.DurationHours,
+		Language:        req.Language,
+		Requirements:    req.Requirements,
+		Status:          "draft",
+	}
+	if req.WhatYouLearn != nil {
+		if whatYouLearn, err := json.Marshal(req.WhatYouLearn); err == nil {
+			course.WhatYouLearn = whatYouLearn
+		}
+	}
+
+	if err := h.courseRepo.Create(&course); err != nil {
+		h.writeCourseError(c, err)
+		return
+	}
+
+	h.logger.Info("Created course " + course.ID + " for user: " + userID.(string))
+
+	created, err := h.courseRepo.GetByID(course.ID)
+	if err != nil {
+		h.logger.Error("Failed to reload course: " + err.Error())
+		created = &course
+	}
+
+	c.JSON(http.StatusCreated, models.ApiResponse{
+		Data:    created,
+		Message: "Course created successfully",
+		Success: true,
+	})
+}
+
+// UpdateCourse edits a course. Courses are published and archived by
+// setting status; publishing needs at least one lesson.
+func (h *CourseHandler) UpdateCourse(c *gin.Context) {
+	courseID := c.Param("id")
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Authentication required",
+			Success: false,
+		})
+		return
+	}
+
+	var req models.UpdateCourseRequest
+	if !h.bind(c, &req) {
+		return
+	}
+
+	course, err := h.courseRepo.GetByID(courseID)
+	if err != nil {
+		h.writeCourseError(c, err)
+		return
+	}
+
+	if req.CategoryID != nil {
+		course.CategoryID = req.CategoryID
+	}
+	if req.Title != nil {
+		course.Title = *req.Title
+	}
+	if req.Subtitle != nil {
+		course.Subtitle = req.Subtitle
+	}
+	if req.Description != nil {
+		course.Description = req.Description
+	}
+	if req.ThumbnailURL != nil {
+		course.ThumbnailURL = req.ThumbnailURL
+	}
+	if req.PreviewVideoURL != nil {
+		course.PreviewVideoURL = req.PreviewVideoURL
+	}
+	if req.Price != nil {
+		course.Price = *req.Price
+	}
+	if req.IsFree != nil {
+		course.IsFree = *req.IsFree
+	}
+	if req.Level != nil {
+		course.Level = *req.Level
+	}
+	if req.DurationHours != nil {
+		course.DurationHours = req.DurationHours
+	}
+	if req.Language != nil {
+		course.Language = *req.Language
+	}
+	if req.Requirements != nil {
+		course.Requirements = req.Requirements
+	}
+	if req.WhatYouLearn != nil {
+		if whatYouLearn, err := json.Marshal(req.WhatYouLearn); err == nil {
+			course.WhatYouLearn = whatYouLearn
+		}
+	}
+	if req.Status != nil {
+		course.Status = *req.Status
+	}
+
+	if err := h.courseRepo.Update(course); err != nil {
+		h.writeCourseError(c, err)
+		return
+	}
+
+	h.logger.Info("Updated course " + courseID + " for user: " + userID.(string))
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Data:    course,
+		Message: "Course updated successfully",
+		Success: true,
+	})
+}
+
+// GetLessons lists a course's lessons in order, with content only where
+// the viewer may see it.
+func (h *CourseHandler) GetLessons(c *gin.Context) {
+	currentUserID := viewerID(c)
+
+	course, err := h.courseRepo.GetVisibleByID(c.Param("id"), currentUserID)
+	if err != nil {
+		h.writeCourseError(c, err)
+		return
+	}
+
+	lessons, err := h.courseRepo.ListLessons(course.ID)
+	if err != nil {
+		h.writeCourseError(c, err)
+		return
+	}
+
+	if lessons, _, err = h.enrollments.Outline(currentUserID, course, lessons); err != nil {
+		h.writeCourseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Data:    lessons,
+		Message: "Lessons retrieved successfully",
+		Success: true,
+	})
+}
+
+// GetLesson returns one lesson, with its content only when the viewer may
+// see it. Enrolled students opening a lesson pick up there when they resume.
+func (h *CourseHandler) GetLesson(c *gin.Context) {
+	currentUserID := viewerID(c)
+
+	course, err := h.courseRepo.GetVisibleByID(c.Param("id"), currentUserID)
+	if err != nil {
+		h.writeCourseError(c, err)
+		return
+	}
+
+	lesson, err := h.courseRepo.GetLesson(course.ID, c.Param("lessonId"))
+	if err != nil {
+		h.writeLessonError(c, err)
+		return
+	}
+	lessons, enrollment, err := h.enrollments.Outline(currentUserID, course, []models.Lesson{*lesson})
+	if err != nil {
+		h.writeCourseError(c, err)
+		return
+	}
+	if enrollment != nil {
+		if err := h.enrollments.RecordAccess(currentUserID, course.ID, lesson.ID); err != nil {
+			h.logger.Error("Failed to record lesson access: " + err.Error())
+		}
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Data:    lessons[0],
+		Message: "Lesson retrieved successfully",
+		Success: true,
+	})
+}
+
+// CreateLesson adds a lesson to a course. sortOrder is the 1-based position
+// to insert it at; 0 appends it.
+func (h *CourseHandler) CreateLesson(c *gin.Context) {
+	var req models.CreateLessonRequest
+	if !h.bind(c, &req) {
+		return
+	}
+
+	lesson := models.Lesson{
+		CourseID:        c.Param("id"),
+		Title:           req.Title,
+		Description:     req.Description,
+		Content:         req.Content,
+		VideoURL:        req.VideoURL,
+		DurationMinutes: req.DurationMinutes,
+		SortOrder:       req.SortOrder,
+		IsPreview:       req.IsPreview,
+	}
+	if req.Resources != nil {
+		if resources, err := json.Marshal(req.Resources); err == nil {
+			lesson.Resources = resources
+		}
+	}
+
+	if err := h.courseRepo.CreateLesson(&lesson); err != nil {
+		h.writeCourseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.ApiResponse{
+		Data:    lesson,
+		Message: "Lesson created successfully",
+		Success: true,
+	})
+}
+
+// UpdateLesson edits a lesson. A new sortOrder moves it to that 1-based
+// position, shifting the lessons in between.
+func (h *CourseHandler) UpdateLesson(c *gin.Context) {
+	var req models.UpdateLessonRequest
+	if !h.bind(c, &req) {
+		return
+	}
+
+	lesson, err := h.courseRepo.GetLesson(c.Param("id"), c.Param("lessonId"))
+	if err != nil {
+		h.writeLessonError(c, err)
+		return
+	}
+
+	if req.Title != nil {
+		lesson.Title = *req.Title
+	}
+	if req.Description != nil {
+		lesson.Description = req.Description
+	}
+	if req.Content != nil {
+		lesson.Content = req.Content
+	}
+	if req.VideoURL != nil {
+		lesson.VideoURL = req.VideoURL
+	}
+	if req.DurationMinutes != nil {
+		lesson.DurationMinutes = req.DurationMinutes
+	}
+	if req.SortOrder != nil {
+		lesson.SortOrder = *req.SortOrder
+	}
+	if req.IsPreview != nil {
+		lesson.IsPreview = *req.IsPreview
+	}
+	if req.Resources != nil {
+		if resources, err := json.Marshal(req.Resources); err == nil {
+			lesson.Resources = resources
+		}
+	}
+
+	if err := h.courseRepo.UpdateLesson(lesson); err != nil {
+		h.writeLessonError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Data:    lesson,
+		Message: "Lesson updated successfully",
+		Success: true,
+	})
+}
+
+func (h *CourseHandler) DeleteLesson(c *gin.Context) {
+	lessonID := c.Param("lessonId")
+
+	if err := h.courseRepo.DeleteLesson(c.Param("id"), lessonID); err != nil {
+		h.writeLessonError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Data:    gin.H{"id": lessonID},
+		Message: "Lesson deleted successfully",
+		Success: true,
+	})
+}
+
+// ReorderLessons applies a drag-and-drop reorder: the body lists every
+// lesson of the course in its new order.
+func (h *CourseHandler) ReorderLessons(c *gin.Context) {
+	var req models.ReorderLessonsRequest
+	if !h.bind(c, &req) {
+		return
+	}
+
+	courseID := c.Param("id")
+	if err := h.courseRepo.ReorderLessons(courseID, req.LessonIDs); err != nil {
+		h.writeCourseError(c, err)
+		return
+	}
+
+	lessons, err := h.courseRepo.ListLessons(courseID)
+	if err != nil {
+		h.writeCourseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Data:    lessons,
+		Message: "Lessons reordered successfully",
+		Success: true,
+	})
+}
+
+// EnrollCourse enrolls the authenticated user in a free course. Paid courses
+// are enrolled in through PurchaseCourse.
+func (h *CourseHandler) EnrollCourse(c *gin.Context) {
+	courseID := c.Param("id")
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Authentication required",
+			Success: false,
+		})
+		return
+	}
+
+	enrollment, err := h.enrollments.Enroll(userID.(string), courseID)
+	if err != nil {
+		h.writeCourseError(c, err)
+		return
+	}
+
+	h.logger.Info("User " + userID.(string) + " enrolled in course " + courseID)
+
+	c.JSON(http.StatusCreated, models.ApiResponse{
+		Data:    enrollment,
+		Message: "Enrolled successfully",
+		Success: true,
+	})
+}
+
+// PurchaseCourse buys a course for the authenticated user, who is enrolled
+// as soon as the payment completes.
+func (h *CourseHandler) PurchaseCourse(c *gin.Context) {
+	courseID := c.Param("id")
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Authentication required",
+			Success: false,
+		})
+		return
+	}
+
+	var req models.PurchaseRequest
+	if !h.bind(c, &req) {
+		return
+	}
+
+	transaction, err := h.checkout.PurchaseCourse(userID.(string), courseID, req.PaymentMethod)
+	if err != nil {
+		h.writeCourseError(c, err)
+		return
+	}
+
+	h.logger.Info("User " + userID.(string) + " purchase of course " + courseID + ": " + transaction.PaymentStatus)
+
+	switch transaction.PaymentStatus {
+	case services.PaymentFailed:
+		c.JSON(http.StatusPaymentRequired, models.ApiResponse{
+			Data:    transaction,
+			Message: "Payment was declined",
+			Success: false,
+		})
+	case services.PaymentPending:
+		c.JSON(http.StatusAccepted, models.ApiResponse{
+			Data:    transaction,
+			Message: "Payment is being processed",
+			Success: true,
+		})
+	default:
+		c.JSON(http.StatusOK, models.ApiResponse{
+			Data:    transaction,
+			Message: "Course purchased successfully",
+			Success: true,
+		})
+	}
+}
+
+// CompleteLesson marks a lesson completed for the enrolled user. Completing
+// the last one issues their certificate.
+func (h *CourseHandler) CompleteLesson(c *gin.Context) {
+	h.setLessonCompleted(c, true)
+}
+
+// UncompleteLesson clears a lesson's completion for the enrolled user.
+func (h *CourseHandler) UncompleteLesson(c *gin.Context) {
+	h.setLessonCompleted(c, false)
+}
+
+func (h *CourseHandler) setLessonCompleted(c *gin.Context, completed bool) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Authentication required",
+			Success: false,
+		})
+		return
+	}
+
+	enrollment, err := h.enrollments.CompleteLesson(userID.(string), c.Param("id"), c.Param("lessonId"), completed)
+	if err != nil {
+		h.writeLessonError(c, err)
+		return
+	}
+
+	// A failed certificate is issued again when it is next requested
+	if err := h.certificates.IssueOnCompletion(enrollment); err != nil {
+		h.logger.Error("Failed to issue certificate: " + err.Error())
+	}
+
+	message := "Lesson marked as completed"
+	if !completed {
+		message = "Lesson marked as not completed"
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Data:    enrollment,
+		Message: message,
+		Success: true,
+	})
+}
+
+// ResumeCourse returns the lesson the enrolled user should continue with.
+func (h *CourseHandler) ResumeCourse(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Authentication required",
+			Success: false,
+		})
+		return
+	}
+
+	resume, err := h.enrollments.Resume(userID.(string), c.Param("id"))
+	if err != nil {
+		h.writeCourseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Data:    resume,
+		Message: "Resume point retrieved successfully",
+		Success: true,
+	})
+}
+
+// GetMyCourses lists the courses the authenticated user is enrolled in with
+// their progress, most recently studied first.
+func (h *CourseHandler) GetMyCourses(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Authentication required",
+			Success: false,
+		})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	enrollments, total, err := h.enrollments.MyCourses(userID.(string), limit, offset)
+	if err != nil {
+		h.writeCourseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Data:    enrollments,
+		Message: "Enrolled courses retrieved successfully",
+		Success: true,
+		Meta: &models.Meta{
+			Page:        (offset / limit) + 1,
+			Limit:       limit,
+			Total:       total,
+			TotalPages:  (total + limit - 1) / limit,
+			HasNext:     offset+limit < total,
+			HasPrevious: offset > 0,
+		},
+	})
+}
+
+// GetCourseAnalytics reports how the course performs to its instructor:
+// enrollment, revenue and rating trends over a date range, where learners
+// drop off, and the course's overall figures.
+func (h *CourseHandler) GetCourseAnalytics(c *gin.Context) {
+	window, ok := parseAnalyticsRange(c)
+	if !ok {
+		return
+	}
+
+	analytics, err := h.analytics.Course(c.Param("id"), window)
+	if err != nil {
+		h.writeCourseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Data:    analytics,
+		Message: "Course analytics retrieved successfully",
+		Success: true,
+	})
+}
+
+// maxAnalyticsBuckets bounds the number of intervals a trend may have.
+const maxAnalyticsBuckets = 366
+
+// parseAnalyticsRange reads the from and to dates (YYYY-MM-DD, both
+// inclusive, the last 30 days by default) and the interval (day, week or
+// month) of an analytics request, writing a 400 response and returning
+// false when they are invalid.
+func parseAnalyticsRange(c *gin.Context) (models.AnalyticsRange, bool) {
+	invalid := func(message string) (models.AnalyticsRange, bool) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid analytics range",
+			Message: message,
+			Success: false,
+		})
+		return models.AnalyticsRange{}, false
+	}
+
+	now := time.Now().UTC()
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if toStr := c.Query("to"); toStr != "" {
+		parsed, err := time.Parse("2006-01-02", toStr)
+		if err != nil {
+			return invalid("to must be a date formatted as YYYY-MM-DD")
+		}
+		to = parsed
+	}
+
+	from := to.AddDate(0, 0, -29)
+	if fromStr := c.Query("from"); fromStr != "" {
+		parsed, err := time.Parse("2006-01-02", fromStr)
+		if err != nil {
+			return invalid("from must be a date formatted as YYYY-MM-DD")
+		}
+		from = parsed
+	}
+	if from.After(to) {
+		return invalid("from must not be after to")
+	}
+
+	window := models.AnalyticsRange{
+		From:     from,
+		To:       to.AddDate(0, 0, 1),
+		Interval: c.DefaultQuery("interval", "day"),
+	}
+
+	var buckets int
+	switch window.Interval {
+	case "day":
+		buckets = int(window.To.Sub(window.From).Hours() / 24)
+	case "week":
+		buckets = int(window.To.Sub(window.From).Hours()/(24*7)) + 1
+	case "month":
+		buckets = (to.Year()-from.Year())*12 + int(to.Month()-from.Month()) + 1
+	default:
+		return invalid("interval must be one of day, week or month")
+	}
+	if buckets > maxAnalyticsBuckets {
+		return invalid("the range spans too many intervals; use a longer interval or a shorter range")
+	}
+
+	return window, true
+}
+
+// markCourses resolves the viewer's enrollment, purchase, saved and liked
+// flags.
+func (h *CourseHandler) markCourses(viewerID string, courses []*models.Course) {
+	if err := h.enrollments.MarkCourses(viewerID, courses); err != nil {
+		h.logger.Error("Failed to resolve course enrollments: " + err.Error())
+	}
+	if err := h.library.MarkCourses(viewerID, courses); err != nil {
+		h.logger.Error("Failed to resolve course purchases: " + err.Error())
+	}
+	if err := h.saved.MarkCourses(viewerID, courses); err != nil {
+		h.logger.Error("Failed to resolve saved courses: " + err.Error())
+	}
+	if err := h.likes.MarkCourses(viewerID, courses); err != nil {
+		h.logger.Error("Failed to resolve course likes: " + err.Error())
+	}
+}
+
+// bind decodes and validates the JSON body into req, writing a 400 response
+// and returning false when it is invalid.
+func (h *CourseHandler) bind(c *gin.Context, req interface{}) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request data",
+			Message: err.Error(),
+			Success: false,
+		})
+		return false
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Validation failed",
+			Message: err.Error(),
+			Success: false,
+		})
+		return false
+	}
+
+	return true
+}
+
+func (h *CourseHandler) writeCourseError(c *gin.Context, err error) {
+	switch {
+	case err == repositories.ErrAlreadyEnrolled:
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error:   "You are already enrolled in this course",
+			Code:    "ALREADY_ENROLLED",
+			Success: false,
+		})
+	case err == repositories.ErrNotEnrolled:
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error:   "You are not enrolled in this course",
+			Code:    "NOT_ENROLLED",
+			Success: false,
+		})
+	case err == services.ErrPurchaseRequired:
+		c.JSON(http.StatusPaymentRequired, models.ErrorResponse{
+			Error:   "This course must be purchased to enroll",
+			Code:    "PURCHASE_REQUIRED",
+			Success: false,
+		})
+	case err == services.ErrOwnCourse, err == services.ErrSelfPurchase:
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error:   "You cannot enroll in your own course",
+			Code:    "SELF_PURCHASE",
+			Success: false,
+		})
+	case err == services.ErrAlreadyPurchased:
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error:   "You already own this course or have a payment for it in progress",
+			Code:    "ALREADY_PURCHASED",
+			Success: false,
+		})
+	case errors.Is(err, services.ErrPaymentProvider):
+		h.logger.Error("Checkout failed: " + err.Error())
+		c.JSON(http.StatusBadGateway, models.ErrorResponse{
+			Error:   "Payment provider unavailable, please try again",
+			Code:    "PAYMENT_PROVIDER_ERROR",
+			Success: false,
+		})
+	case err == sql.ErrNoRows:
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Course not found",
+			Success: false,
+		})
+	case err == repositories.ErrQuizNotPassed:
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error:   "Pass the lesson's quiz to complete it",
+			Code:    "QUIZ_NOT_PASSED",
+			Success: false,
+		})
+	case err == repositories.ErrCategoryNotFound, err == repositories.ErrCourseHasNoLessons, err == repositories.ErrLessonOrderInvalid:
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid course",
+			Message: err.Error(),
+			Success: false,
+		})
+	default:
+		h.logger.Error("Course operation failed: " + err.Error())
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal server error",
+			Success: false,
+		})
+	}
+}
+
+func (h *CourseHandler) writeLessonError(c *gin.Context, err error) {
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Lesson not found",
+			Success: false,
+		})
+		return
+	}
+	h.writeCourseError(c, err)
+}
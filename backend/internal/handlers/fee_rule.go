@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"viport-backend/internal/models"
+	"viport-backend/internal/repositories"
+	"viport-backend/internal/services"
+	"viport-backend/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// FeeRuleHandler serves the admin endpoints managing platform fee rules.
+type FeeRuleHandler struct {
+	logger     logger.Logger
+	validate   *validator.Validate
+	feeService *services.FeeService
+}
+
+func NewFeeRuleHandler(db *sql.DB, logger logger.Logger) *FeeRuleHandler {
+	return &FeeRuleHandler{
+		logger:     logger,
+		validate:   validator.New(),
+		feeService: services.NewFeeService(db),
+	}
+}
+
+func (h *FeeRuleHandler) GetFeeRules(c *gin.Context) {
+	rules, err := h.feeService.List()
+	if err != nil {
+		h.writeFeeRuleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Data:    rules,
+		Message: "Fee rules retrieved successfully",
+		Success: true,
+	})
+}
+
+func (h *FeeRuleHandler) CreateFeeRule(c *gin.Context) {
+	var req models.FeeRuleRequest
+	if !h.bind(c, &req) {
+		return
+	}
+
+	rule, err := h.feeService.Create(req)
+	if err != nil {
+		h.writeFeeRuleError(c, err)
+		return
+	}
+
+	h.logger.Info("Created fee rule " + rule.ID + " (" + rule.Name + ")")
+
+	c.JSON(http.StatusCreated, models.ApiResponse{
+		Data:    rule,
+		Message: "Fee rule created successfully",
+		Success: true,
+	})
+}
+
+// UpdateFeeRule replaces a fee rule. Rules are retired by setting isActive
+// to false; transactions keep referring to the rule that priced them.
+func (h *FeeRuleHandler) UpdateFeeRule(c *gin.Context) {
+	var req models.FeeRuleRequest
+	if !h.bind(c, &req) {
+		return
+	}
+
+	rule, err := h.feeService.Update(c.Param("id"), req)
+	if err != nil {
+		h.writeFeeRuleError(c, err)
+		return
+	}
+
+	h.logger.Info("Updated fee rule " + rule.ID + " (" + rule.Name + ")")
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Data:    rule,
+		Message: "Fee rule updated successfully",
+		Success: true,
+	})
+}
+
+// bind decodes and validates the JSON body into req, writing a 400 response
+// and returning false when it is invalid.
+func (h *FeeRuleHandler) bind(c *gin.Context, req interface{}) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request data",
+			Message: err.Error(),
+			Success: false,
+		})
+		return false
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Validation failed",
+			Message: err.Error(),
+			Success: false,
+		})
+		return false
+	}
+
+	return true
+}
+
+func (h *FeeRuleHandler) writeFeeRuleError(c *gin.Context, err error) {
+	switch err {
+	case sql.ErrNoRows:
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Fee rule not found",
+			Success: false,
+		})
+	case repositories.ErrCategoryNotFound, services.ErrInvalidFeeWindow:
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid fee rule",
+			Message: err.Error(),
+			Success: false,
+		})
+	default:
+		h.logger.Error("Fee rule operation failed: " + err.Error())
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal server error",
+			Success: false,
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"viport-backend/internal/models"
+	"viport-backend/internal/repositories"
+	"viport-backend/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LedgerHandler struct {
+	logger     logger.Logger
+	ledgerRepo *repositories.LedgerRepository
+}
+
+func NewLedgerHandler(db *sql.DB, logger logger.Logger) *LedgerHandler {
+	return &LedgerHandler{
+		logger:     logger,
+		ledgerRepo: repositories.NewLedgerRepository(db),
+	}
+}
+
+// GetMyBalance returns what the platform owes the authenticated creator,
+// per currency, in minor units.
+func (h *LedgerHandler) GetMyBalance(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Authentication required",
+			Success: false,
+		})
+		return
+	}
+
+	h.writeBalances(c, userID.(string))
+}
+
+// GetUserBalance returns a creator's balances for admins.
+func (h *LedgerHandler) GetUserBalance(c *gin.Context) {
+	h.writeBalances(c, c.Param("id"))
+}
+
+func (h *LedgerHandler) writeBalances(c *gin.Context, userID string) {
+	balances, err := h.ledgerRepo.Balances(userID)
+	if err != nil {
+		h.writeLedgerError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Data:    balances,
+		Message: "Balance retrieved successfully",
+		Success: true,
+	})
+}
+
+// GetMyLedger lists the entries of the authenticated creator's account.
+func (h *LedgerHandler) GetMyLedger(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Authentication required",
+			Success: false,
+		})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	entries, total, err := h.ledgerRepo.ListEntries(userID.(string), limit, offset)
+	if err != nil {
+		h.writeLedgerError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Data:    entries,
+		Message: "Ledger entries retrieved successfully",
+		Success: true,
+		Meta: &models.Meta{
+			Page:        (offset / limit) + 1,
+			Limit:       limit,
+			Total:       total,
+			TotalPages:  (total + limit - 1) / limit,
+			HasNext:     offset+limit < total,
+			HasPrevious: offset > 0,
+		},
+	})
+}
+
+// VerifyLedger runs the ledger invariant checks for admins. Imbalances are
+// reported in the body; the request itself still succeeds.
+func (h *LedgerHandler) VerifyLedger(c *gin.Context) {
+	report, err := h.ledgerRepo.Verify()
+	if err != nil {
+		h.writeLedgerError(c, err)
+		return
+	}
+
+	message := "Ledger balances"
+	if !report.Balanced {
+		message = "Ledger imbalances found"
+		h.logger.Error("Ledger verification found " + strconv.Itoa(len(report.Imbalances)) + " imbalances")
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Data:    report,
+		Message: message,
+		Success: true,
+	})
+}
+
+func (h *LedgerHandler) writeLedgerError(c *gin.Context, err error) {
+	h.logger.Error("Ledger operation failed: " + err.Error())
+	c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+		Error:   "Internal server error",
+		Success: false,
+	})
+}
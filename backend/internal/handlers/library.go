@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"viport-backend/internal/models"
+	"viport-backend/internal/services"
+	"viport-backend/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LibraryHandler struct {
+	logger  logger.Logger
+	library *services.LibraryService
+}
+
+func NewLibraryHandler(db *sql.DB, logger logger.Logger) *LibraryHandler {
+	return &LibraryHandler{
+		logger:  logger,
+		library: services.NewLibraryService(db),
+	}
+}
+
+// GetLibrary lists the authenticated user's completed purchases, newest
+// first. ?type=product or ?type=course narrows the list.
+func (h *LibraryHandler) GetLibrary(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Authentication required",
+			Success: false,
+		})
+		return
+	}
+
+	itemType := c.Query("type")
+	if itemType != "" && itemType != "product" && itemType != "course" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid item type",
+			Message: "type must be product or course",
+			Success: false,
+		})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	items, total, err := h.library.Library(userID.(string), itemType, limit, offset)
+	if err != nil {
+		h.logger.Error("Failed to get library: " + err.Error())
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal server error",
+			Success: false,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Data:    items,
+		Message: "Library retrieved successfully",
+		Success: true,
+		Meta: &models.Meta{
+			Page:        (offset / limit) + 1,
+			Limit:       limit,
+			Total:       total,
+			TotalPages:  (total + limit - 1) / limit,
+			HasNext:     offset+limit < total,
+			HasPrevious: offset > 0,
+		},
+	})
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"viport-backend/internal/models"
+	"viport-backend/internal/repositories"
+	"viport-backend/internal/services"
+	"viport-backend/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LikeHandler serves the like/unlike endpoints shared by products, courses
+// and comments. Posts keep their own handlers on PostHandler.
+type LikeHandler struct {
+	logger      logger.Logger
+	likeService *services.LikeService
+	commentRepo *repositories.CommentRepository
+	postRepo    *repositories.PostRepository
+}
+
+func NewLikeHandler(db *sql.DB, logger logger.Logger) *LikeHandler {
+	return &LikeHandler{
+		logger:      logger,
+		likeService: services.NewLikeService(db),
+		commentRepo: repositories.NewCommentRepository(db),
+		postRepo:    repositories.NewPostRepository(db),
+	}
+}
+
+// Like returns a handler that likes the :id item of the given type.
+func (h *LikeHandler) Like(likeableType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		h.toggle(c, likeableType, true)
+	}
+}
+
+// Unlike returns a handler that unlikes the :id item of the given type.
+func (h *LikeHandler) Unlike(likeableType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		h.toggle(c, likeableType, false)
+	}
+}
+
+func (h *LikeHandler) toggle(c *gin.Context, likeableType string, like bool) {
+	itemID := c.Param("id")
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Authentication required",
+			Success: false,
+		})
+		return
+	}
+
+	if likeableType == "comment" {
+		if err := h.checkCommentVisible(itemID, userID.(string)); err != nil {
+			writeLikeError(c, h.logger, likeableType, err)
+			return
+		}
+	}
+
+	var likeCount int
+	var err error
+	if like {
+		likeCount, err = h.likeService.Like(userID.(string), likeableType, itemID)
+	} else {
+		likeCount, err = h.likeService.Unlike(userID.(string), likeableType, itemID)
+	}
+	if err != nil {
+		writeLikeError(c, h.logger, likeableType, err)
+		return
+	}
+
+	message := capitalize(likeableType) + " liked successfully"
+	if !like {
+		message = capitalize(likeableType) + " unliked successfully"
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Data: gin.H{
+			likeableType + "Id": itemID,
+			"isLiked":           like,
+			"likeCount":         likeCount,
+		},
+		Message: message,
+		Success: true,
+	})
+}
+
+// checkCommentVisible returns sql.ErrNoRows unless the comment exists and
+// the user may see the post it was made on.
+func (h *LikeHandler) checkCommentVisible(commentID, userID string) error {
+	comment, err := h.commentRepo.GetByID(commentID)
+	if err != nil {
+		return err
+	}
+	if comment.CommentableType != "post" {
+		return nil
+	}
+	return h.postRepo.CanView(comment.CommentableID, userID)
+}
+
+func writeLikeError(c *gin.Context, log logger.Logger, likeableType string, err error) {
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   capitalize(likeableType) + " not found",
+			Success: false,
+		})
+		return
+	}
+
+	log.Error("Failed to update " + likeableType + " like: " + err.Error())
+	c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+		Error:   "Internal server error",
+		Success: false,
+	})
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	b := []byte(s)
+	if b[0] >= 'a' && b[0] <= 'z' {
+		b[0] -= 'a' - 'A'
+	}
+	return string(b)
+}
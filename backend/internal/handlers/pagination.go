@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"viport-backend/internal/models"
+	"viport-backend/pkg/pagination"
+
+	"github.com/gin-gonic/gin"
+)
+
+// pageRequest is the limit, offset and optional keyset cursor of a list
+// request. Offset is ignored once a cursor is supplied. Kind names the list
+// its cursors belong to.
+type pageRequest struct {
+	Kind   string
+	Limit  int
+	Offset int
+	Cursor *pagination.Cursor
+}
+
+// parsePageRequest reads the limit, offset and cursor query parameters of a
+// request for the kind of list. It writes a 400 response and returns
+// ok=false for a malformed or tampered cursor, or one issued for another
+// kind of list.
+func parsePageRequest(c *gin.Context, cursors *pagination.Codec, kind string) (pageRequest, bool) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	page := pageRequest{Kind: kind, Limit: limit, Offset: offset}
+
+	if token := c.Query("cursor"); token != "" {
+		cursor, err := cursors.Decode(token)
+		if err == nil && cursor.Kind != kind {
+			err = pagination.ErrInvalidCursor
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Invalid cursor",
+				Message: "The pagination cursor is malformed or has been tampered with",
+				Success: false,
+			})
+			return page, false
+		}
+		page.Cursor = cursor
+		page.Offset = 0
+	}
+
+	return page, true
+}
+
+// paginate trims rows fetched with Limit+1 down to the requested page and
+// builds the response Meta, including cursors for the neighbouring pages.
+// key returns the sort value and ID of a row. Page numbers are only
+// meaningful for offset requests and are left at zero for cursor requests.
+func paginate[T any](rows []T, page pageRequest, total int, cursors *pagination.Codec, sortBy, sortOrder string, key func(T) (string, string)) ([]T, *models.Meta) {
+	hasMore := len(rows) > page.Limit
+	backward := page.Cursor != nil && page.Cursor.Backward
+	if hasMore {
+		if backward {
+			// The extra row sits before the page once rows are in list order
+			rows = rows[1:]
+		} else {
+			rows = rows[:page.Limit]
+		}
+	}
+
+	meta := &models.Meta{
+		Limit:      page.Limit,
+		Total:      total,
+		TotalPages: (total + page.Limit - 1) / page.Limit,
+	}
+	if page.Cursor == nil {
+		meta.Page = (page.Offset / page.Limit) + 1
+	}
+
+	if backward {
+		meta.HasNext = true
+		meta.HasPrevious = hasMore
+	} else {
+		meta.HasNext = hasMore
+		meta.HasPrevious = page.Cursor != nil || page.Offset > 0
+	}
+
+	if len(rows) == 0 {
+		return rows, meta
+	}
+
+	cursorAt := func(row T, backward bool) string {
+		value, id := key(row)
+		return cursors.Encode(pagination.Cursor{
+			Kind:      page.Kind,
+			SortBy:    sortBy,
+			SortOrder: sortOrder,
+			Value:     value,
+			ID:        id,
+			Backward:  backward,
+		})
+	}
+	if meta.HasNext {
+		meta.NextCursor = cursorAt(rows[len(rows)-1], false)
+	}
+	if meta.HasPrevious {
+		meta.PrevCursor = cursorAt(rows[0], true)
+	}
+
+	return rows, meta
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"viport-backend/internal/models"
+	"viport-backend/internal/services"
+	"viport-backend/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// PaymentSignatureHeader carries the HMAC signature of webhook deliveries.
+const PaymentSignatureHeader = "X-Payment-Signature"
+
+// maxWebhookBodySize bounds the webhook payloads read into memory.
+const maxWebhookBodySize = 1 << 20
+
+type PaymentHandler struct {
+	logger   logger.Logger
+	validate *validator.Validate
+	webhooks *services.PaymentWebhookService
+}
+
+func NewPaymentHandler(db *sql.DB, logger logger.Logger, webhookSecret string) *PaymentHandler {
+	return &PaymentHandler{
+		logger:   logger,
+		validate: validator.New(),
+		webhooks: services.NewPaymentWebhookService(db, webhookSecret),
+	}
+}
+
+// Webhook receives payment provider events. Every authentic, well-formed
+// event is acknowledged with 200, including duplicates and events that are
+// ignored, so the provider only redelivers after server errors.
+func (h *PaymentHandler) Webhook(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxWebhookBodySize)
+	body, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request body",
+			Success: false,
+		})
+		return
+	}
+
+	if err := h.webhooks.VerifySignature(c.GetHeader(PaymentSignatureHeader), body); err != nil {
+		if err == services.ErrWebhookDisabled {
+			h.logger.Error("Rejected payment webhook: PAYMENT_WEBHOOK_SECRET is not set")
+			c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{
+				Error:   "Payment webhooks are not configured",
+				Success: false,
+			})
+			return
+		}
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Invalid signature",
+			Success: false,
+		})
+		return
+	}
+
+	var event models.PaymentEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid event payload",
+			Message: err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	if err := h.validate.Struct(&event); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Validation failed",
+			Message: err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	outcome, transaction, err := h.webhooks.HandleEvent(event)
+	if err != nil {
+		h.logger.Error("Failed to handle payment event " + event.ID + ": " + err.Error())
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal server error",
+			Success: false,
+		})
+		return
+	}
+
+	message := "Payment event " + event.ID + " (" + event.Type + ") " + outcome
+	if transaction != nil {
+		message += ", transaction " + transaction.ID + " is " + transaction.PaymentStatus
+	}
+	h.logger.Info(message)
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Data:    gin.H{"eventId": event.ID, "outcome": outcome},
+		Message: "Event received",
+		Success: true,
+	})
+}
@@ -0,0 +1,391 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"viport-backend/internal/models"
+	"viport-backend/internal/services"
+	"viport-backend/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type PayoutHandler struct {
+	logger        logger.Logger
+	validate      *validator.Validate
+	payoutService *services.PayoutService
+}
+
+func NewPayoutHandler(db *sql.DB, logger logger.Logger, payouts services.PayoutProvider) *PayoutHandler {
+	return &PayoutHandler{
+		logger:        logger,
+		validate:      validator.New(),
+		payoutService: services.NewPayoutService(db, payouts),
+	}
+}
+
+// GetMyPayouts lists the authenticated creator's payout history.
+func (h *PayoutHandler) GetMyPayouts(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Authentication required",
+			Success: false,
+		})
+		return
+	}
+
+	h.listPayouts(c, userID.(string))
+}
+
+// GetAllPayouts lists every creator's payouts for admins, optionally
+// filtered by status.
+func (h *PayoutHandler) GetAllPayouts(c *gin.Context) {
+	h.listPayouts(c, "")
+}
+
+func (h *PayoutHandler) listPayouts(c *gin.Context, userID string) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	payouts, total, err := h.payoutService.List(userID, c.Query("status"), limit, offset)
+	if err != nil {
+		h.writePayoutError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Data:    payouts,
+		Message: "Payouts retrieved successfully",
+		Success: true,
+		Meta: &models.Meta{
+			Page:        (offset / limit) + 1,
+			Limit:       limit,
+			Total:       total,
+			TotalPages:  (total + limit - 1) / limit,
+			HasNext:     offset+limit < total,
+			HasPrevious: offset > 0,
+		},
+	})
+}
+
+func (h *PayoutHandler) GetPayout(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Authentication required",
+			Success: false,
+		})
+		return
+	}
+
+	payout, err := h.payoutService.Get(userID.(string), isAdmin(c), c.Param("id"))
+	if err != nil {
+		h.writePayoutError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Data:    payout,
+		Message: "Payout retrieved successfully",
+		Success: true,
+	})
+}
+
+func (h *PayoutHandler) GetPayoutMethods(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Authentication required",
+			Success: false,
+		})
+		return
+	}
+
+	methods, err := h.payoutService.ListMethods(userID.(string))
+	if err != nil {
+		h.writePayoutError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Data:    methods,
+		Message: "Payout methods retrieved successfully",
+		Success: true,
+	})
+}
+
+func (h *PayoutHandler) CreatePayoutMethod(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Authentication required",
+			Success: false,
+		})
+		return
+	}
+
+	var req models.CreatePayoutMethodRequest
+	if !h.bind(c, &req) {
+		return
+	}
+
+	method, err := h.payoutService.AddMethod(userID.(string), req)
+	if err != nil {
+		h.writePayoutError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.ApiResponse{
+		Data:    method,
+		Message: "Payout method added successfully",
+		Success: true,
+	})
+}
+
+func (h *PayoutHandler) SetDefaultPayoutMethod(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Authentication required",
+			Success: false,
+		})
+		return
+	}
+
+	if err := h.payoutService.SetDefaultMethod(userID.(string), c.Param("id")); err != nil {
+		h.writePayoutError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Data:    gin.H{"id": c.Param("id"), "isDefault": true},
+		Message: "Default payout method updated",
+		Success: true,
+	})
+}
+
+func (h *PayoutHandler) DeletePayoutMethod(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Authentication required",
+			Success: false,
+		})
+		return
+	}
+
+	if err := h.payoutService.RemoveMethod(userID.(string), c.Param("id")); err != nil {
+		h.writePayoutError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Message: "Payout method removed successfully",
+		Success: true,
+	})
+}
+
+func (h *PayoutHandler) GetPayoutSettings(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Authentication required",
+			Success: false,
+		})
+		return
+	}
+
+	settings, err := h.payoutService.Settings(userID.(string))
+	if err != nil {
+		h.writePayoutError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Data:    settings,
+		Message: "Payout settings retrieved successfully",
+		Success: true,
+	})
+}
+
+func (h *PayoutHandler) UpdatePayoutSettings(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Authentication required",
+			Success: false,
+		})
+		return
+	}
+
+	var req models.UpdatePayoutSettingsRequest
+	if !h.bind(c, &req) {
+		return
+	}
+
+	settings, err := h.payoutService.SetMinimum(userID.(string), req.MinimumAmount)
+	if err != nil {
+		h.writePayoutError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Data:    settings,
+		Message: "Payout settings updated successfully",
+		Success: true,
+	})
+}
+
+// HoldPayout stops a pending payout from being sent.
+func (h *PayoutHandler) HoldPayout(c *gin.Context) {
+	var req models.HoldPayoutRequest
+	if c.Request.ContentLength > 0 && !h.bind(c, &req) {
+		return
+	}
+
+	payout, err := h.payoutService.Hold(c.Param("id"), req.Reason)
+	if err != nil {
+		h.writePayoutError(c, err)
+		return
+	}
+
+	h.logger.Info("Held payout " + payout.ID)
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Data:    payout,
+		Message: "Payout held",
+		Success: true,
+	})
+}
+
+func (h *PayoutHandler) ReleasePayout(c *gin.Context) {
+	payout, err := h.payoutService.Release(c.Param("id"))
+	if err != nil {
+		h.writePayoutError(c, err)
+		return
+	}
+
+	h.logger.Info("Released payout " + payout.ID)
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Data:    payout,
+		Message: "Payout released",
+		Success: true,
+	})
+}
+
+// HoldCreatorPayouts stops new payouts to the :id creator.
+func (h *PayoutHandler) HoldCreatorPayouts(c *gin.Context) {
+	var req models.HoldPayoutRequest
+	if c.Request.ContentLength > 0 && !h.bind(c, &req) {
+		return
+	}
+
+	h.setCreatorHold(c, true, req.Reason)
+}
+
+func (h *PayoutHandler) ReleaseCreatorPayouts(c *gin.Context) {
+	h.setCreatorHold(c, false, nil)
+}
+
+func (h *PayoutHandler) setCreatorHold(c *gin.Context, onHold bool, reason *string) {
+	settings, err := h.payoutService.HoldCreator(c.Param("id"), onHold, reason)
+	if err != nil {
+		h.writePayoutError(c, err)
+		return
+	}
+
+	message := "Creator payouts held"
+	if !onHold {
+		message = "Creator payouts released"
+	}
+	h.logger.Info(message + ": " + settings.UserID)
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Data:    settings,
+		Message: message,
+		Success: true,
+	})
+}
+
+// RunPayouts runs a payout batch immediately instead of waiting for the
+// scheduler.
+func (h *PayoutHandler) RunPayouts(c *gin.Context) {
+	batch, err := h.payoutService.RunBatch()
+	if batch == nil {
+		h.writePayoutError(c, err)
+		return
+	}
+	if err != nil {
+		h.logger.Error("Payout batch " + batch.ID + " could not send every payout: " + err.Error())
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Data:    batch,
+		Message: "Payout batch completed",
+		Success: true,
+	})
+}
+
+// bind decodes and validates the JSON body into req, writing a 400 response
+// and returning false when it is invalid.
+func (h *PayoutHandler) bind(c *gin.Context, req interface{}) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request data",
+			Message: err.Error(),
+			Success: false,
+		})
+		return false
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Validation failed",
+			Message: err.Error(),
+			Success: false,
+		})
+		return false
+	}
+
+	return true
+}
+
+func (h *PayoutHandler) writePayoutError(c *gin.Context, err error) {
+	switch {
+	case err == sql.ErrNoRows:
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Not found",
+			Success: false,
+		})
+	case err == services.ErrPayoutNotPending, err == services.ErrPayoutNotHeld:
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error:   err.Error(),
+			Success: false,
+		})
+	case errors.Is(err, services.ErrPayoutProvider):
+		h.logger.Error("Payout failed: " + err.Error())
+		c.JSON(http.StatusBadGateway, models.ErrorResponse{
+			Error:   "Payout provider unavailable, please try again",
+			Code:    "PAYOUT_PROVIDER_ERROR",
+			Success: false,
+		})
+	default:
+		h.logger.Error("Payout operation failed: " + err.Error())
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal server error",
+			Success: false,
+		})
+	}
+}
@@ -2,88 +2,120 @@ package handlers
 
 import (
 	"database/sql"
+	"encoding/json"
+	"errors"
+	"mime"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 	"viport-backend/internal/models"
+	"viport-backend/internal/repositories"
+	"viport-backend/internal/services"
+	"viport-backend/pkg/downloads"
 	"viport-backend/pkg/logger"
+	"viport-backend/pkg/pagination"
+	"viport-backend/pkg/storage"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
-	"github.com/google/uuid"
 )
 
 type ProductHandler struct {
-	db       *sql.DB
-	logger   logger.Logger
-	validate *validator.Validate
+	db          *sql.DB
+	logger      logger.Logger
+	validate    *validator.Validate
+	productRepo *repositories.ProductRepository
+	likeService *services.LikeService
+	library     *services.LibraryService
+	saved       *services.SavedService
+	tagService  *services.TagService
+	checkout    *services.CheckoutService
+	downloads   *services.DownloadService
+	cursors     *pagination.Codec
 }
 
-func NewProductHandler(db *sql.DB, logger logger.Logger) *ProductHandler {
+func NewProductHandler(db *sql.DB, logger logger.Logger, cursors *pagination.Codec, payments services.PaymentProvider, signer *downloads.Signer, files *storage.Fetcher) *ProductHandler {
 	return &ProductHandler{
-		db:       db,
-		logger:   logger,
-		validate: validator.New(),
+		db:          db,
+		logger:      logger,
+		validate:    validator.New(),
+		productRepo: repositories.NewProductRepository(db),
+		likeService: services.NewLikeService(db),
+		library:     services.NewLibraryService(db),
+		saved:       services.NewSavedService(db),
+		tagService:  services.NewTagService(db),
+		checkout:    services.NewCheckoutService(db, payments),
+		downloads:   services.NewDownloadService(db, signer, files),
+		cursors:     cursors,
 	}
 }
 
 func (h *ProductHandler) GetProducts(c *gin.Context) {
-	// Parse query parameters
-	limitStr := c.DefaultQuery("limit", "20")
-	offsetStr := c.DefaultQuery("offset", "0")
-	categoryID := c.Query("categoryId")
-	minPriceStr := c.Query("minPrice")
-	maxPriceStr := c.Query("maxPrice")
-	isFreeStr := c.Query("isFree")
-	search := c.Query("search")
-	sortBy := c.DefaultQuery("sortBy", "created_at")
-	sortOrder := c.DefaultQuery("sortOrder", "desc")
-
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil || limit <= 0 || limit > 100 {
-		limit = 20
-	}
-
-	offset, err := strconv.Atoi(offsetStr)
-	if err != nil || offset < 0 {
-		offset = 0
-	}
-
-	var minPrice, maxPrice *float64
-	if minPriceStr != "" {
-		if mp, err := strconv.ParseFloat(minPriceStr, 64); err == nil {
-			minPrice = &mp
-		}
+	filter, page, ok := h.parseProductFilter(c)
+	if !ok {
+		return
 	}
-	if maxPriceStr != "" {
-		if mp, err := strconv.ParseFloat(maxPriceStr, 64); err == nil {
-			maxPrice = &mp
-		}
+
+	h.listProducts(c, filter, page)
+}
+
+// GetProductsByTag lists the products carrying the :slug tag, with the same
+// filters and paging as GetProducts.
+func (h *ProductHandler) GetProductsByTag(c *gin.Context) {
+	filter, page, ok := h.parseProductFilter(c)
+	if !ok {
+		return
 	}
 
-	var isFree *bool
-	if isFreeStr != "" {
-		if free, err := strconv.ParseBool(isFreeStr); err == nil {
-			isFree = &free
-		}
+	tag, err := h.tagService.GetBySlug(c.Param("slug"))
+	if err != nil {
+		writeTagError(c, h.logger, err)
+		return
 	}
+	filter.TagIDs = []string{tag.ID}
+
+	h.listProducts(c, filter, page)
+}
 
+func (h *ProductHandler) listProducts(c *gin.Context, filter models.ProductFilter, page pageRequest) {
 	// Get current user ID if authenticated
-	var currentUserID string
 	if userID, exists := c.Get("userID"); exists {
-		currentUserID = userID.(string)
+		filter.ViewerID = userID.(string)
 	}
 
-	// Generate dummy products
-	products := h.generateDummyProducts(limit, offset, categoryID, minPrice, maxPrice, isFree, search, sortBy, sortOrder, currentUserID)
+	products, total, err := h.productRepo.List(filter)
+	if err != nil {
+		h.logger.Error("Failed to list products: " + err.Error())
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal server error",
+			Success: false,
+		})
+		return
+	}
 
-	meta := &models.Meta{
-		Page:        (offset / limit) + 1,
-		Limit:       limit,
-		Total:       250, // dummy total
-		TotalPages:  13,  // dummy total pages
-		HasNext:     offset + limit < 250,
-		HasPrevious: offset > 0,
+	products, meta := paginate(products, page, total, h.cursors, filter.SortBy, filter.SortOrder,
+		func(product *models.Product) (string, string) {
+			switch filter.SortBy {
+			case "price":
+				return strconv.FormatFloat(product.Price, 'f', -1, 64), product.ID
+			case "rating_average":
+				return strconv.FormatFloat(product.RatingAverage, 'f', -1, 64), product.ID
+			case "download_count":
+				return strconv.Itoa(product.DownloadCount), product.ID
+			default:
+				return product.CreatedAt.Format(time.RFC3339Nano), product.ID
+			}
+		})
+
+	if err := h.likeService.MarkProducts(filter.ViewerID, products); err != nil {
+		h.logger.Error("Failed to resolve product likes: " + err.Error())
+	}
+	if err := h.library.MarkProducts(filter.ViewerID, products); err != nil {
+		h.logger.Error("Failed to resolve product purchases: " + err.Error())
+	}
+	if err := h.saved.MarkProducts(filter.ViewerID, products); err != nil {
+		h.logger.Error("Failed to resolve saved products: " + err.Error())
 	}
 
 	c.JSON(http.StatusOK, models.ApiResponse{
@@ -94,6 +126,70 @@ func (h *ProductHandler) GetProducts(c *gin.Context) {
 	})
 }
 
+// parseProductFilter reads the catalog query parameters. A cursor carries its
+// own sort, which takes precedence over sortBy and sortOrder.
+func (h *ProductHandler) parseProductFilter(c *gin.Context) (models.ProductFilter, pageRequest, bool) {
+	page, ok := parsePageRequest(c, h.cursors, "products")
+	if !ok {
+		return models.ProductFilter{}, page, false
+	}
+
+	filter := models.ProductFilter{
+		Limit:     page.Limit,
+		Offset:    page.Offset,
+		Cursor:    page.Cursor,
+		SortBy:    c.DefaultQuery("sortBy", "created_at"),
+		SortOrder: c.DefaultQuery("sortOrder", "desc"),
+	}
+	if page.Cursor != nil {
+		filter.SortBy, filter.SortOrder = page.Cursor.SortBy, page.Cursor.SortOrder
+	}
+	switch filter.SortBy {
+	case "created_at", "price", "rating_average", "download_count":
+	default:
+		filter.SortBy = "created_at"
+	}
+	if filter.SortOrder != "asc" {
+		filter.SortOrder = "desc"
+	}
+
+	if userID := c.Query("userId"); userID != "" {
+		filter.UserID = &userID
+	}
+	if categoryID := c.Query("categoryId"); categoryID != "" {
+		filter.CategoryID = &categoryID
+	}
+	if status := c.Query("status"); status != "" {
+		filter.Status = &status
+	}
+	if licenseType := c.Query("licenseType"); licenseType != "" {
+		filter.LicenseType = &licenseType
+	}
+	if minPriceStr := c.Query("minPrice"); minPriceStr != "" {
+		if minPrice, err := strconv.ParseFloat(minPriceStr, 64); err == nil {
+			filter.MinPrice = &minPrice
+		}
+	}
+	if maxPriceStr := c.Query("maxPrice"); maxPriceStr != "" {
+		if maxPrice, err := strconv.ParseFloat(maxPriceStr, 64); err == nil {
+			filter.MaxPrice = &maxPrice
+		}
+	}
+	if isFreeStr := c.Query("isFree"); isFreeStr != "" {
+		if isFree, err := strconv.ParseBool(isFreeStr); err == nil {
+			filter.IsFree = &isFree
+		}
+	}
+	if tagIDs := c.Query("tagIds"); tagIDs != "" {
+		filter.TagIDs = strings.Split(tagIDs, ",")
+	}
+	if search := c.Query("search"); search != "" {
+		filter.Search = &search
+	}
+
+	return filter, page, true
+}
+
 func (h *ProductHandler) GetProduct(c *gin.Context) {
 	productID := c.Param("id")
 	if productID == "" {
@@ -110,8 +206,21 @@ func (h *ProductHandler) GetProduct(c *gin.Context) {
 		currentUserID = userID.(string)
 	}
 
-	// Generate dummy product
-	product := h.generateDummyProduct(productID, currentUserID)
+	product, err := h.productRepo.GetVisibleByID(productID, currentUserID)
+	if err != nil {
+		h.writeProductError(c, err)
+		return
+	}
+
+	if err := h.likeService.MarkProducts(currentUserID, []*models.Product{product}); err != nil {
+		h.logger.Error("Failed to resolve product likes: " + err.Error())
+	}
+	if err := h.library.MarkProducts(currentUserID, []*models.Product{product}); err != nil {
+		h.logger.Error("Failed to resolve product purchases: " + err.Error())
+	}
+	if err := h.saved.MarkProducts(currentUserID, []*models.Product{product}); err != nil {
+		h.logger.Error("Failed to resolve saved products: " + err.Error())
+	}
 
 	c.JSON(http.StatusOK, models.ApiResponse{
 		Data:    product,
@@ -149,12 +258,7 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 		return
 	}
 
-	// Create product
-	productID := uuid.New().String()
-	now := time.Now()
-
 	product := models.Product{
-		ID:               productID,
 		UserID:           userID.(string),
 		CategoryID:       req.CategoryID,
 		Title:            req.Title,
@@ -170,23 +274,40 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 		FileFormat:       req.FileFormat,
 		Compatibility:    req.Compatibility,
 		Requirements:     req.Requirements,
+		DownloadLimit:    req.DownloadLimit,
 		Status:           "draft",
-		CreatedAt:        now,
-		UpdatedAt:        now,
-		User: &models.User{
-			ID:          userID.(string),
-			Username:    "johndoe",
-			DisplayName: stringPtr("John Doe"),
-			AvatarURL:   stringPtr("https://images.unsplash.com/photo-1472099645785-5658abf4ff4e?w=150"),
-			IsVerified:  true,
-			IsCreator:   true,
-		},
+		Tags:             services.TagsFromNames(req.TagNames),
+	}
+	if req.PreviewImages != nil {
+		if previewImages, err := json.Marshal(req.PreviewImages); err == nil {
+			product.PreviewImages = previewImages
+		}
+	}
+	if req.FileURLs != nil {
+		if fileURLs, err := json.Marshal(req.FileURLs); err == nil {
+			product.FileURLs = fileURLs
+		}
 	}
 
-	h.logger.Info("Creating product for user: " + userID.(string))
+	if err := h.productRepo.Create(&product); err != nil {
+		h.logger.Error("Failed to create product: " + err.Error())
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal server error",
+			Success: false,
+		})
+		return
+	}
+
+	h.logger.Info("Created product " + product.ID + " for user: " + userID.(string))
+
+	created, err := h.productRepo.GetByID(product.ID)
+	if err != nil {
+		h.logger.Error("Failed to reload product: " + err.Error())
+		created = &product
+	}
 
 	c.JSON(http.StatusCreated, models.ApiResponse{
-		Data:    product,
+		Data:    created,
 		Message: "Product created successfully",
 		Success: true,
 	})
@@ -213,10 +334,92 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 		return
 	}
 
-	// For demo, just return success
-	h.logger.Info("Updating product " + productID + " for user: " + userID.(string))
+	if err := h.validate.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Validation failed",
+			Message: err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	product, err := h.productRepo.GetByID(productID)
+	if err != nil {
+		h.writeProductError(c, err)
+		return
+	}
+
+	if req.CategoryID != nil {
+		product.CategoryID = req.CategoryID
+	}
+	if req.Title != nil {
+		product.Title = *req.Title
+	}
+	if req.Description != nil {
+		product.Description = req.Description
+	}
+	if req.ShortDescription != nil {
+		product.ShortDescription = req.ShortDescription
+	}
+	if req.ThumbnailURL != nil {
+		product.ThumbnailURL = req.ThumbnailURL
+	}
+	if req.PreviewImages != nil {
+		if previewImages, err := json.Marshal(req.PreviewImages); err == nil {
+			product.PreviewImages = previewImages
+		}
+	}
+	if req.FileURLs != nil {
+		if fileURLs, err := json.Marshal(req.FileURLs); err == nil {
+			product.FileURLs = fileURLs
+		}
+	}
+	if req.DemoURL != nil {
+		product.DemoURL = req.DemoURL
+	}
+	if req.Price != nil {
+		product.Price = *req.Price
+	}
+	if req.OriginalPrice != nil {
+		product.OriginalPrice = req.OriginalPrice
+	}
+	if req.IsFree != nil {
+		product.IsFree = *req.IsFree
+	}
+	if req.LicenseType != nil {
+		product.LicenseType = *req.LicenseType
+	}
+	if req.FileSize != nil {
+		product.FileSize = req.FileSize
+	}
+	if req.FileFormat != nil {
+		product.FileFormat = req.FileFormat
+	}
+	if req.Compatibility != nil {
+		product.Compatibility = req.Compatibility
+	}
+	if req.Requirements != nil {
+		product.Requirements = req.Requirements
+	}
+	if req.DownloadLimit != nil {
+		product.DownloadLimit = req.DownloadLimit
+	}
+	if req.Status != nil {
+		product.Status = *req.Status
+	}
+
+	// An empty tagNames list clears the tags; omitting it leaves them as is
+	var tags []models.Tag
+	if req.TagNames != nil {
+		tags = services.TagsFromNames(req.TagNames)
+	}
+
+	if err := h.productRepo.Update(product, tags); err != nil {
+		h.writeProductError(c, err)
+		return
+	}
 
-	product := h.generateDummyProduct(productID, userID.(string))
+	h.logger.Info("Updated product " + productID + " for user: " + userID.(string))
 
 	c.JSON(http.StatusOK, models.ApiResponse{
 		Data:    product,
@@ -236,7 +439,12 @@ func (h *ProductHandler) DeleteProduct(c *gin.Context) {
 		return
 	}
 
-	h.logger.Info("Deleting product " + productID + " for user: " + userID.(string))
+	if err := h.productRepo.Delete(productID); err != nil {
+		h.writeProductError(c, err)
+		return
+	}
+
+	h.logger.Info("Deleted product " + productID + " for user: " + userID.(string))
 
 	c.JSON(http.StatusOK, models.ApiResponse{
 		Data:    gin.H{"id": productID},
@@ -256,370 +464,182 @@ func (h *ProductHandler) PurchaseProduct(c *gin.Context) {
 		return
 	}
 
-	var req struct {
-		PaymentMethod string `json:"paymentMethod" validate:"required"`
-	}
-
+	var req models.PurchaseRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Error:   "Invalid request data",
+			Message: err.Error(),
 			Success: false,
 		})
 		return
 	}
 
-	// Simulate purchase process
-	transactionID := uuid.New().String()
-	
-	transaction := models.Transaction{
-		ID:            transactionID,
-		BuyerID:       userID.(string),
-		SellerID:      "seller-user-id",
-		ItemType:      "product",
-		ItemID:        productID,
-		Amount:        29.99,
-		FeeAmount:     2.99,
-		NetAmount:     27.00,
-		Currency:      "USD",
-		PaymentMethod: &req.PaymentMethod,
-		PaymentStatus: "completed",
-		TransactionID: &transactionID,
-		CreatedAt:     time.Now(),
+	if err := h.validate.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Validation failed",
+			Message: err.Error(),
+			Success: false,
+		})
+		return
 	}
 
-	h.logger.Info("User " + userID.(string) + " purchased product: " + productID)
+	transaction, err := h.checkout.PurchaseProduct(userID.(string), productID, req.PaymentMethod)
+	if err != nil {
+		h.writeCheckoutError(c, err)
+		return
+	}
 
-	c.JSON(http.StatusOK, models.ApiResponse{
-		Data:    transaction,
-		Message: "Product purchased successfully",
-		Success: true,
-	})
+	h.logger.Info("User " + userID.(string) + " purchase of product " + productID + ": " + transaction.PaymentStatus)
+
+	switch transaction.PaymentStatus {
+	case services.PaymentFailed:
+		c.JSON(http.StatusPaymentRequired, models.ApiResponse{
+			Data:    transaction,
+			Message: "Payment was declined",
+			Success: false,
+		})
+	case services.PaymentPending:
+		c.JSON(http.StatusAccepted, models.ApiResponse{
+			Data:    transaction,
+			Message: "Payment is being processed",
+			Success: true,
+		})
+	default:
+		c.JSON(http.StatusOK, models.ApiResponse{
+			Data:    transaction,
+			Message: "Product purchased successfully",
+			Success: true,
+		})
+	}
 }
 
-func (h *ProductHandler) GetCategories(c *gin.Context) {
-	// Generate dummy categories
-	categories := []models.Category{
-		{
-			ID:          "cat-1",
-			Name:        "Web Templates",
-			Slug:        "web-templates",
-			Description: stringPtr("Modern and responsive web templates"),
-			Icon:        stringPtr("globe"),
-			Color:       stringPtr("#3B82F6"),
-			IsActive:    true,
-			SortOrder:   1,
-			ItemCount:   45,
-		},
-		{
-			ID:          "cat-2",
-			Name:        "UI/UX Kits",
-			Slug:        "ui-ux-kits",
-			Description: stringPtr("Complete UI and UX design systems"),
-			Icon:        stringPtr("palette"),
-			Color:       stringPtr("#8B5CF6"),
-			IsActive:    true,
-			SortOrder:   2,
-			ItemCount:   32,
-		},
-		{
-			ID:          "cat-3",
-			Name:        "Graphics & Illustrations",
-			Slug:        "graphics-illustrations",
-			Description: stringPtr("Vector graphics and digital illustrations"),
-			Icon:        stringPtr("image"),
-			Color:       stringPtr("#F59E0B"),
-			IsActive:    true,
-			SortOrder:   3,
-			ItemCount:   67,
-		},
-		{
-			ID:          "cat-4",
-			Name:        "3D Assets",
-			Slug:        "3d-assets",
-			Description: stringPtr("3D models, textures, and animations"),
-			Icon:        stringPtr("cube"),
-			Color:       stringPtr("#EF4444"),
-			IsActive:    true,
-			SortOrder:   4,
-			ItemCount:   28,
-		},
-		{
-			ID:          "cat-5",
-			Name:        "Audio & Music",
-			Slug:        "audio-music",
-			Description: stringPtr("Sound effects, music tracks, and audio tools"),
-			Icon:        stringPtr("music"),
-			Color:       stringPtr("#10B981"),
-			IsActive:    true,
-			SortOrder:   5,
-			ItemCount:   53,
-		},
+// GetDownloadLinks returns short-lived signed links to the files of a
+// product the user bought or owns.
+func (h *ProductHandler) GetDownloadLinks(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Authentication required",
+			Success: false,
+		})
+		return
+	}
+
+	links, err := h.downloads.Links(userID.(string), c.Param("id"), time.Now())
+	if err != nil {
+		h.writeDownloadError(c, err)
+		return
 	}
 
 	c.JSON(http.StatusOK, models.ApiResponse{
-		Data:    categories,
-		Message: "Categories retrieved successfully",
+		Data:    links,
+		Message: "Download links created successfully",
 		Success: true,
 	})
 }
 
-// Helper functions for dummy data
-func (h *ProductHandler) generateDummyProducts(limit, offset int, categoryID string, minPrice, maxPrice *float64, isFree *bool, search, sortBy, sortOrder, currentUserID string) []models.Product {
-	products := []models.Product{}
-
-	// Sample product data
-	sampleProducts := []struct {
-		title            string
-		description      string
-		shortDescription string
-		thumbnailURL     string
-		price            float64
-		originalPrice    *float64
-		isFree           bool
-		licenseType      string
-		fileFormat       string
-		downloadCount    int
-		rating           float64
-		ratingCount      int
-		user             models.User
-		category         models.Category
-	}{
-		{
-			title:            "Modern Dashboard UI Kit",
-			description:      "Complete UI kit for modern dashboard applications with 50+ components, dark/light themes, and Figma source files included.",
-			shortDescription: "Complete dashboard UI kit with 50+ components",
-			thumbnailURL:     "https://images.unsplash.com/photo-1611224923853-80b023f02d71?w=400",
-			price:            49.99,
-			originalPrice:    floatPtr(79.99),
-			isFree:           false,
-			licenseType:      "commercial",
-			fileFormat:       "Figma, Sketch, XD",
-			downloadCount:    342,
-			rating:           4.8,
-			ratingCount:      67,
-			user: models.User{
-				ID:          "creator-1",
-				Username:    "design_master",
-				DisplayName: stringPtr("Alex Rivera"),
-				AvatarURL:   stringPtr("https://images.unsplash.com/photo-1472099645785-5658abf4ff4e?w=150"),
-				IsVerified:  true,
-				IsCreator:   true,
-			},
-			category: models.Category{
-				ID:   "cat-2",
-				Name: "UI/UX Kits",
-				Slug: "ui-ux-kits",
-			},
-		},
-		{
-			title:            "E-commerce Website Template",
-			description:      "Fully responsive e-commerce website template built with React and Next.js. Includes shopping cart, product pages, checkout flow, and admin dashboard.",
-			shortDescription: "Responsive e-commerce template with React/Next.js",
-			thumbnailURL:     "https://images.unsplash.com/photo-1556742049-0cfed4f6a45d?w=400",
-			price:            89.99,
-			originalPrice:    floatPtr(129.99),
-			isFree:           false,
-			licenseType:      "commercial",
-			fileFormat:       "HTML, CSS, JS, React",
-			downloadCount:    156,
-			rating:           4.9,
-			ratingCount:      23,
-			user: models.User{
-				ID:          "creator-2",
-				Username:    "web_wizard",
-				DisplayName: stringPtr("Sarah Chen"),
-				AvatarURL:   stringPtr("https://images.unsplash.com/photo-1494790108755-2616b612b786?w=150"),
-				IsVerified:  true,
-				IsCreator:   true,
-			},
-			category: models.Category{
-				ID:   "cat-1",
-				Name: "Web Templates",
-				Slug: "web-templates",
-			},
-		},
-		{
-			title:            "Free Icon Pack Collection",
-			description:      "Beautiful collection of 200+ icons in multiple formats. Perfect for web and mobile applications. Includes business, technology, and lifestyle icons.",
-			shortDescription: "200+ icons in multiple formats - Free download",
-			thumbnailURL:     "https://images.unsplash.com/photo-1558655146-9f40138edfeb?w=400",
-			price:            0.00,
-			originalPrice:    nil,
-			isFree:           true,
-			licenseType:      "standard",
-			fileFormat:       "SVG, PNG, ICO",
-			downloadCount:    1250,
-			rating:           4.6,
-			ratingCount:      189,
-			user: models.User{
-				ID:          "creator-3",
-				Username:    "icon_artist",
-				DisplayName: stringPtr("Mike Johnson"),
-				AvatarURL:   stringPtr("https://images.unsplash.com/photo-1500648767791-00dcc994a43e?w=150"),
-				IsVerified:  false,
-				IsCreator:   true,
-			},
-			category: models.Category{
-				ID:   "cat-3",
-				Name: "Graphics & Illustrations",
-				Slug: "graphics-illustrations",
-			},
-		},
-		{
-			title:            "3D Character Models Pack",
-			description:      "High-quality 3D character models for games and animations. Includes rigged characters with multiple animations and texture variations.",
-			shortDescription: "High-quality 3D characters with animations",
-			thumbnailURL:     "https://images.unsplash.com/photo-1633356122544-f134324a6cee?w=400",
-			price:            199.99,
-			originalPrice:    floatPtr(299.99),
-			isFree:           false,
-			licenseType:      "commercial",
-			fileFormat:       "FBX, OBJ, Blend",
-			downloadCount:    89,
-			rating:           4.7,
-			ratingCount:      34,
-			user: models.User{
-				ID:          "creator-4",
-				Username:    "3d_sculptor",
-				DisplayName: stringPtr("Emma Wilson"),
-				AvatarURL:   stringPtr("https://images.unsplash.com/photo-1438761681033-6461ffad8d80?w=150"),
-				IsVerified:  true,
-				IsCreator:   true,
-			},
-			category: models.Category{
-				ID:   "cat-4",
-				Name: "3D Assets",
-				Slug: "3d-assets",
-			},
-		},
-		{
-			title:            "Ambient Music Collection",
-			description:      "Royalty-free ambient music tracks perfect for videos, games, and meditation apps. 10 high-quality tracks in WAV and MP3 formats.",
-			shortDescription: "10 royalty-free ambient music tracks",
-			thumbnailURL:     "https://images.unsplash.com/photo-1493225457124-a3eb161ffa5f?w=400",
-			price:            29.99,
-			originalPrice:    nil,
-			isFree:           false,
-			licenseType:      "commercial",
-			fileFormat:       "WAV, MP3",
-			downloadCount:    78,
-			rating:           4.5,
-			ratingCount:      12,
-			user: models.User{
-				ID:          "creator-5",
-				Username:    "sound_creator",
-				DisplayName: stringPtr("David Kim"),
-				AvatarURL:   stringPtr("https://images.unsplash.com/photo-1507003211169-0a1dd7228f2d?w=150"),
-				IsVerified:  false,
-				IsCreator:   true,
-			},
-			category: models.Category{
-				ID:   "cat-5",
-				Name: "Audio & Music",
-				Slug: "audio-music",
-			},
-		},
-	}
-
-	// Filter and generate products
-	for i := 0; i < limit && (offset+i) < len(sampleProducts)*10; i++ {
-		productIndex := (offset + i) % len(sampleProducts)
-		sample := sampleProducts[productIndex]
-
-		// Apply filters
-		if categoryID != "" && sample.category.ID != categoryID {
-			continue
-		}
-		if minPrice != nil && sample.price < *minPrice {
-			continue
-		}
-		if maxPrice != nil && sample.price > *maxPrice {
-			continue
-		}
-		if isFree != nil && sample.isFree != *isFree {
-			continue
-		}
-		if search != "" {
-			// Simple search implementation
-			// In real implementation, use full-text search
-			continue
-		}
+// Download redeems a signed download link and redirects to the file. The
+// token in the URL is the only credential, so links work from download
+// managers and plain browser navigation.
+func (h *ProductHandler) Download(c *gin.Context) {
+	file, err := h.downloads.Resolve(c.Param("token"), time.Now())
+	if err != nil {
+		h.writeDownloadError(c, err)
+		return
+	}
+	defer file.Body.Close()
 
-		productID := uuid.New().String()
-		product := models.Product{
-			ID:               productID,
-			UserID:           sample.user.ID,
-			CategoryID:       &sample.category.ID,
-			Title:            sample.title,
-			Description:      &sample.description,
-			ShortDescription: &sample.shortDescription,
-			ThumbnailURL:     &sample.thumbnailURL,
-			Price:            sample.price,
-			OriginalPrice:    sample.originalPrice,
-			IsFree:           sample.isFree,
-			LicenseType:      sample.licenseType,
-			FileFormat:       &sample.fileFormat,
-			Status:           "active",
-			DownloadCount:    sample.downloadCount + (i * 5),
-			RatingAverage:    sample.rating,
-			RatingCount:      sample.ratingCount + i,
-			CreatedAt:        time.Now().Add(-time.Duration(i*12) * time.Hour),
-			UpdatedAt:        time.Now().Add(-time.Duration(i*6) * time.Hour),
-			User:             &sample.user,
-			Category:         &sample.category,
-			IsLiked:          currentUserID == sample.user.ID || (i%4 == 0),
-			IsPurchased:      currentUserID == sample.user.ID || (i%7 == 0),
-			IsInWishlist:     i%5 == 0,
-		}
+	// Streamed through the server so the file's storage URL stays private
+	c.Header("Cache-Control", "no-store")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": file.Name}))
+	c.DataFromReader(http.StatusOK, file.Size, file.ContentType, file.Body, nil)
+}
 
-		products = append(products, product)
+func (h *ProductHandler) writeDownloadError(c *gin.Context, err error) {
+	switch err {
+	case services.ErrNotPurchased:
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error:   "Purchase this product to download its files",
+			Code:    "NOT_PURCHASED",
+			Success: false,
+		})
+	case repositories.ErrDownloadLimitReached:
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error:   "Download limit reached for this product",
+			Code:    "DOWNLOAD_LIMIT_REACHED",
+			Success: false,
+		})
+	case downloads.ErrInvalidLink:
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Download link not found",
+			Success: false,
+		})
+	case downloads.ErrLinkExpired:
+		c.JSON(http.StatusGone, models.ErrorResponse{
+			Error:   "Download link expired",
+			Code:    "LINK_EXPIRED",
+			Success: false,
+		})
+	case storage.ErrFileNotFound, storage.ErrFileUnavailable:
+		h.logger.Error("Failed to open product file: " + err.Error())
+		c.JSON(http.StatusBadGateway, models.ErrorResponse{
+			Error:   "This file is unavailable, please try again later",
+			Code:    "FILE_UNAVAILABLE",
+			Success: false,
+		})
+	default:
+		h.writeProductError(c, err)
 	}
-
-	return products
 }
 
-func (h *ProductHandler) generateDummyProduct(productID, currentUserID string) models.Product {
-	return models.Product{
-		ID:               productID,
-		UserID:           "creator-1",
-		CategoryID:       stringPtr("cat-2"),
-		Title:            "Premium Dashboard UI Kit",
-		Description:      stringPtr("Complete premium UI kit for dashboard applications with 100+ components, multiple themes, and comprehensive documentation. Built with modern design principles and best practices."),
-		ShortDescription: stringPtr("Premium dashboard UI kit with 100+ components"),
-		ThumbnailURL:     stringPtr("https://images.unsplash.com/photo-1611224923853-80b023f02d71?w=600"),
-		Price:            79.99,
-		OriginalPrice:    floatPtr(119.99),
-		IsFree:           false,
-		LicenseType:      "commercial",
-		FileSize:         stringPtr("45 MB"),
-		FileFormat:       stringPtr("Figma, Sketch, XD, PDF"),
-		Compatibility:    stringPtr("Figma 2020+, Sketch 70+, Adobe XD"),
-		Requirements:     stringPtr("Modern design tool with component support"),
-		Status:           "active",
-		DownloadCount:    567,
-		RatingAverage:    4.8,
-		RatingCount:      89,
-		CreatedAt:        time.Now().Add(-30 * 24 * time.Hour),
-		UpdatedAt:        time.Now().Add(-2 * 24 * time.Hour),
-		User: &models.User{
-			ID:          "creator-1",
-			Username:    "design_master",
-			DisplayName: stringPtr("Alex Rivera"),
-			AvatarURL:   stringPtr("https://images.unsplash.com/photo-1472099645785-5658abf4ff4e?w=150"),
-			IsVerified:  true,
-			IsCreator:   true,
-		},
-		Category: &models.Category{
-			ID:   "cat-2",
-			Name: "UI/UX Kits",
-			Slug: "ui-ux-kits",
-		},
-		IsLiked:      currentUserID == "creator-1" || productID == "liked-product",
-		IsPurchased:  currentUserID == "creator-1",
-		IsInWishlist: productID == "wishlist-product",
+func (h *ProductHandler) writeCheckoutError(c *gin.Context, err error) {
+	switch {
+	case err == services.ErrSelfPurchase:
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error:   "You cannot purchase your own product",
+			Code:    "SELF_PURCHASE",
+			Success: false,
+		})
+	case err == services.ErrAlreadyPurchased:
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error:   "You already own this product or have a payment for it in progress",
+			Code:    "ALREADY_PURCHASED",
+			Success: false,
+		})
+	case errors.Is(err, services.ErrPaymentProvider):
+		h.logger.Error("Checkout failed: " + err.Error())
+		c.JSON(http.StatusBadGateway, models.ErrorResponse{
+			Error:   "Payment provider unavailable, please try again",
+			Code:    "PAYMENT_PROVIDER_ERROR",
+			Success: false,
+		})
+	default:
+		h.writeProductError(c, err)
 	}
 }
 
-func floatPtr(f float64) *float64 {
-	return &f
-}
\ No newline at end of file
+func (h *ProductHandler) writeProductError(c *gin.Context, err error) {
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Product not found",
+			Success: false,
+		})
+		return
+	}
+	if err == repositories.ErrProductHasSales {
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error:   "Products with completed sales cannot be deleted",
+			Code:    "PRODUCT_HAS_SALES",
+			Message: "Set the product back to draft to take it off sale; buyers keep their downloads",
+			Success: false,
+		})
+		return
+	}
+
+	h.logger.Error("Product operation failed: " + err.Error())
+	c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+		Error:   "Internal server error",
+		Success: false,
+	})
+}
@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"viport-backend/internal/models"
+	"viport-backend/internal/repositories"
+	"viport-backend/internal/services"
+	"viport-backend/pkg/logger"
+	"viport-backend/pkg/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// QuizHandler serves the quizzes attached to lessons: instructors define
+// them and enrolled students submit scored attempts.
+type QuizHandler struct {
+	logger       logger.Logger
+	validate     *validator.Validate
+	quizzes      *services.QuizService
+	certificates *services.CertificateService
+}
+
+func NewQuizHandler(db *sql.DB, logger logger.Logger, store storage.Storage) *QuizHandler {
+	return &QuizHandler{
+		logger:       logger,
+		validate:     validator.New(),
+		quizzes:      services.NewQuizService(db),
+		certificates: services.NewCertificateService(db, store),
+	}
+}
+
+// GetQuiz returns the quiz of a lesson. Students see the questions without
+// their answers, with how many attempts they have left.
+func (h *QuizHandler) GetQuiz(c *gin.Context) {
+	quiz, err := h.quizzes.Get(viewerID(c), c.Param("id"), c.Param("lessonId"))
+	if err != nil {
+		h.writeQuizError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Data:    quiz,
+		Message: "Quiz retrieved successfully",
+		Success: true,
+	})
+}
+
+// SaveQuiz creates the quiz of a lesson or replaces it. Replacing a quiz
+// keeps the attempts already made.
+func (h *QuizHandler) SaveQuiz(c *gin.Context) {
+	var req models.SaveQuizRequest
+	if !h.bind(c, &req) {
+		return
+	}
+
+	quiz, err := h.quizzes.Save(c.Param("id"), c.Param("lessonId"), &req)
+	if err != nil {
+		h.writeQuizError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Data:    quiz,
+		Message: "Quiz saved successfully",
+		Success: true,
+	})
+}
+
+func (h *QuizHandler) DeleteQuiz(c *gin.Context) {
+	lessonID := c.Param("lessonId")
+
+	if err := h.quizzes.Delete(c.Param("id"), lessonID); err != nil {
+		h.writeQuizError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Data:    gin.H{"lessonId": lessonID},
+		Message: "Quiz deleted successfully",
+		Success: true,
+	})
+}
+
+// SubmitAttempt scores the authenticated student's answers. Passing
+// completes the lesson, and the course when it was the last one.
+func (h *QuizHandler) SubmitAttempt(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Authentication required",
+			Success: false,
+		})
+		return
+	}
+
+	var req models.SubmitQuizRequest
+	if !h.bind(c, &req) {
+		return
+	}
+
+	attempt, err := h.quizzes.Submit(userID.(string), c.Param("id"), c.Param("lessonId"), req.Answers)
+	if err != nil {
+		h.writeQuizError(c, err)
+		return
+	}
+
+	// A failed certificate is issued again when it is next requested
+	if err := h.certificates.IssueOnCompletion(attempt.Enrollment); err != nil {
+		h.logger.Error("Failed to issue certificate: " + err.Error())
+	}
+
+	message := "Quiz passed"
+	if !attempt.Passed {
+		message = "Quiz not passed"
+	}
+
+	c.JSON(http.StatusCreated, models.ApiResponse{
+		Data:    attempt,
+		Message: message,
+		Success: true,
+	})
+}
+
+// GetAttempts lists the authenticated student's attempts at a lesson's quiz.
+func (h *QuizHandler) GetAttempts(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Authentication required",
+			Success: false,
+		})
+		return
+	}
+
+	attempts, err := h.quizzes.Attempts(userID.(string), c.Param("id"), c.Param("lessonId"))
+	if err != nil {
+		h.writeQuizError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Data:    attempts,
+		Message: "Quiz attempts retrieved successfully",
+		Success: true,
+	})
+}
+
+// bind decodes and validates the JSON body into req, writing a 400 response
+// and returning false when it is invalid.
+func (h *QuizHandler) bind(c *gin.Context, req interface{}) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request data",
+			Message: err.Error(),
+			Success: false,
+		})
+		return false
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Validation failed",
+			Message: err.Error(),
+			Success: false,
+		})
+		return false
+	}
+
+	return true
+}
+
+func (h *QuizHandler) writeQuizError(c *gin.Context, err error) {
+	switch {
+	case err == sql.ErrNoRows:
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Quiz not found",
+			Success: false,
+		})
+	case errors.Is(err, services.ErrInvalidQuiz):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid quiz",
+			Message: err.Error(),
+			Success: false,
+		})
+	case err == services.ErrLessonLocked:
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error:   "Enroll in the course to open this lesson",
+			Code:    "LESSON_LOCKED",
+			Success: false,
+		})
+	case err == repositories.ErrNotEnrolled:
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error:   "You are not enrolled in this course",
+			Code:    "NOT_ENROLLED",
+			Success: false,
+		})
+	case err == repositories.ErrAttemptsExhausted:
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error:   "You have used every attempt at this quiz",
+			Code:    "ATTEMPTS_EXHAUSTED",
+			Success: false,
+		})
+	default:
+		h.logger.Error("Quiz operation failed: " + err.Error())
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal server error",
+			Success: false,
+		})
+	}
+}
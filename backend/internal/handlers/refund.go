@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"viport-backend/internal/models"
+	"viport-backend/internal/repositories"
+	"viport-backend/internal/services"
+	"viport-backend/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type RefundHandler struct {
+	logger        logger.Logger
+	validate      *validator.Validate
+	refundService *services.RefundService
+}
+
+func NewRefundHandler(db *sql.DB, logger logger.Logger, payments services.PaymentProvider) *RefundHandler {
+	return &RefundHandler{
+		logger:        logger,
+		validate:      validator.New(),
+		refundService: services.NewRefundService(db, payments),
+	}
+}
+
+// RequestRefund lets the buyer of the :id transaction ask for a full or
+// partial refund.
+func (h *RefundHandler) RequestRefund(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Authentication required",
+			Success: false,
+		})
+		return
+	}
+
+	var req models.CreateRefundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request data",
+			Message: err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	if err := h.validate.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Validation failed",
+			Message: err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	refund, err := h.refundService.Request(userID.(string), c.Param("id"), req)
+	if err != nil {
+		h.writeRefundError(c, err)
+		return
+	}
+
+	h.logger.Info("User " + userID.(string) + " requested refund " + refund.ID + " on transaction " + refund.TransactionID)
+
+	c.JSON(http.StatusCreated, models.ApiResponse{
+		Data:    refund,
+		Message: "Refund requested successfully",
+		Success: true,
+	})
+}
+
+// ListRefunds lists the refunds on the user's purchases, or on their sales
+// with role=seller.
+func (h *RefundHandler) ListRefunds(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Authentication required",
+			Success: false,
+		})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	role := c.DefaultQuery("role", "buyer")
+	if role != "seller" {
+		role = "buyer"
+	}
+
+	refunds, total, err := h.refundService.List(userID.(string), role, limit, offset)
+	if err != nil {
+		h.writeRefundError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Data:    refunds,
+		Message: "Refunds retrieved successfully",
+		Success: true,
+		Meta: &models.Meta{
+			Page:        (offset / limit) + 1,
+			Limit:       limit,
+			Total:       total,
+			TotalPages:  (total + limit - 1) / limit,
+			HasNext:     offset+limit < total,
+			HasPrevious: offset > 0,
+		},
+	})
+}
+
+func (h *RefundHandler) GetRefund(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Authentication required",
+			Success: false,
+		})
+		return
+	}
+
+	refund, err := h.refundService.Get(userID.(string), isAdmin(c), c.Param("id"))
+	if err != nil {
+		h.writeRefundError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Data:    refund,
+		Message: "Refund retrieved successfully",
+		Success: true,
+	})
+}
+
+// ApproveRefund lets the seller or an admin pay a requested refund back.
+func (h *RefundHandler) ApproveRefund(c *gin.Context) {
+	h.review(c, h.refundService.Approve, "approved")
+}
+
+// RejectRefund lets the seller or an admin decline a requested refund.
+func (h *RefundHandler) RejectRefund(c *gin.Context) {
+	h.review(c, h.refundService.Reject, "rejected")
+}
+
+func (h *RefundHandler) review(c *gin.Context, decide func(string, bool, string, *string) (*models.Refund, error), verb string) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Authentication required",
+			Success: false,
+		})
+		return
+	}
+
+	var req models.ReviewRefundRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Invalid request data",
+				Message: err.Error(),
+				Success: false,
+			})
+			return
+		}
+		if err := h.validate.Struct(&req); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Validation failed",
+				Message: err.Error(),
+				Success: false,
+			})
+			return
+		}
+	}
+
+	refund, err := decide(userID.(string), isAdmin(c), c.Param("id"), req.Note)
+	if err != nil {
+		h.writeRefundError(c, err)
+		return
+	}
+
+	h.logger.Info("User " + userID.(string) + " " + verb + " refund " + refund.ID + ": " + refund.Status)
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Data:    refund,
+		Message: "Refund " + verb,
+		Success: true,
+	})
+}
+
+func (h *RefundHandler) writeRefundError(c *gin.Context, err error) {
+	switch {
+	case err == sql.ErrNoRows:
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Not found",
+			Success: false,
+		})
+	case err == services.ErrNotRefundReviewer:
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error:   err.Error(),
+			Code:    "FORBIDDEN",
+			Success: false,
+		})
+	case err == repositories.ErrRefundOpen, err == services.ErrRefundNotOpen:
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error:   err.Error(),
+			Success: false,
+		})
+	case err == repositories.ErrNotRefundable, err == repositories.ErrRefundExceedsBalance:
+		c.JSON(http.StatusUnprocessableEntity, models.ErrorResponse{
+			Error:   err.Error(),
+			Success: false,
+		})
+	case errors.Is(err, services.ErrPaymentProvider):
+		h.logger.Error("Refund failed: " + err.Error())
+		c.JSON(http.StatusBadGateway, models.ErrorResponse{
+			Error:   "Payment provider unavailable, please try again",
+			Code:    "PAYMENT_PROVIDER_ERROR",
+			Success: false,
+		})
+	default:
+		h.logger.Error("Refund operation failed: " + err.Error())
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal server error",
+			Success: false,
+		})
+	}
+}
+
+// isAdmin reports whether the authenticated caller has the admin role.
+func isAdmin(c *gin.Context) bool {
+	role, _ := c.Get("role")
+	r, ok := role.(string)
+	return ok && r == "admin"
+}
@@ -0,0 +1,297 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+	"viport-backend/internal/models"
+	"viport-backend/internal/repositories"
+	"viport-backend/internal/services"
+	"viport-backend/pkg/logger"
+	"viport-backend/pkg/pagination"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// ReviewHandler serves product and course reviews, their rating summaries
+// and helpful votes.
+type ReviewHandler struct {
+	logger        logger.Logger
+	validate      *validator.Validate
+	reviewService *services.ReviewService
+	cursors       *pagination.Codec
+}
+
+func NewReviewHandler(db *sql.DB, logger logger.Logger, cursors *pagination.Codec) *ReviewHandler {
+	return &ReviewHandler{
+		logger:        logger,
+		validate:      validator.New(),
+		reviewService: services.NewReviewService(db),
+		cursors:       cursors,
+	}
+}
+
+// ListReviews returns a handler listing the reviews of the :id item of the
+// given type. sortBy=helpful_count puts the most helpful first, otherwise
+// the newest come first; rating=1..5 keeps one star rating.
+func (h *ReviewHandler) ListReviews(reviewableType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page, ok := parsePageRequest(c, h.cursors, "reviews")
+		if !ok {
+			return
+		}
+
+		filter := models.ReviewFilter{
+			ReviewableType: reviewableType,
+			ReviewableID:   c.Param("id"),
+			Limit:          page.Limit,
+			Offset:         page.Offset,
+			SortBy:         c.DefaultQuery("sortBy", "created_at"),
+			Cursor:         page.Cursor,
+		}
+		if page.Cursor != nil {
+			filter.SortBy = page.Cursor.SortBy
+		}
+		if filter.SortBy != "helpful_count" {
+			filter.SortBy = "created_at"
+		}
+
+		if ratingStr := c.Query("rating"); ratingStr != "" {
+			rating, err := strconv.Atoi(ratingStr)
+			if err != nil || rating < 1 || rating > 5 {
+				c.JSON(http.StatusBadRequest, models.ErrorResponse{
+					Error:   "Invalid rating",
+					Message: "rating must be between 1 and 5",
+					Success: false,
+				})
+				return
+			}
+			filter.Rating = &rating
+		}
+
+		reviews, total, err := h.reviewService.List(filter, viewerID(c))
+		if err != nil {
+			h.writeReviewError(c, err, capitalize(reviewableType)+" not found")
+			return
+		}
+
+		reviews, meta := paginate(reviews, page, total, h.cursors, filter.SortBy, "desc",
+			func(review *models.Review) (string, string) {
+				if filter.SortBy == "helpful_count" {
+					return strconv.Itoa(review.HelpfulCount), review.ID
+				}
+				return review.CreatedAt.Format(time.RFC3339Nano), review.ID
+			})
+
+		c.JSON(http.StatusOK, models.ApiResponse{
+			Data:    reviews,
+			Message: "Reviews retrieved successfully",
+			Success: true,
+			Meta:    meta,
+		})
+	}
+}
+
+// GetRatingSummary returns a handler reporting the average rating, review
+// count and star histogram of the :id item of the given type.
+func (h *ReviewHandler) GetRatingSummary(reviewableType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		summary, err := h.reviewService.Summary(reviewableType, c.Param("id"), viewerID(c))
+		if err != nil {
+			h.writeReviewError(c, err, capitalize(reviewableType)+" not found")
+			return
+		}
+
+		c.JSON(http.StatusOK, models.ApiResponse{
+			Data:    summary,
+			Message: "Rating summary retrieved successfully",
+			Success: true,
+		})
+	}
+}
+
+// CreateReview returns a handler that posts the authenticated user's review
+// of the :id item of the given type.
+func (h *ReviewHandler) CreateReview(reviewableType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userID")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+				Error:   "Authentication required",
+				Success: false,
+			})
+			return
+		}
+
+		var req models.CreateReviewRequest
+		if !h.bind(c, &req) {
+			return
+		}
+
+		review, err := h.reviewService.Create(userID.(string), reviewableType, c.Param("id"), &req)
+		if err != nil {
+			h.writeReviewError(c, err, capitalize(reviewableType)+" not found")
+			return
+		}
+
+		h.logger.Info("User " + userID.(string) + " reviewed " + reviewableType + ": " + review.ReviewableID)
+
+		c.JSON(http.StatusCreated, models.ApiResponse{
+			Data:    review,
+			Message: "Review created successfully",
+			Success: true,
+		})
+	}
+}
+
+func (h *ReviewHandler) UpdateReview(c *gin.Context) {
+	var req models.UpdateReviewRequest
+	if !h.bind(c, &req) {
+		return
+	}
+
+	review, err := h.reviewService.Update(c.Param("id"), &req)
+	if err != nil {
+		h.writeReviewError(c, err, "Review not found")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Data:    review,
+		Message: "Review updated successfully",
+		Success: true,
+	})
+}
+
+func (h *ReviewHandler) DeleteReview(c *gin.Context) {
+	reviewID := c.Param("id")
+
+	if err := h.reviewService.Delete(reviewID); err != nil {
+		h.writeReviewError(c, err, "Review not found")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Data:    gin.H{"id": reviewID},
+		Message: "Review deleted successfully",
+		Success: true,
+	})
+}
+
+// MarkHelpful records the authenticated user's helpful vote on a review.
+func (h *ReviewHandler) MarkHelpful(c *gin.Context) {
+	h.vote(c, true)
+}
+
+// UnmarkHelpful withdraws the authenticated user's helpful vote.
+func (h *ReviewHandler) UnmarkHelpful(c *gin.Context) {
+	h.vote(c, false)
+}
+
+func (h *ReviewHandler) vote(c *gin.Context, helpful bool) {
+	reviewID := c.Param("id")
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Authentication required",
+			Success: false,
+		})
+		return
+	}
+
+	var helpfulCount int
+	var err error
+	if helpful {
+		helpfulCount, err = h.reviewService.Vote(reviewID, userID.(string))
+	} else {
+		helpfulCount, err = h.reviewService.Unvote(reviewID, userID.(string))
+	}
+	if err != nil {
+		h.writeReviewError(c, err, "Review not found")
+		return
+	}
+
+	message := "Review marked as helpful"
+	if !helpful {
+		message = "Helpful vote removed"
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Data: gin.H{
+			"reviewId":     reviewID,
+			"isHelpful":    helpful,
+			"helpfulCount": helpfulCount,
+		},
+		Message: message,
+		Success: true,
+	})
+}
+
+// bind decodes and validates the JSON body into req, writing a 400 response
+// and returning false when it is invalid.
+func (h *ReviewHandler) bind(c *gin.Context, req interface{}) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request data",
+			Message: err.Error(),
+			Success: false,
+		})
+		return false
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Validation failed",
+			Message: err.Error(),
+			Success: false,
+		})
+		return false
+	}
+
+	return true
+}
+
+// writeReviewError reports sql.ErrNoRows with the given not found message.
+func (h *ReviewHandler) writeReviewError(c *gin.Context, err error, notFound string) {
+	switch err {
+	case sql.ErrNoRows:
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   notFound,
+			Success: false,
+		})
+	case repositories.ErrAlreadyReviewed:
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error:   "Already reviewed",
+			Message: "You have already reviewed this item; edit your review instead",
+			Success: false,
+		})
+	case repositories.ErrSelfReview, repositories.ErrSelfVote:
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error:   "Forbidden",
+			Message: err.Error(),
+			Success: false,
+		})
+	case services.ErrUnsupportedReviewableType:
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid item type",
+			Message: err.Error(),
+			Success: false,
+		})
+	default:
+		h.logger.Error("Review operation failed: " + err.Error())
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal server error",
+			Success: false,
+		})
+	}
+}
+
+// viewerID is the authenticated user's ID, or empty for anonymous requests.
+func viewerID(c *gin.Context) string {
+	if userID, exists := c.Get("userID"); exists {
+		return userID.(string)
+	}
+	return ""
+}
@@ -0,0 +1,301 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"viport-backend/internal/models"
+	"viport-backend/internal/repositories"
+	"viport-backend/internal/services"
+	"viport-backend/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// SavedHandler serves the save/unsave endpoints of posts, products and
+// courses and the authenticated user's saved items and collections.
+type SavedHandler struct {
+	logger       logger.Logger
+	validate     *validator.Validate
+	savedService *services.SavedService
+}
+
+func NewSavedHandler(db *sql.DB, logger logger.Logger) *SavedHandler {
+	return &SavedHandler{
+		logger:       logger,
+		validate:     validator.New(),
+		savedService: services.NewSavedService(db),
+	}
+}
+
+// Save returns a handler that saves the :id item of the given type. The
+// optional body files it under a collection.
+func (h *SavedHandler) Save(saveableType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userID")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+				Error:   "Authentication required",
+				Success: false,
+			})
+			return
+		}
+
+		var req models.SaveItemRequest
+		if c.Request.ContentLength > 0 && !h.bind(c, &req) {
+			return
+		}
+
+		item, err := h.savedService.Save(userID.(string), saveableType, c.Param("id"), req.CollectionID)
+		if err != nil {
+			h.writeSavedError(c, err, capitalize(saveableType)+" not found")
+			return
+		}
+
+		c.JSON(http.StatusOK, models.ApiResponse{
+			Data:    item,
+			Message: capitalize(saveableType) + " saved successfully",
+			Success: true,
+		})
+	}
+}
+
+// Unsave returns a handler that removes the :id item of the given type from
+// the user's saved items.
+func (h *SavedHandler) Unsave(saveableType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		itemID := c.Param("id")
+		userID, exists := c.Get("userID")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+				Error:   "Authentication required",
+				Success: false,
+			})
+			return
+		}
+
+		if err := h.savedService.Unsave(userID.(string), saveableType, itemID); err != nil {
+			h.writeSavedError(c, err, capitalize(saveableType)+" not found")
+			return
+		}
+
+		c.JSON(http.StatusOK, models.ApiResponse{
+			Data: gin.H{
+				"itemType": saveableType,
+				"itemId":   itemID,
+				"isSaved":  false,
+			},
+			Message: capitalize(saveableType) + " removed from saved items",
+			Success: true,
+		})
+	}
+}
+
+// GetSavedItems lists the authenticated user's saved items, newest first.
+// ?type narrows them to posts, products or courses and ?collectionId to one
+// collection.
+func (h *SavedHandler) GetSavedItems(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Authentication required",
+			Success: false,
+		})
+		return
+	}
+
+	var collectionID *string
+	if id := c.Query("collectionId"); id != "" {
+		collectionID = &id
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	items, total, err := h.savedService.List(userID.(string), c.Query("type"), collectionID, limit, offset)
+	if err != nil {
+		h.writeSavedError(c, err, "Collection not found")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Data:    items,
+		Message: "Saved items retrieved successfully",
+		Success: true,
+		Meta: &models.Meta{
+			Page:        (offset / limit) + 1,
+			Limit:       limit,
+			Total:       total,
+			TotalPages:  (total + limit - 1) / limit,
+			HasNext:     offset+limit < total,
+			HasPrevious: offset > 0,
+		},
+	})
+}
+
+func (h *SavedHandler) GetCollections(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Authentication required",
+			Success: false,
+		})
+		return
+	}
+
+	collections, err := h.savedService.ListCollections(userID.(string))
+	if err != nil {
+		h.writeSavedError(c, err, "Collection not found")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Data:    collections,
+		Message: "Collections retrieved successfully",
+		Success: true,
+	})
+}
+
+func (h *SavedHandler) CreateCollection(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Authentication required",
+			Success: false,
+		})
+		return
+	}
+
+	var req models.SavedCollectionRequest
+	if !h.bind(c, &req) {
+		return
+	}
+
+	collection, err := h.savedService.CreateCollection(userID.(string), &req)
+	if err != nil {
+		h.writeSavedError(c, err, "Collection not found")
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.ApiResponse{
+		Data:    collection,
+		Message: "Collection created successfully",
+		Success: true,
+	})
+}
+
+func (h *SavedHandler) UpdateCollection(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Authentication required",
+			Success: false,
+		})
+		return
+	}
+
+	var req models.SavedCollectionRequest
+	if !h.bind(c, &req) {
+		return
+	}
+
+	collection, err := h.savedService.UpdateCollection(c.Param("id"), userID.(string), &req)
+	if err != nil {
+		h.writeSavedError(c, err, "Collection not found")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Data:    collection,
+		Message: "Collection updated successfully",
+		Success: true,
+	})
+}
+
+// DeleteCollection removes a collection. Its items stay saved.
+func (h *SavedHandler) DeleteCollection(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Authentication required",
+			Success: false,
+		})
+		return
+	}
+
+	if err := h.savedService.DeleteCollection(c.Param("id"), userID.(string)); err != nil {
+		h.writeSavedError(c, err, "Collection not found")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Message: "Collection deleted successfully",
+		Success: true,
+	})
+}
+
+// bind decodes and validates the JSON body into req, writing a 400 response
+// and returning false when it is invalid.
+func (h *SavedHandler) bind(c *gin.Context, req interface{}) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request data",
+			Message: err.Error(),
+			Success: false,
+		})
+		return false
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Validation failed",
+			Message: err.Error(),
+			Success: false,
+		})
+		return false
+	}
+
+	return true
+}
+
+// writeSavedError reports sql.ErrNoRows with the given not found message.
+func (h *SavedHandler) writeSavedError(c *gin.Context, err error, notFound string) {
+	switch err {
+	case sql.ErrNoRows:
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   notFound,
+			Success: false,
+		})
+	case repositories.ErrCollectionNotFound:
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Collection not found",
+			Success: false,
+		})
+	case repositories.ErrCollectionExists:
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error:   "Collection already exists",
+			Message: "You already have a collection with this name",
+			Success: false,
+		})
+	case services.ErrUnsupportedSaveableType:
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid item type",
+			Message: "type must be post, product or course",
+			Success: false,
+		})
+	default:
+		h.logger.Error("Saved items operation failed: " + err.Error())
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal server error",
+			Success: false,
+		})
+	}
+}
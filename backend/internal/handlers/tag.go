@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"viport-backend/internal/models"
+	"viport-backend/internal/services"
+	"viport-backend/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TagHandler struct {
+	logger     logger.Logger
+	tagService *services.TagService
+}
+
+func NewTagHandler(db *sql.DB, logger logger.Logger) *TagHandler {
+	return &TagHandler{
+		logger:     logger,
+		tagService: services.NewTagService(db),
+	}
+}
+
+// SearchTags autocompletes tag names from the q prefix, most used first.
+func (h *TagHandler) SearchTags(c *gin.Context) {
+	limit := queryInt(c, "limit", 10, 50)
+
+	tags, err := h.tagService.Autocomplete(c.Query("q"), limit)
+	if err != nil {
+		writeTagError(c, h.logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Data:    tags,
+		Message: "Tags retrieved successfully",
+		Success: true,
+	})
+}
+
+// GetTrendingTags lists the tags used most on content published in the last
+// days (7 by default, at most 30).
+func (h *TagHandler) GetTrendingTags(c *gin.Context) {
+	days := queryInt(c, "days", 7, 30)
+	limit := queryInt(c, "limit", 20, 50)
+
+	tags, err := h.tagService.Trending(days, limit)
+	if err != nil {
+		writeTagError(c, h.logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Data:    tags,
+		Message: "Trending tags retrieved successfully",
+		Success: true,
+	})
+}
+
+func (h *TagHandler) GetTag(c *gin.Context) {
+	tag, err := h.tagService.GetBySlug(c.Param("slug"))
+	if err != nil {
+		writeTagError(c, h.logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Data:    tag,
+		Message: "Tag retrieved successfully",
+		Success: true,
+	})
+}
+
+// queryInt reads a positive integer query parameter, falling back to def
+// when it is missing or invalid and capping it at max.
+func queryInt(c *gin.Context, name string, def, max int) int {
+	value, err := strconv.Atoi(c.DefaultQuery(name, strconv.Itoa(def)))
+	if err != nil || value <= 0 {
+		return def
+	}
+	if value > max {
+		return max
+	}
+	return value
+}
+
+func writeTagError(c *gin.Context, log logger.Logger, err error) {
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Tag not found",
+			Success: false,
+		})
+		return
+	}
+
+	log.Error("Tag operation failed: " + err.Error())
+	c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+		Error:   "Internal server error",
+		Success: false,
+	})
+}
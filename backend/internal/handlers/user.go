@@ -7,44 +7,70 @@ import (
 	"viport-backend/internal/models"
 	"viport-backend/internal/repositories"
 	"viport-backend/pkg/logger"
+	"viport-backend/pkg/pagination"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
 )
 
 type UserHandler struct {
-	db       *sql.DB
-	logger   logger.Logger
-	validate *validator.Validate
-	userRepo *repositories.UserRepository
+	db         *sql.DB
+	logger     logger.Logger
+	validate   *validator.Validate
+	userRepo   *repositories.UserRepository
+	followRepo *repositories.FollowRepository
+	txRepo     *repositories.TransactionRepository
+	cursors    *pagination.Codec
 }
 
-func NewUserHandler(db *sql.DB, logger logger.Logger) *UserHandler {
+func NewUserHandler(db *sql.DB, logger logger.Logger, cursors *pagination.Codec) *UserHandler {
 	return &UserHandler{
-		db:       db,
-		logger:   logger,
-		validate: validator.New(),
-		userRepo: repositories.NewUserRepository(db),
+		db:         db,
+		logger:     logger,
+		validate:   validator.New(),
+		userRepo:   repositories.NewUserRepository(db),
+		followRepo: repositories.NewFollowRepository(db),
+		txRepo:     repositories.NewTransactionRepository(db),
+		cursors:    cursors,
 	}
 }
 
 func (h *UserHandler) GetUsers(c *gin.Context) {
-	// Try to get users from database
-	dbUsers, err := h.userRepo.GetAll()
-	if err != nil {
-		h.logger.Error("Failed to get users from database: " + err.Error())
-		// Fallback to dummy data
+	page, ok := parsePageRequest(c, h.cursors, "users")
+	if !ok {
+		return
 	}
 
-	var users []models.User
-	if len(dbUsers) > 0 {
-		// Convert pointer array to value array for response
+	if h.userRepo.IsConnected() {
+		dbUsers, total, err := h.userRepo.List(page.Limit, page.Offset, page.Cursor)
+		if err != nil {
+			h.logger.Error("Failed to get users from database: " + err.Error())
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "Internal server error",
+				Success: false,
+			})
+			return
+		}
+
+		dbUsers, meta := paginate(dbUsers, page, total, h.cursors, "created_at", "desc",
+			func(user *models.User) (string, string) {
+				return user.CreatedAt.Format(time.RFC3339Nano), user.ID
+			})
 		for _, user := range dbUsers {
-			users = append(users, *user)
+			user.Email = ""
 		}
-	} else {
-		// Generate dummy users data as fallback
-		users = []models.User{
+
+		c.JSON(http.StatusOK, models.ApiResponse{
+			Data:    dbUsers,
+			Message: "Users retrieved successfully",
+			Success: true,
+			Meta:    meta,
+		})
+		return
+	}
+
+	// Generate dummy users data when running without a database
+	users := []models.User{
 		{
 			ID:                "user-1",
 			Username:          "sarah_designer",
@@ -105,7 +131,6 @@ func (h *UserHandler) GetUsers(c *gin.Context) {
 			CreatedAt:         time.Now().AddDate(0, -3, 0),
 			UpdatedAt:         time.Now(),
 		},
-		}
 	}
 
 	response := models.ApiResponse{
@@ -120,32 +145,27 @@ func (h *UserHandler) GetUsers(c *gin.Context) {
 func (h *UserHandler) GetUser(c *gin.Context) {
 	id := c.Param("id")
 
-	// Generate dummy user data
-	user := models.User{
-		ID:                id,
-		Username:          "sarah_designer",
-		Email:             "sarah@example.com",
-		FirstName:         stringPtr("Sarah"),
-		LastName:          stringPtr("Johnson"),
-		DisplayName:       stringPtr("Sarah Johnson"),
-		Bio:               stringPtr("Creative designer and digital artist passionate about UI/UX and digital illustrations."),
-		AvatarURL:         stringPtr("https://images.unsplash.com/photo-1494790108755-2616b612b786?w=150"),
-		CoverImageURL:     stringPtr("https://images.unsplash.com/photo-1506905925346-21bda4d32df4?w=800"),
-		Location:          stringPtr("San Francisco, CA"),
-		WebsiteURL:        stringPtr("https://sarah-designs.com"),
-		IsVerified:        true,
-		IsCreator:         true,
-		VerificationLevel: "creator",
-		AccountType:       "creator",
-		FollowerCount:     1250,
-		FollowingCount:    890,
-		PostCount:         156,
-		ProductCount:      23,
-		CreatedAt:         time.Now().AddDate(0, -6, 0),
-		UpdatedAt:         time.Now(),
-		LastActiveAt:      timePtr(time.Now().Add(-1 * time.Hour)),
+	user, err := h.userRepo.GetByID(id)
+	if err != nil {
+		h.writeUserError(c, err)
+		return
+	}
+
+	if err := h.userRepo.LoadCounts(user); err != nil {
+		h.logger.Error("Failed to load user counts: " + err.Error())
 	}
 
+	if currentUserID, exists := c.Get("userID"); exists && currentUserID.(string) != user.ID {
+		isFollowing, err := h.followRepo.IsFollowing(currentUserID.(string), user.ID)
+		if err != nil {
+			h.logger.Error("Failed to resolve follow state: " + err.Error())
+		}
+		user.IsFollowing = isFollowing
+	}
+
+	// Email addresses are only visible on the owner's profile
+	user.Email = ""
+
 	response := models.ApiResponse{
 		Data:    user,
 		Message: "User retrieved successfully",
@@ -171,16 +191,192 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 	})
 }
 
+// DeleteUser is not supported yet: purchases, refunds and payouts reference
+// their users, so accounts cannot simply be removed.
 func (h *UserHandler) DeleteUser(c *gin.Context) {
+	c.JSON(http.StatusNotImplemented, models.ErrorResponse{
+		Error:   "Not implemented",
+		Message: "Account deletion is not supported yet",
+		Success: false,
+	})
+}
+
+func (h *UserHandler) FollowUser(c *gin.Context) {
+	targetID := c.Param("id")
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Authentication required",
+			Success: false,
+		})
+		return
+	}
+
+	if userID.(string) == targetID {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid follow",
+			Message: "You cannot follow yourself",
+			Success: false,
+		})
+		return
+	}
+
+	if err := h.followRepo.Follow(userID.(string), targetID); err != nil {
+		if err == repositories.ErrAlreadyFollowing {
+			c.JSON(http.StatusConflict, models.ErrorResponse{
+				Error:   "Already following",
+				Message: "You already follow this user",
+				Success: false,
+			})
+			return
+		}
+		h.writeUserError(c, err)
+		return
+	}
+
+	h.logger.Info("User " + userID.(string) + " followed user: " + targetID)
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Data: gin.H{
+			"userId":      targetID,
+			"isFollowing": true,
+		},
+		Message: "User followed successfully",
+		Success: true,
+	})
+}
+
+func (h *UserHandler) UnfollowUser(c *gin.Context) {
+	targetID := c.Param("id")
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Authentication required",
+			Success: false,
+		})
+		return
+	}
+
+	if err := h.followRepo.Unfollow(userID.(string), targetID); err != nil {
+		if err == repositories.ErrNotFollowing {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "Not following",
+				Message: "You do not follow this user",
+				Success: false,
+			})
+			return
+		}
+		h.writeUserError(c, err)
+		return
+	}
+
+	h.logger.Info("User " + userID.(string) + " unfollowed user: " + targetID)
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Data: gin.H{
+			"userId":      targetID,
+			"isFollowing": false,
+		},
+		Message: "User unfollowed successfully",
+		Success: true,
+	})
+}
+
+func (h *UserHandler) GetFollowers(c *gin.Context) {
+	h.listFollows(c, h.followRepo.GetFollowers, "followers", "Followers retrieved successfully")
+}
+
+func (h *UserHandler) GetFollowing(c *gin.Context) {
+	h.listFollows(c, h.followRepo.GetFollowing, "following", "Following retrieved successfully")
+}
+
+// listFollows serves a follower list of the :id user, paged by offset or by
+// cursors of the given list kind.
+func (h *UserHandler) listFollows(c *gin.Context, list func(string, int, int, *pagination.Cursor) ([]*models.User, int, error), kind, message string) {
 	id := c.Param("id")
-	h.logger.Info("Delete user request for ID: " + id)
 
-	response := models.ApiResponse{
-		Data:    gin.H{"id": id},
-		Message: "User deleted successfully",
+	page, ok := parsePageRequest(c, h.cursors, kind)
+	if !ok {
+		return
+	}
+
+	if _, err := h.userRepo.GetOwnerID(id); err != nil {
+		h.writeUserError(c, err)
+		return
+	}
+
+	users, total, err := list(id, page.Limit, page.Offset, page.Cursor)
+	if err != nil {
+		h.writeUserError(c, err)
+		return
+	}
+
+	users, meta := paginate(users, page, total, h.cursors, "created_at", "desc",
+		func(user *models.User) (string, string) {
+			return user.FollowedAt.Format(time.RFC3339Nano), user.ID
+		})
+
+	if currentUserID, exists := c.Get("userID"); exists {
+		ids := make([]string, len(users))
+		for i, user := range users {
+			ids[i] = user.ID
+		}
+		following, err := h.followRepo.FollowingIDs(currentUserID.(string), ids)
+		if err != nil {
+			h.logger.Error("Failed to resolve follow state: " + err.Error())
+		}
+		for _, user := range users {
+			user.IsFollowing = following[user.ID]
+		}
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Data:    users,
+		Message: message,
 		Success: true,
+		Meta:    meta,
+	})
+}
+
+// GetMyStats returns the authenticated user's seller earnings and sales.
+func (h *UserHandler) GetMyStats(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Authentication required",
+			Success: false,
+		})
+		return
 	}
 
-	c.JSON(http.StatusOK, response)
+	now := time.Now().UTC()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	stats, err := h.txRepo.SellerStats(userID.(string), monthStart)
+	if err != nil {
+		h.writeUserError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Data:    stats,
+		Message: "Stats retrieved successfully",
+		Success: true,
+	})
 }
 
+func (h *UserHandler) writeUserError(c *gin.Context, err error) {
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "User not found",
+			Success: false,
+		})
+		return
+	}
+
+	h.logger.Error("User operation failed: " + err.Error())
+	c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+		Error:   "Internal server error",
+		Success: false,
+	})
+}
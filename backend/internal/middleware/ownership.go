@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"database/sql"
+	"net/http"
+	"viport-backend/internal/models"
+	"viport-backend/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OwnerLookup resolves the ID of the user that owns the resource with the
+// given ID. It returns sql.ErrNoRows when the resource does not exist.
+type OwnerLookup func(resourceID string) (string, error)
+
+// IsAdmin reports whether the authenticated user has the admin role.
+func IsAdmin(c *gin.Context) bool {
+	role, exists := c.Get("role")
+	if !exists {
+		return false
+	}
+	r, ok := role.(string)
+	return ok && r == "admin"
+}
+
+// CanModify reports whether the authenticated user owns a resource owned by
+// ownerID, or is an admin.
+func CanModify(c *gin.Context, ownerID string) bool {
+	userID, exists := c.Get("userID")
+	if !exists {
+		return false
+	}
+	return userID.(string) == ownerID || IsAdmin(c)
+}
+
+// RequireOwnership loads the resource named by the :param path parameter and
+// only lets the request through when the caller owns it or is an admin.
+// Lookup failures are logged to log. It must run after AuthMiddleware.
+func RequireOwnership(log logger.Logger, resource, param string, lookup OwnerLookup) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, exists := c.Get("userID"); !exists {
+			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+				Error:   "Authentication required",
+				Success: false,
+			})
+			c.Abort()
+			return
+		}
+
+		ownerID, err := lookup(c.Param(param))
+		if err != nil {
+			if err == sql.ErrNoRows {
+				c.JSON(http.StatusNotFound, models.ErrorResponse{
+					Error:   resource + " not found",
+					Code:    "NOT_FOUND",
+					Success: false,
+				})
+				c.Abort()
+				return
+			}
+			log.Error("Failed to look up " + lowerFirst(resource) + " owner: " + err.Error())
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "Internal server error",
+				Success: false,
+			})
+			c.Abort()
+			return
+		}
+
+		if !CanModify(c, ownerID) {
+			c.JSON(http.StatusForbidden, models.ErrorResponse{
+				Error:   "Forbidden",
+				Message: "You do not have permission to modify this " + lowerFirst(resource),
+				Code:    "FORBIDDEN",
+				Success: false,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	b := []byte(s)
+	if b[0] >= 'A' && b[0] <= 'Z' {
+		b[0] += 'a' - 'A'
+	}
+	return string(b)
+}
@@ -2,6 +2,7 @@ package models
 
 import (
 	"time"
+	"viport-backend/pkg/pagination"
 )
 
 // Common response structures
@@ -19,6 +20,8 @@ type Meta struct {
 	TotalPages  int `json:"totalPages"`
 	HasNext     bool `json:"hasNext"`
 	HasPrevious bool `json:"hasPrevious"`
+	NextCursor  string `json:"nextCursor,omitempty"`
+	PrevCursor  string `json:"prevCursor,omitempty"`
 }
 
 type ErrorResponse struct {
@@ -48,6 +51,38 @@ type Category struct {
 	ItemCount int       `json:"itemCount,omitempty"`
 }
 
+type CreateCategoryRequest struct {
+	Name        string  `json:"name" validate:"required,min=2,max=100"`
+	Slug        *string `json:"slug,omitempty" validate:"omitempty,max=100"`
+	Description *string `json:"description,omitempty" validate:"omitempty,max=1000"`
+	Icon        *string `json:"icon,omitempty" validate:"omitempty,max=50"`
+	Color       *string `json:"color,omitempty" validate:"omitempty,hexcolor,len=7"`
+	ParentID    *string `json:"parentId,omitempty" validate:"omitempty,uuid"`
+}
+
+// UpdateCategoryRequest changes the given fields. Moving a category back to
+// the top level is done through the reorder endpoint.
+type UpdateCategoryRequest struct {
+	Name        *string `json:"name,omitempty" validate:"omitempty,min=2,max=100"`
+	Slug        *string `json:"slug,omitempty" validate:"omitempty,max=100"`
+	Description *string `json:"description,omitempty" validate:"omitempty,max=1000"`
+	Icon        *string `json:"icon,omitempty" validate:"omitempty,max=50"`
+	Color       *string `json:"color,omitempty" validate:"omitempty,hexcolor,len=7"`
+	ParentID    *string `json:"parentId,omitempty" validate:"omitempty,uuid"`
+}
+
+// CategoryPosition places one category in the tree; a nil ParentID puts it
+// at the top level.
+type CategoryPosition struct {
+	ID        string  `json:"id" validate:"required,uuid"`
+	ParentID  *string `json:"parentId" validate:"omitempty,uuid"`
+	SortOrder int     `json:"sortOrder" validate:"min=0"`
+}
+
+type ReorderCategoriesRequest struct {
+	Positions []CategoryPosition `json:"positions" validate:"required,min=1,max=500,dive"`
+}
+
 // Tags system
 type Tag struct {
 	ID         string    `json:"id" db:"id"`
@@ -93,10 +128,20 @@ type Comment struct {
 	UpdatedAt       time.Time  `json:"updatedAt" db:"updated_at"`
 	
 	// Joined fields
-	User     *User     `json:"user,omitempty"`
-	Parent   *Comment  `json:"parent,omitempty"`
-	Replies  []Comment `json:"replies,omitempty"`
-	IsLiked  bool      `json:"isLiked,omitempty"`
+	User       *User     `json:"user,omitempty"`
+	Parent     *Comment  `json:"parent,omitempty"`
+	Replies    []Comment `json:"replies,omitempty"`
+	ReplyCount int       `json:"replyCount,omitempty"`
+	IsLiked    bool      `json:"isLiked,omitempty"`
+}
+
+type CreateCommentRequest struct {
+	Content  string  `json:"content" validate:"required,min=1,max=2000"`
+	ParentID *string `json:"parentId,omitempty" validate:"omitempty,uuid"`
+}
+
+type UpdateCommentRequest struct {
+	Content string `json:"content" validate:"required,min=1,max=2000"`
 }
 
 // Transaction system
@@ -113,11 +158,261 @@ type Transaction struct {
 	PaymentMethod *string   `json:"paymentMethod,omitempty" db:"payment_method"`
 	PaymentStatus string    `json:"paymentStatus" db:"payment_status"`
 	TransactionID *string   `json:"transactionId,omitempty" db:"transaction_id"`
+	RefundedAmount float64  `json:"refundedAmount" db:"refunded_amount"`
+	FeeRuleID     *string   `json:"feeRuleId,omitempty" db:"fee_rule_id"`
 	CreatedAt     time.Time `json:"createdAt" db:"created_at"`
 	
 	// Joined fields
 	Buyer  *User `json:"buyer,omitempty"`
 	Seller *User `json:"seller,omitempty"`
+
+	// Set on checkout responses for declined payments, not stored
+	FailureReason string `json:"failureReason,omitempty"`
+}
+
+// LibraryItem is one completed purchase in a buyer's library, with the item
+// bought. DownloadURL issues fresh download links for products.
+type LibraryItem struct {
+	TransactionID string    `json:"transactionId"`
+	ItemType      string    `json:"itemType"`
+	ItemID        string    `json:"itemId"`
+	Amount        float64   `json:"amount"`
+	Currency      string    `json:"currency"`
+	PurchasedAt   time.Time `json:"purchasedAt"`
+	Product       *Product  `json:"product,omitempty"`
+	Course        *Course   `json:"course,omitempty"`
+	DownloadURL   string    `json:"downloadUrl,omitempty"`
+}
+
+// SavedItem is a post, product or course a user saved, with the item itself
+// on list responses.
+type SavedItem struct {
+	ID           string    `json:"id" db:"id"`
+	UserID       string    `json:"userId" db:"user_id"`
+	ItemType     string    `json:"itemType" db:"saveable_type"`
+	ItemID       string    `json:"itemId" db:"saveable_id"`
+	CollectionID *string   `json:"collectionId,omitempty" db:"collection_id"`
+	CreatedAt    time.Time `json:"createdAt" db:"created_at"`
+
+	// Joined fields
+	Post    *Post    `json:"post,omitempty"`
+	Product *Product `json:"product,omitempty"`
+	Course  *Course  `json:"course,omitempty"`
+}
+
+type SaveItemRequest struct {
+	CollectionID *string `json:"collectionId,omitempty" validate:"omitempty,uuid"`
+}
+
+type SavedCollection struct {
+	ID          string    `json:"id" db:"id"`
+	UserID      string    `json:"userId" db:"user_id"`
+	Name        string    `json:"name" db:"name"`
+	Description *string   `json:"description,omitempty" db:"description"`
+	ItemCount   int       `json:"itemCount" db:"item_count"`
+	CreatedAt   time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt   time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+type SavedCollectionRequest struct {
+	Name        string  `json:"name" validate:"required,min=1,max=100"`
+	Description *string `json:"description,omitempty" validate:"omitempty,max=1000"`
+}
+
+type PurchaseRequest struct {
+	PaymentMethod string `json:"paymentMethod" validate:"required,max=50"`
+}
+
+// FeeRule prices the platform fee of matching sales as Percentage of the
+// price plus FixedAmount. Nil criteria match every sale.
+type FeeRule struct {
+	ID                string     `json:"id" db:"id"`
+	Name              string     `json:"name" db:"name"`
+	Description       *string    `json:"description,omitempty" db:"description"`
+	CategoryID        *string    `json:"categoryId,omitempty" db:"category_id"`
+	LicenseType       *string    `json:"licenseType,omitempty" db:"license_type"`
+	VerificationLevel *string    `json:"verificationLevel,omitempty" db:"verification_level"`
+	Percentage        float64    `json:"percentage" db:"percentage"`
+	FixedAmount       float64    `json:"fixedAmount" db:"fixed_amount"`
+	Priority          int        `json:"priority" db:"priority"`
+	StartsAt          *time.Time `json:"startsAt,omitempty" db:"starts_at"`
+	EndsAt            *time.Time `json:"endsAt,omitempty" db:"ends_at"`
+	IsActive          bool       `json:"isActive" db:"is_active"`
+	CreatedAt         time.Time  `json:"createdAt" db:"created_at"`
+	UpdatedAt         time.Time  `json:"updatedAt" db:"updated_at"`
+}
+
+// FeeRuleRequest creates a fee rule or replaces all of its fields.
+type FeeRuleRequest struct {
+	Name              string     `json:"name" validate:"required,min=1,max=100"`
+	Description       *string    `json:"description,omitempty" validate:"omitempty,max=500"`
+	CategoryID        *string    `json:"categoryId,omitempty" validate:"omitempty,uuid"`
+	LicenseType       *string    `json:"licenseType,omitempty" validate:"omitempty,oneof=standard extended commercial"`
+	VerificationLevel *string    `json:"verificationLevel,omitempty" validate:"omitempty,oneof=none email phone identity creator"`
+	Percentage        float64    `json:"percentage" validate:"gte=0,lte=100"`
+	FixedAmount       float64    `json:"fixedAmount" validate:"gte=0"`
+	Priority          int        `json:"priority"`
+	StartsAt          *time.Time `json:"startsAt,omitempty"`
+	EndsAt            *time.Time `json:"endsAt,omitempty"`
+	IsActive          *bool      `json:"isActive,omitempty"`
+}
+
+// Refund returns all or part of a completed transaction to the buyer, either
+// on the buyer's request or through a card dispute.
+type Refund struct {
+	ID                string     `json:"id" db:"id"`
+	TransactionID     string     `json:"transactionId" db:"transaction_id"`
+	Kind              string     `json:"kind" db:"kind"` // refund, dispute
+	RequestedBy       *string    `json:"requestedBy,omitempty" db:"requested_by"`
+	Reason            string     `json:"reason" db:"reason"`
+	Amount            float64    `json:"amount" db:"amount"`
+	Status            string     `json:"status" db:"status"` // requested, processing, completed, rejected, failed
+	ReviewedBy        *string    `json:"reviewedBy,omitempty" db:"reviewed_by"`
+	ReviewNote        *string    `json:"reviewNote,omitempty" db:"review_note"`
+	ProviderReference *string    `json:"-" db:"provider_reference"`
+	CreatedAt         time.Time  `json:"createdAt" db:"created_at"`
+	ResolvedAt        *time.Time `json:"resolvedAt,omitempty" db:"resolved_at"`
+
+	// Joined fields
+	Transaction *Transaction `json:"transaction,omitempty"`
+}
+
+// CreateRefundRequest asks for a refund; Amount defaults to everything not
+// refunded yet.
+type CreateRefundRequest struct {
+	Amount *float64 `json:"amount,omitempty" validate:"omitempty,gt=0"`
+	Reason string   `json:"reason" validate:"required,min=3,max=2000"`
+}
+
+type ReviewRefundRequest struct {
+	Note *string `json:"note,omitempty" validate:"omitempty,max=2000"`
+}
+
+// PaymentEvent is a webhook notification from the payment provider about
+// one of our transactions.
+type PaymentEvent struct {
+	ID   string           `json:"id" validate:"required,max=255"`
+	Type string           `json:"type" validate:"required,max=50"` // payment.*, refund.* or dispute.*
+	Data PaymentEventData `json:"data"`
+}
+
+type PaymentEventData struct {
+	TransactionID string   `json:"transactionId,omitempty"` // payment.* and dispute.opened events
+	Reference     string   `json:"reference,omitempty"`
+	RefundID      string   `json:"refundId,omitempty"` // refund.* events
+	Amount        *float64 `json:"amount,omitempty"`   // dispute.opened events
+	Reason        string   `json:"reason,omitempty"`   // dispute.opened events
+}
+
+// Ledger system. Ledger amounts are integer minor units (cents); debits are
+// positive and credits negative.
+type LedgerEntry struct {
+	ID            int64     `json:"id" db:"id"`
+	JournalID     string    `json:"journalId" db:"journal_id"`
+	Kind          string    `json:"kind" db:"kind"`       // opening, sale, refund, payout
+	Account       string    `json:"account" db:"account"` // cash, platform_fees, creator_payable
+	UserID        *string   `json:"userId,omitempty" db:"user_id"`
+	TransactionID *string   `json:"transactionId,omitempty" db:"transaction_id"`
+	RefundID      *string   `json:"refundId,omitempty" db:"refund_id"`
+	PayoutID      *string   `json:"payoutId,omitempty" db:"payout_id"`
+	Amount        int64     `json:"amount" db:"amount"`
+	Currency      string    `json:"currency" db:"currency"`
+	Description   *string   `json:"description,omitempty" db:"description"`
+	CreatedAt     time.Time `json:"createdAt" db:"created_at"`
+}
+
+// LedgerBalance sums a creator's payable account in one currency. Amounts
+// are positive minor units owed to the creator.
+type LedgerBalance struct {
+	Currency  string `json:"currency"`
+	Earned    int64  `json:"earned"`   // creator share of sales
+	Refunded  int64  `json:"refunded"` // taken back by refunds
+	PaidOut   int64  `json:"paidOut"`
+	Available int64  `json:"available"` // still owed
+}
+
+// LedgerImbalance is a failed ledger invariant: a journal whose entries do
+// not sum to zero, or a transaction whose ledger totals disagree with it.
+type LedgerImbalance struct {
+	Check         string  `json:"check"` // journal_balance, transaction_cash, transaction_fee, transaction_payable
+	JournalID     *string `json:"journalId,omitempty"`
+	TransactionID *string `json:"transactionId,omitempty"`
+	Expected      int64   `json:"expected"`
+	Actual        int64   `json:"actual"`
+}
+
+type LedgerReport struct {
+	Balanced   bool               `json:"balanced"`
+	Journals   int                `json:"journals"`
+	Imbalances []*LedgerImbalance `json:"imbalances"`
+	CheckedAt  time.Time          `json:"checkedAt"`
+}
+
+// Payout system. Payout amounts are integer minor units like the ledger.
+type PayoutMethod struct {
+	ID              string    `json:"id" db:"id"`
+	UserID          string    `json:"userId" db:"user_id"`
+	Kind            string    `json:"kind" db:"kind"` // bank_account, paypal
+	Label           *string   `json:"label,omitempty" db:"label"`
+	Destination     string    `json:"-" db:"destination"`
+	DestinationHint string    `json:"destinationHint" db:"destination_hint"`
+	IsDefault       bool      `json:"isDefault" db:"is_default"`
+	CreatedAt       time.Time `json:"createdAt" db:"created_at"`
+}
+
+type CreatePayoutMethodRequest struct {
+	Kind        string  `json:"kind" validate:"required,oneof=bank_account paypal"`
+	Label       *string `json:"label,omitempty" validate:"omitempty,max=100"`
+	Destination string  `json:"destination" validate:"required,min=4,max=255"`
+	IsDefault   bool    `json:"isDefault"`
+}
+
+// PayoutSettings are a creator's payout preferences. Balances below
+// MinimumAmount are carried over to the next run.
+type PayoutSettings struct {
+	UserID        string    `json:"userId" db:"user_id"`
+	MinimumAmount int64     `json:"minimumAmount" db:"minimum_amount"`
+	OnHold        bool      `json:"onHold" db:"on_hold"`
+	HoldReason    *string   `json:"holdReason,omitempty" db:"hold_reason"`
+	UpdatedAt     time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+type UpdatePayoutSettingsRequest struct {
+	MinimumAmount int64 `json:"minimumAmount" validate:"gte=1000,lte=100000000"`
+}
+
+type Payout struct {
+	ID                string     `json:"id" db:"id"`
+	UserID            string     `json:"userId" db:"user_id"`
+	MethodID          string     `json:"methodId" db:"method_id"`
+	BatchID           *string    `json:"batchId,omitempty" db:"batch_id"`
+	Amount            int64      `json:"amount" db:"amount"`
+	Currency          string     `json:"currency" db:"currency"`
+	Status            string     `json:"status" db:"status"` // pending, held, processing, paid, failed
+	ProviderReference *string    `json:"providerReference,omitempty" db:"provider_reference"`
+	FailureReason     *string    `json:"failureReason,omitempty" db:"failure_reason"`
+	HoldReason        *string    `json:"holdReason,omitempty" db:"hold_reason"`
+	CreatedAt         time.Time  `json:"createdAt" db:"created_at"`
+	UpdatedAt         time.Time  `json:"updatedAt" db:"updated_at"`
+	PaidAt            *time.Time `json:"paidAt,omitempty" db:"paid_at"`
+
+	// Joined fields
+	Method *PayoutMethod `json:"method,omitempty"`
+}
+
+// PayoutBatch summarises one scheduled payout run.
+type PayoutBatch struct {
+	ID          string     `json:"id" db:"id"`
+	PayoutCount int        `json:"payoutCount" db:"payout_count"`
+	TotalAmount int64      `json:"totalAmount" db:"total_amount"`
+	PaidCount   int        `json:"paidCount" db:"paid_count"`
+	FailedCount int        `json:"failedCount" db:"failed_count"`
+	CreatedAt   time.Time  `json:"createdAt" db:"created_at"`
+	CompletedAt *time.Time `json:"completedAt,omitempty" db:"completed_at"`
+}
+
+type HoldPayoutRequest struct {
+	Reason *string `json:"reason,omitempty" validate:"omitempty,max=500"`
 }
 
 // Review system
@@ -139,6 +434,40 @@ type Review struct {
 	IsHelpful bool  `json:"isHelpful,omitempty"`
 }
 
+type CreateReviewRequest struct {
+	Rating  int     `json:"rating" validate:"required,min=1,max=5"`
+	Title   *string `json:"title,omitempty" validate:"omitempty,max=255"`
+	Content *string `json:"content,omitempty" validate:"omitempty,max=5000"`
+}
+
+type UpdateReviewRequest struct {
+	Rating  int     `json:"rating" validate:"required,min=1,max=5"`
+	Title   *string `json:"title,omitempty" validate:"omitempty,max=255"`
+	Content *string `json:"content,omitempty" validate:"omitempty,max=5000"`
+}
+
+type ReviewFilter struct {
+	ReviewableType string `json:"reviewableType"`
+	ReviewableID   string `json:"reviewableId"`
+	Rating         *int   `json:"rating,omitempty"`
+	Limit          int    `json:"limit"`
+	Offset         int    `json:"offset"`
+	SortBy         string `json:"sortBy"` // created_at, helpful_count; always descending
+
+	// Set by handlers, never bound from requests
+	Cursor *pagination.Cursor `json:"-"` // keyset position; replaces Offset when set
+}
+
+// RatingSummary aggregates the reviews of a product or course. Histogram
+// counts the reviews for each star rating, 1 to 5.
+type RatingSummary struct {
+	ReviewableType string      `json:"reviewableType"`
+	ReviewableID   string      `json:"reviewableId"`
+	RatingAverage  float64     `json:"ratingAverage"`
+	RatingCount    int         `json:"ratingCount"`
+	Histogram      map[int]int `json:"histogram"`
+}
+
 // Notification system
 type Notification struct {
 	ID        string                 `json:"id" db:"id"`
@@ -3,6 +3,7 @@ package models
 import (
 	"encoding/json"
 	"time"
+	"viport-backend/pkg/pagination"
 )
 
 type Course struct {
@@ -25,6 +26,7 @@ type Course struct {
 	EnrollmentCount int             `json:"enrollmentCount" db:"enrollment_count"`
 	RatingAverage   float64         `json:"ratingAverage" db:"rating_average"`
 	RatingCount     int             `json:"ratingCount" db:"rating_count"`
+	LikeCount       int             `json:"likeCount" db:"like_count"`
 	CreatedAt       time.Time       `json:"createdAt" db:"created_at"`
 	UpdatedAt       time.Time       `json:"updatedAt" db:"updated_at"`
 
@@ -36,6 +38,7 @@ type Course struct {
 	Progress     int       `json:"progress,omitempty"` // percentage
 	IsPurchased  bool      `json:"isPurchased,omitempty"`
 	IsInWishlist bool      `json:"isInWishlist,omitempty"`
+	IsLiked      bool      `json:"isLiked,omitempty"`
 }
 
 type Lesson struct {
@@ -54,6 +57,7 @@ type Lesson struct {
 	// Additional fields
 	IsCompleted bool `json:"isCompleted,omitempty"`
 	IsAccessible bool `json:"isAccessible,omitempty"`
+	HasQuiz      bool `json:"hasQuiz,omitempty"`
 }
 
 type LessonResource struct {
@@ -65,7 +69,7 @@ type LessonResource struct {
 }
 
 type CreateCourseRequest struct {
-	CategoryID      *string  `json:"categoryId,omitempty"`
+	CategoryID      *string  `json:"categoryId,omitempty" validate:"omitempty,uuid"`
 	Title           string   `json:"title" validate:"required,min=3,max=255"`
 	Subtitle        *string  `json:"subtitle,omitempty" validate:"omitempty,max=500"`
 	Description     *string  `json:"description,omitempty" validate:"omitempty,max=5000"`
@@ -81,7 +85,7 @@ type CreateCourseRequest struct {
 }
 
 type UpdateCourseRequest struct {
-	CategoryID      *string  `json:"categoryId,omitempty"`
+	CategoryID      *string  `json:"categoryId,omitempty" validate:"omitempty,uuid"`
 	Title           *string  `json:"title,omitempty" validate:"omitempty,min=3,max=255"`
 	Subtitle        *string  `json:"subtitle,omitempty" validate:"omitempty,max=500"`
 	Description     *string  `json:"description,omitempty" validate:"omitempty,max=5000"`
@@ -133,6 +137,15 @@ type CourseFilter struct {
 	Offset       int       `json:"offset"`
 	SortBy       string    `json:"sortBy"` // created_at, price, rating_average, enrollment_count
 	SortOrder    string    `json:"sortOrder"` // asc, desc
+
+	// Set by handlers, never bound from requests
+	ViewerID string             `json:"-"` // empty for anonymous viewers; sees their own unpublished courses
+	Cursor   *pagination.Cursor `json:"-"` // keyset position; replaces Offset when set
+}
+
+// ReorderLessonsRequest lists every lesson of a course in its new order.
+type ReorderLessonsRequest struct {
+	LessonIDs []string `json:"lessonIds" validate:"required,min=1,max=500,dive,uuid"`
 }
 
 type Enrollment struct {
@@ -143,9 +156,209 @@ type Enrollment struct {
 	CompletionDate       *time.Time `json:"completionDate,omitempty" db:"completion_date"`
 	ProgressPercentage   int        `json:"progressPercentage" db:"progress_percentage"`
 	LastAccessedLessonID *string    `json:"lastAccessedLessonId,omitempty" db:"last_accessed_lesson_id"`
+	LastAccessedAt       *time.Time `json:"lastAccessedAt,omitempty" db:"last_accessed_at"`
 	CertificateURL       *string    `json:"certificateUrl,omitempty" db:"certificate_url"`
 
 	// Joined fields
 	Course *Course `json:"course,omitempty"`
 	User   *User   `json:"user,omitempty"`
-}
\ No newline at end of file
+}
+
+// CourseResume is where a student left off in a course: the lesson to open
+// next, with their enrollment.
+type CourseResume struct {
+	Enrollment *Enrollment `json:"enrollment"`
+	Lesson     *Lesson     `json:"lesson,omitempty"`
+}
+
+// Certificate attests that a learner completed a course. VerificationCode is
+// printed on the PDF at URL and confirms it through the verify endpoint.
+type Certificate struct {
+	ID               string    `json:"id" db:"id"`
+	UserID           string    `json:"userId" db:"user_id"`
+	CourseID         string    `json:"courseId" db:"course_id"`
+	VerificationCode string    `json:"verificationCode" db:"verification_code"`
+	LearnerName      string    `json:"learnerName" db:"learner_name"`
+	CourseTitle      string    `json:"courseTitle" db:"course_title"`
+	InstructorName   string    `json:"instructorName" db:"instructor_name"`
+	CompletedAt      time.Time `json:"completedAt" db:"completed_at"`
+	URL              string    `json:"url" db:"url"`
+	IssuedAt         time.Time `json:"issuedAt" db:"issued_at"`
+}
+
+// Quiz is a graded check attached to a lesson. Students are shown the
+// questions without their answers.
+type Quiz struct {
+	ID                 string         `json:"id" db:"id"`
+	LessonID           string         `json:"lessonId" db:"lesson_id"`
+	CourseID           string         `json:"courseId" db:"course_id"`
+	Title              string         `json:"title" db:"title"`
+	Description        *string        `json:"description,omitempty" db:"description"`
+	PassMark           int            `json:"passMark" db:"pass_mark"`                 // percentage of points needed to pass
+	MaxAttempts        *int           `json:"maxAttempts,omitempty" db:"max_attempts"` // nil allows unlimited attempts
+	RequiredToComplete bool           `json:"requiredToComplete" db:"required_to_complete"`
+	Questions          []QuizQuestion `json:"questions" db:"questions"`
+	CreatedAt          time.Time      `json:"createdAt" db:"created_at"`
+	UpdatedAt          time.Time      `json:"updatedAt" db:"updated_at"`
+
+	// The viewer's attempts
+	AttemptsUsed      int  `json:"attemptsUsed"`
+	AttemptsRemaining *int `json:"attemptsRemaining,omitempty"`
+	BestPercentage    *int `json:"bestPercentage,omitempty"`
+	IsPassed          bool `json:"isPassed"`
+}
+
+// QuizQuestion is a multiple_choice question with one correct option, a
+// multi_select question scored on choosing exactly the correct options, or
+// a short_answer question matched against AcceptedAnswers as exact text or
+// regular expressions.
+type QuizQuestion struct {
+	ID               string       `json:"id" validate:"omitempty,max=50"`
+	Type             string       `json:"type" validate:"required,oneof=multiple_choice multi_select short_answer"`
+	Prompt           string       `json:"prompt" validate:"required,max=2000"`
+	Options          []QuizOption `json:"options,omitempty" validate:"omitempty,max=20,dive"`
+	Points           int          `json:"points" validate:"min=0,max=100"` // 0 counts as 1
+	CorrectOptionIDs []string     `json:"correctOptionIds,omitempty"`
+	AcceptedAnswers  []string     `json:"acceptedAnswers,omitempty" validate:"omitempty,max=20,dive,max=500"`
+	MatchMode        string       `json:"matchMode,omitempty" validate:"omitempty,oneof=exact regex"`
+	CaseSensitive    bool         `json:"caseSensitive,omitempty"`
+}
+
+type QuizOption struct {
+	ID   string `json:"id" validate:"required,max=50"`
+	Text string `json:"text" validate:"required,max=500"`
+}
+
+// SaveQuizRequest creates or replaces the quiz of a lesson.
+type SaveQuizRequest struct {
+	Title              string         `json:"title" validate:"required,min=3,max=255"`
+	Description        *string        `json:"description,omitempty" validate:"omitempty,max=2000"`
+	PassMark           int            `json:"passMark" validate:"required,min=1,max=100"`
+	MaxAttempts        *int           `json:"maxAttempts,omitempty" validate:"omitempty,min=1,max=100"`
+	RequiredToComplete bool           `json:"requiredToComplete"`
+	Questions          []QuizQuestion `json:"questions" validate:"required,min=1,max=100,dive"`
+}
+
+// QuizAnswer answers one question: OptionIDs for choice questions, Text for
+// short answers.
+type QuizAnswer struct {
+	QuestionID string   `json:"questionId" validate:"required,max=50"`
+	OptionIDs  []string `json:"optionIds,omitempty" validate:"omitempty,max=20"`
+	Text       *string  `json:"text,omitempty" validate:"omitempty,max=1000"`
+}
+
+type SubmitQuizRequest struct {
+	Answers []QuizAnswer `json:"answers" validate:"max=100,dive"`
+}
+
+// QuizAttempt is one scored submission of a quiz.
+type QuizAttempt struct {
+	ID         string       `json:"id" db:"id"`
+	QuizID     string       `json:"quizId" db:"quiz_id"`
+	UserID     string       `json:"userId" db:"user_id"`
+	Answers    []QuizAnswer `json:"answers" db:"answers"`
+	Results    []QuizResult `json:"results" db:"results"`
+	Score      int          `json:"score" db:"score"`
+	MaxScore   int          `json:"maxScore" db:"max_score"`
+	Percentage int          `json:"percentage" db:"percentage"`
+	Passed     bool         `json:"passed" db:"passed"`
+	CreatedAt  time.Time    `json:"createdAt" db:"created_at"`
+
+	// Joined fields
+	Enrollment *Enrollment `json:"enrollment,omitempty"`
+}
+
+// QuizResult is how one question of an attempt was scored.
+type QuizResult struct {
+	QuestionID string `json:"questionId"`
+	Correct    bool   `json:"correct"`
+	Points     int    `json:"points"`
+	MaxPoints  int    `json:"maxPoints"`
+}
+
+// AnalyticsRange is the window of course analytics trends: From inclusive,
+// To exclusive, bucketed by Interval (day, week or month, in UTC).
+type AnalyticsRange struct {
+	From     time.Time `json:"from"`
+	To       time.Time `json:"to"`
+	Interval string    `json:"interval"`
+}
+
+// CourseAnalytics is how a course performs, for its instructor. Overview
+// and Lessons describe the current enrollments; the trends and Revenue
+// cover Range.
+type CourseAnalytics struct {
+	CourseID     string              `json:"courseId"`
+	Range        AnalyticsRange      `json:"range"`
+	Overview     *CourseOverview     `json:"overview"`
+	Enrollments  []*EnrollmentPoint  `json:"enrollments"`
+	Lessons      []*LessonFunnelStep `json:"lessons"`
+	Revenue      []*CourseRevenue    `json:"revenue"`
+	RevenueTrend []*RevenuePoint     `json:"revenueTrend"`
+	Ratings      *RatingSummary      `json:"ratings"`
+	RatingTrend  []*RatingPoint      `json:"ratingTrend"`
+}
+
+// CourseOverview sums up a course's enrollments. Rates and progress are
+// percentages.
+type CourseOverview struct {
+	Enrollments     int     `json:"enrollments"`
+	Completions     int     `json:"completions"`
+	CompletionRate  float64 `json:"completionRate"`
+	AverageProgress float64 `json:"averageProgress"`
+	NewEnrollments  int     `json:"newEnrollments"` // enrolled within the range
+	ActiveLearners  int     `json:"activeLearners"` // opened a lesson within the range
+}
+
+// EnrollmentPoint counts the enrollments and course completions of one
+// interval starting at Period.
+type EnrollmentPoint struct {
+	Period      time.Time `json:"period"`
+	Enrollments int       `json:"enrollments"`
+	Completions int       `json:"completions"`
+}
+
+// LessonFunnelStep is one lesson of the completion funnel. CompletionRate
+// is the share of enrolled learners who completed the lesson, DropOffRate
+// the share of those who completed the previous lesson, or enrolled for
+// the first one, who did not. Stalled counts learners who have not
+// finished the course and left off at this lesson.
+type LessonFunnelStep struct {
+	LessonID       string  `json:"lessonId"`
+	Title          string  `json:"title"`
+	SortOrder      int     `json:"sortOrder"`
+	Completions    int     `json:"completions"`
+	CompletionRate float64 `json:"completionRate"`
+	DropOffRate    float64 `json:"dropOffRate"`
+	Stalled        int     `json:"stalled"`
+}
+
+// CourseRevenue sums a course's sales in one currency by sale date. Fees
+// and Net are what the platform and the instructor keep after refunds.
+type CourseRevenue struct {
+	Currency string  `json:"currency"`
+	Sales    int     `json:"sales"`
+	Gross    float64 `json:"gross"`
+	Refunded float64 `json:"refunded"`
+	Fees     float64 `json:"fees"`
+	Net      float64 `json:"net"`
+}
+
+// RevenuePoint sums the sales of one interval in one currency.
+type RevenuePoint struct {
+	Period   time.Time `json:"period"`
+	Currency string    `json:"currency"`
+	Sales    int       `json:"sales"`
+	Gross    float64   `json:"gross"`
+	Net      float64   `json:"net"`
+}
+
+// RatingPoint describes the reviews of one interval. Average covers the
+// interval's reviews alone and CumulativeAverage every review up to its
+// end; both are nil without reviews.
+type RatingPoint struct {
+	Period            time.Time `json:"period"`
+	Reviews           int       `json:"reviews"`
+	Average           *float64  `json:"average"`
+	CumulativeAverage *float64  `json:"cumulativeAverage"`
+}
@@ -3,6 +3,7 @@ package models
 import (
 	"encoding/json"
 	"time"
+	"viport-backend/pkg/pagination"
 )
 
 type Post struct {
@@ -44,7 +45,7 @@ type CreatePostRequest struct {
 	MediaURLs  []MediaFile `json:"mediaUrls,omitempty"`
 	MediaType  string      `json:"mediaType" validate:"oneof=image video mixed text"`
 	Visibility string      `json:"visibility" validate:"oneof=public followers private"`
-	TagNames   []string    `json:"tagNames,omitempty"`
+	TagNames   []string    `json:"tagNames,omitempty" validate:"omitempty,max=10,dive,max=50"`
 }
 
 type UpdatePostRequest struct {
@@ -52,7 +53,7 @@ type UpdatePostRequest struct {
 	Content    *string     `json:"content,omitempty" validate:"omitempty,max=5000"`
 	MediaURLs  []MediaFile `json:"mediaUrls,omitempty"`
 	Visibility *string     `json:"visibility,omitempty" validate:"omitempty,oneof=public followers private"`
-	TagNames   []string    `json:"tagNames,omitempty"`
+	TagNames   []string    `json:"tagNames,omitempty" validate:"omitempty,max=10,dive,max=50"`
 }
 
 type PostFilter struct {
@@ -66,4 +67,9 @@ type PostFilter struct {
 	Offset     int     `json:"offset"`
 	SortBy     string  `json:"sortBy"` // created_at, like_count, view_count
 	SortOrder  string  `json:"sortOrder"` // asc, desc
+
+	// Set by handlers, never bound from requests
+	ViewerID      string             `json:"-"` // empty for anonymous viewers
+	FollowingOnly bool               `json:"-"` // restrict to the viewer and accounts they follow
+	Cursor        *pagination.Cursor `json:"-"` // keyset position; replaces Offset when set
 }
\ No newline at end of file
@@ -3,6 +3,7 @@ package models
 import (
 	"encoding/json"
 	"time"
+	"viport-backend/pkg/pagination"
 )
 
 type Product struct {
@@ -14,7 +15,7 @@ type Product struct {
 	ShortDescription *string         `json:"shortDescription,omitempty" db:"short_description"`
 	ThumbnailURL     *string         `json:"thumbnailUrl,omitempty" db:"thumbnail_url"`
 	PreviewImages    json.RawMessage `json:"previewImages,omitempty" db:"preview_images"`
-	FileURLs         json.RawMessage `json:"fileUrls,omitempty" db:"file_urls"`
+	FileURLs         json.RawMessage `json:"-" db:"file_urls"` // only served through download links
 	DemoURL          *string         `json:"demoUrl,omitempty" db:"demo_url"`
 	Price            float64         `json:"price" db:"price"`
 	OriginalPrice    *float64        `json:"originalPrice,omitempty" db:"original_price"`
@@ -26,8 +27,10 @@ type Product struct {
 	Requirements     *string         `json:"requirements,omitempty" db:"requirements"`
 	Status           string          `json:"status" db:"status"`
 	DownloadCount    int             `json:"downloadCount" db:"download_count"`
+	DownloadLimit    *int            `json:"downloadLimit,omitempty" db:"download_limit"`
 	RatingAverage    float64         `json:"ratingAverage" db:"rating_average"`
 	RatingCount      int             `json:"ratingCount" db:"rating_count"`
+	LikeCount        int             `json:"likeCount" db:"like_count"`
 	CreatedAt        time.Time       `json:"createdAt" db:"created_at"`
 	UpdatedAt        time.Time       `json:"updatedAt" db:"updated_at"`
 
@@ -40,6 +43,8 @@ type Product struct {
 	IsInWishlist bool      `json:"isInWishlist,omitempty"`
 }
 
+// ProductFile is a downloadable file of a product. URL is never served
+// directly; buyers get it through a signed download link.
 type ProductFile struct {
 	Name        string `json:"name"`
 	URL         string `json:"url"`
@@ -48,8 +53,27 @@ type ProductFile struct {
 	Description string `json:"description,omitempty"`
 }
 
+// ProductDownloads lists the signed download links of a product's files for
+// one user. DownloadLimit is 0 for the product's owner, who is not limited.
+type ProductDownloads struct {
+	ProductID     string         `json:"productId"`
+	Files         []DownloadLink `json:"files"`
+	DownloadsUsed int            `json:"downloadsUsed"`
+	DownloadLimit int            `json:"downloadLimit,omitempty"`
+	ExpiresAt     time.Time      `json:"expiresAt"`
+}
+
+type DownloadLink struct {
+	Index       int    `json:"index"`
+	Name        string `json:"name"`
+	Size        int64  `json:"size"`
+	Format      string `json:"format"`
+	Description string `json:"description,omitempty"`
+	URL         string `json:"url"`
+}
+
 type CreateProductRequest struct {
-	CategoryID       *string       `json:"categoryId,omitempty"`
+	CategoryID       *string       `json:"categoryId,omitempty" validate:"omitempty,uuid"`
 	Title            string        `json:"title" validate:"required,min=3,max=255"`
 	Description      *string       `json:"description,omitempty" validate:"omitempty,max=5000"`
 	ShortDescription *string       `json:"shortDescription,omitempty" validate:"omitempty,max=500"`
@@ -65,11 +89,12 @@ type CreateProductRequest struct {
 	FileFormat       *string       `json:"fileFormat,omitempty"`
 	Compatibility    *string       `json:"compatibility,omitempty"`
 	Requirements     *string       `json:"requirements,omitempty"`
-	TagNames         []string      `json:"tagNames,omitempty"`
+	DownloadLimit    *int          `json:"downloadLimit,omitempty" validate:"omitempty,min=1,max=1000"`
+	TagNames         []string      `json:"tagNames,omitempty" validate:"omitempty,max=10,dive,max=50"`
 }
 
 type UpdateProductRequest struct {
-	CategoryID       *string       `json:"categoryId,omitempty"`
+	CategoryID       *string       `json:"categoryId,omitempty" validate:"omitempty,uuid"`
 	Title            *string       `json:"title,omitempty" validate:"omitempty,min=3,max=255"`
 	Description      *string       `json:"description,omitempty" validate:"omitempty,max=5000"`
 	ShortDescription *string       `json:"shortDescription,omitempty" validate:"omitempty,max=500"`
@@ -85,8 +110,9 @@ type UpdateProductRequest struct {
 	FileFormat       *string       `json:"fileFormat,omitempty"`
 	Compatibility    *string       `json:"compatibility,omitempty"`
 	Requirements     *string       `json:"requirements,omitempty"`
+	DownloadLimit    *int          `json:"downloadLimit,omitempty" validate:"omitempty,min=1,max=1000"`
 	Status           *string       `json:"status,omitempty" validate:"omitempty,oneof=draft pending active suspended"`
-	TagNames         []string      `json:"tagNames,omitempty"`
+	TagNames         []string      `json:"tagNames,omitempty" validate:"omitempty,max=10,dive,max=50"`
 }
 
 type ProductFilter struct {
@@ -103,4 +129,8 @@ type ProductFilter struct {
 	Offset       int       `json:"offset"`
 	SortBy       string    `json:"sortBy"` // created_at, price, rating_average, download_count
 	SortOrder    string    `json:"sortOrder"` // asc, desc
+
+	// Set by handlers, never bound from requests
+	ViewerID string             `json:"-"` // empty for anonymous viewers; sees their own unpublished products
+	Cursor   *pagination.Cursor `json:"-"` // keyset position; replaces Offset when set
 }
\ No newline at end of file
@@ -29,11 +29,12 @@ type User struct {
 	LastActiveAt      *time.Time      `json:"lastActiveAt,omitempty" db:"last_active_at"`
 
 	// Additional fields for API responses
-	FollowerCount  int  `json:"followerCount,omitempty"`
-	FollowingCount int  `json:"followingCount,omitempty"`
-	PostCount      int  `json:"postCount,omitempty"`
-	ProductCount   int  `json:"productCount,omitempty"`
-	IsFollowing    bool `json:"isFollowing,omitempty"`
+	FollowerCount  int        `json:"followerCount,omitempty"`
+	FollowingCount int        `json:"followingCount,omitempty"`
+	PostCount      int        `json:"postCount,omitempty"`
+	ProductCount   int        `json:"productCount,omitempty"`
+	IsFollowing    bool       `json:"isFollowing,omitempty"`
+	FollowedAt     *time.Time `json:"followedAt,omitempty"` // in follower lists
 }
 
 type SocialLinks struct {
@@ -0,0 +1,305 @@
+package repositories
+
+import (
+	"database/sql"
+	"viport-backend/internal/models"
+)
+
+// analyticsBuckets is a CTE listing the UTC intervals of an analytics range
+// as "period": $2 is the interval unit, $3 and $4 the range bounds. Queries
+// using it group their rows with bucketOf.
+const analyticsBuckets = `buckets AS (
+	SELECT generate_series(
+		date_trunc($2::text, $3::timestamptz AT TIME ZONE 'UTC'),
+		$4::timestamptz AT TIME ZONE 'UTC' - INTERVAL '1 microsecond',
+		('1 ' || $2::text)::interval
+	) AS period
+)`
+
+// bucketOf returns the SQL for the analytics interval containing column.
+func bucketOf(column string) string {
+	return `date_trunc($2::text, ` + column + ` AT TIME ZONE 'UTC')`
+}
+
+// AnalyticsRepository aggregates a course's enrollments, sales and reviews
+// for its instructor.
+type AnalyticsRepository struct {
+	db *sql.DB
+}
+
+func NewAnalyticsRepository(db *sql.DB) *AnalyticsRepository {
+	return &AnalyticsRepository{db: db}
+}
+
+func (r *AnalyticsRepository) IsConnected() bool {
+	return r.db != nil
+}
+
+// CourseOverview sums up the course's current enrollments; new and active
+// learners are counted within the range.
+func (r *AnalyticsRepository) CourseOverview(courseID string, window models.AnalyticsRange) (*models.CourseOverview, error) {
+	if !r.IsConnected() {
+		return nil, sql.ErrConnDone
+	}
+	overview := &models.CourseOverview{}
+	if !isUUID(courseID) {
+		return overview, nil
+	}
+
+	err := r.db.QueryRow(`
+		SELECT COUNT(*), COUNT(completion_date),
+			COALESCE(ROUND(COUNT(completion_date) * 100.0 / NULLIF(COUNT(*), 0), 2), 0),
+			COALESCE(ROUND(AVG(progress_percentage), 2), 0),
+			COUNT(*) FILTER (WHERE enrollment_date >= $2 AND enrollment_date < $3),
+			COUNT(*) FILTER (WHERE last_accessed_at >= $2 AND last_accessed_at < $3)
+		FROM enrollments
+		WHERE course_id = $1`, courseID, window.From, window.To,
+	).Scan(
+		&overview.Enrollments, &overview.Completions, &overview.CompletionRate,
+		&overview.AverageProgress, &overview.NewEnrollments, &overview.ActiveLearners,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return overview, nil
+}
+
+// EnrollmentTrend counts the course's enrollments and completions in each
+// interval of the range, empty intervals included.
+func (r *AnalyticsRepository) EnrollmentTrend(courseID string, window models.AnalyticsRange) ([]*models.EnrollmentPoint, error) {
+	if !r.IsConnected() {
+		return nil, sql.ErrConnDone
+	}
+	points := []*models.EnrollmentPoint{}
+	if !isUUID(courseID) {
+		return points, nil
+	}
+
+	rows, err := r.db.Query(`
+		WITH `+analyticsBuckets+`,
+		enrolled AS (
+			SELECT `+bucketOf("enrollment_date")+` AS period, COUNT(*) AS count
+			FROM enrollments
+			WHERE course_id = $1 AND enrollment_date >= $3 AND enrollment_date < $4
+			GROUP BY 1
+		),
+		completed AS (
+			SELECT `+bucketOf("completion_date")+` AS period, COUNT(*) AS count
+			FROM enrollments
+			WHERE course_id = $1 AND completion_date >= $3 AND completion_date < $4
+			GROUP BY 1
+		)
+		SELECT b.period AT TIME ZONE 'UTC', COALESCE(e.count, 0), COALESCE(c.count, 0)
+		FROM buckets b
+		LEFT JOIN enrolled e ON e.period = b.period
+		LEFT JOIN completed c ON c.period = b.period
+		ORDER BY b.period`, courseID, window.Interval, window.From, window.To)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		point := &models.EnrollmentPoint{}
+		if err := rows.Scan(&point.Period, &point.Enrollments, &point.Completions); err != nil {
+			return nil, err
+		}
+		point.Period = point.Period.UTC()
+		points = append(points, point)
+	}
+	return points, rows.Err()
+}
+
+// LessonFunnel returns the course's lessons in order with how many enrolled
+// learners completed each, counting completions as progress does, and where
+// the others dropped off.
+func (r *AnalyticsRepository) LessonFunnel(courseID string) ([]*models.LessonFunnelStep, error) {
+	if !r.IsConnected() {
+		return nil, sql.ErrConnDone
+	}
+	steps := []*models.LessonFunnelStep{}
+	if !isUUID(courseID) {
+		return steps, nil
+	}
+
+	rows, err := r.db.Query(`
+		WITH enrolled AS (
+			SELECT COUNT(*) AS count FROM enrollments WHERE course_id = $1
+		),
+		completed AS (
+			SELECT lc.lesson_id, COUNT(*) AS count
+			FROM lesson_completions lc
+			JOIN enrollments e ON e.user_id = lc.user_id AND e.course_id = lc.course_id
+			WHERE lc.course_id = $1 AND `+countedCompletion+`
+			GROUP BY lc.lesson_id
+		),
+		stalled AS (
+			SELECT last_accessed_lesson_id AS lesson_id, COUNT(*) AS count
+			FROM enrollments
+			WHERE course_id = $1 AND completion_date IS NULL AND last_accessed_lesson_id IS NOT NULL
+			GROUP BY last_accessed_lesson_id
+		),
+		steps AS (
+			SELECT l.id, l.title, l.sort_order, COALESCE(c.count, 0) AS completions,
+				COALESCE(s.count, 0) AS stalled, n.count AS enrolled,
+				LAG(COALESCE(c.count, 0), 1, n.count) OVER (ORDER BY l.sort_order, l.id) AS reached
+			FROM lessons l
+			CROSS JOIN enrolled n
+			LEFT JOIN completed c ON c.lesson_id = l.id
+			LEFT JOIN stalled s ON s.lesson_id = l.id
+			WHERE l.course_id = $1
+		)
+		SELECT id, title, sort_order, completions,
+			COALESCE(ROUND(completions * 100.0 / NULLIF(enrolled, 0), 2), 0),
+			COALESCE(GREATEST(ROUND((reached - completions) * 100.0 / NULLIF(reached, 0), 2), 0), 0),
+			stalled
+		FROM steps
+		ORDER BY sort_order, id`, courseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		step := &models.LessonFunnelStep{}
+		err := rows.Scan(
+			&step.LessonID, &step.Title, &step.SortOrder, &step.Completions,
+			&step.CompletionRate, &step.DropOffRate, &step.Stalled,
+		)
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, step)
+	}
+	return steps, rows.Err()
+}
+
+// CourseRevenue sums the course's settled sales made within the range per
+// currency. Refunds count against the sale they refund.
+func (r *AnalyticsRepository) CourseRevenue(courseID string, window models.AnalyticsRange) ([]*models.CourseRevenue, error) {
+	if !r.IsConnected() {
+		return nil, sql.ErrConnDone
+	}
+	revenue := []*models.CourseRevenue{}
+	if !isUUID(courseID) {
+		return revenue, nil
+	}
+
+	rows, err := r.db.Query(`
+		SELECT currency, COUNT(*), SUM(amount), SUM(refunded_amount),
+			COALESCE(SUM(fee_amount), 0), SUM(net_amount)
+		FROM transactions
+		WHERE item_type = 'course' AND item_id = $1
+			AND payment_status IN ('completed', 'refunded')
+			AND created_at >= $2 AND created_at < $3
+		GROUP BY currency
+		ORDER BY currency`, courseID, window.From, window.To)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		total := &models.CourseRevenue{}
+		err := rows.Scan(&total.Currency, &total.Sales, &total.Gross, &total.Refunded, &total.Fees, &total.Net)
+		if err != nil {
+			return nil, err
+		}
+		revenue = append(revenue, total)
+	}
+	return revenue, rows.Err()
+}
+
+// RevenueTrend sums the course's settled sales in each interval of the
+// range for every currency it sold in, empty intervals included.
+func (r *AnalyticsRepository) RevenueTrend(courseID string, window models.AnalyticsRange) ([]*models.RevenuePoint, error) {
+	if !r.IsConnected() {
+		return nil, sql.ErrConnDone
+	}
+	points := []*models.RevenuePoint{}
+	if !isUUID(courseID) {
+		return points, nil
+	}
+
+	rows, err := r.db.Query(`
+		WITH `+analyticsBuckets+`,
+		sales AS (
+			SELECT `+bucketOf("created_at")+` AS period, currency,
+				COUNT(*) AS count, SUM(amount) AS gross, SUM(net_amount) AS net
+			FROM transactions
+			WHERE item_type = 'course' AND item_id = $1
+				AND payment_status IN ('completed', 'refunded')
+				AND created_at >= $3 AND created_at < $4
+			GROUP BY 1, currency
+		)
+		SELECT b.period AT TIME ZONE 'UTC', cur.currency,
+			COALESCE(s.count, 0), COALESCE(s.gross, 0), COALESCE(s.net, 0)
+		FROM buckets b
+		CROSS JOIN (SELECT DISTINCT currency FROM sales) cur
+		LEFT JOIN sales s ON s.period = b.period AND s.currency = cur.currency
+		ORDER BY cur.currency, b.period`, courseID, window.Interval, window.From, window.To)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		point := &models.RevenuePoint{}
+		if err := rows.Scan(&point.Period, &point.Currency, &point.Sales, &point.Gross, &point.Net); err != nil {
+			return nil, err
+		}
+		point.Period = point.Period.UTC()
+		points = append(points, point)
+	}
+	return points, rows.Err()
+}
+
+// RatingTrend averages the course's reviews in each interval of the range,
+// alongside the average of every review posted up to its end.
+func (r *AnalyticsRepository) RatingTrend(courseID string, window models.AnalyticsRange) ([]*models.RatingPoint, error) {
+	if !r.IsConnected() {
+		return nil, sql.ErrConnDone
+	}
+	points := []*models.RatingPoint{}
+	if !isUUID(courseID) {
+		return points, nil
+	}
+
+	rows, err := r.db.Query(`
+		WITH `+analyticsBuckets+`,
+		earlier AS (
+			SELECT COUNT(*) AS count, COALESCE(SUM(rating), 0) AS total
+			FROM reviews
+			WHERE reviewable_type = 'course' AND reviewable_id = $1 AND created_at < $3
+		),
+		reviewed AS (
+			SELECT `+bucketOf("created_at")+` AS period, COUNT(*) AS count, SUM(rating) AS total
+			FROM reviews
+			WHERE reviewable_type = 'course' AND reviewable_id = $1
+				AND created_at >= $3 AND created_at < $4
+			GROUP BY 1
+		)
+		SELECT b.period AT TIME ZONE 'UTC', COALESCE(v.count, 0),
+			ROUND(v.total::numeric / v.count, 2),
+			ROUND((e.total + SUM(COALESCE(v.total, 0)) OVER running)::numeric
+				/ NULLIF(e.count + SUM(COALESCE(v.count, 0)) OVER running, 0), 2)
+		FROM buckets b
+		CROSS JOIN earlier e
+		LEFT JOIN reviewed v ON v.period = b.period
+		WINDOW running AS (ORDER BY b.period)
+		ORDER BY b.period`, courseID, window.Interval, window.From, window.To)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		point := &models.RatingPoint{}
+		if err := rows.Scan(&point.Period, &point.Reviews, &point.Average, &point.CumulativeAverage); err != nil {
+			return nil, err
+		}
+		point.Period = point.Period.UTC()
+		points = append(points, point)
+	}
+	return points, rows.Err()
+}
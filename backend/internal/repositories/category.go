@@ -0,0 +1,303 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"viport-backend/internal/models"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrDuplicateSlug  = errors.New("slug already in use")
+	ErrCategoryCycle  = errors.New("category cannot be nested under itself or its descendants")
+	ErrParentNotFound = errors.New("parent category not found")
+)
+
+// Columns selected for every category read. item_count counts the active
+// products and published courses filed directly under the category.
+const categorySelectColumns = `
+	c.id, c.name, c.slug, c.description, c.icon, c.color, c.parent_id,
+	c.is_active, c.sort_order, c.created_at,
+	(SELECT COUNT(*) FROM products p WHERE p.category_id = c.id AND p.status = 'active') +
+	(SELECT COUNT(*) FROM courses co WHERE co.category_id = c.id AND co.status = 'published')`
+
+type CategoryRepository struct {
+	db *sql.DB
+}
+
+func NewCategoryRepository(db *sql.DB) *CategoryRepository {
+	return &CategoryRepository{db: db}
+}
+
+func (r *CategoryRepository) IsConnected() bool {
+	return r.db != nil
+}
+
+// List returns every category, ordered for display, with the item count of
+// each category on its own. Inactive categories are skipped unless
+// includeInactive is set.
+func (r *CategoryRepository) List(includeInactive bool) ([]*models.Category, error) {
+	if !r.IsConnected() {
+		return nil, sql.ErrConnDone
+	}
+
+	query := `SELECT ` + categorySelectColumns + ` FROM categories c`
+	if !includeInactive {
+		query += ` WHERE c.is_active`
+	}
+	query += ` ORDER BY c.sort_order, c.name`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := []*models.Category{}
+	for rows.Next() {
+		category, err := scanCategory(rows)
+		if err != nil {
+			return nil, err
+		}
+		categories = append(categories, category)
+	}
+
+	return categories, rows.Err()
+}
+
+func (r *CategoryRepository) GetByID(id string) (*models.Category, error) {
+	if !r.IsConnected() {
+		return nil, sql.ErrConnDone
+	}
+	if !isUUID(id) {
+		return nil, sql.ErrNoRows
+	}
+
+	return scanCategory(r.db.QueryRow(`SELECT `+categorySelectColumns+` FROM categories c WHERE c.id = $1`, id))
+}
+
+func (r *CategoryRepository) GetBySlug(slug string) (*models.Category, error) {
+	if !r.IsConnected() {
+		return nil, sql.ErrConnDone
+	}
+
+	return scanCategory(r.db.QueryRow(`SELECT `+categorySelectColumns+` FROM categories c WHERE c.slug = $1`, slug))
+}
+
+// Create inserts the category after its last sibling. It returns ErrDuplicateSlug when the slug is taken and
+// ErrParentNotFound for an unknown parent.
+func (r *CategoryRepository) Create(category *models.Category) error {
+	if !r.IsConnected() {
+		return sql.ErrConnDone
+	}
+	if category.ParentID != nil && !isUUID(*category.ParentID) {
+		return ErrParentNotFound
+	}
+
+	err := r.db.QueryRow(`
+		INSERT INTO categories (name, slug, description, icon, color, parent_id, is_active, sort_order)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, (
+			SELECT COALESCE(MAX(sort_order), -1) + 1 FROM categories WHERE parent_id IS NOT DISTINCT FROM $6::uuid
+		))
+		RETURNING id, sort_order, created_at`,
+		category.Name, category.Slug, category.Description, category.Icon, category.Color,
+		category.ParentID, category.IsActive,
+	).Scan(&category.ID, &category.SortOrder, &category.CreatedAt)
+
+	return categoryWriteError(err)
+}
+
+// Update saves every editable field of the category, including its parent.
+// Moving a category under itself or one of its descendants returns
+// ErrCategoryCycle.
+func (r *CategoryRepository) Update(category *models.Category) error {
+	if !r.IsConnected() {
+		return sql.ErrConnDone
+	}
+	if !isUUID(category.ID) {
+		return sql.ErrNoRows
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if category.ParentID != nil {
+		if err := checkCategoryParent(tx, category.ID, *category.ParentID); err != nil {
+			return err
+		}
+	}
+
+	result, err := tx.Exec(`
+		UPDATE categories SET
+			name = $2, slug = $3, description = $4, icon = $5, color = $6,
+			parent_id = $7, is_active = $8, sort_order = $9
+		WHERE id = $1`,
+		category.ID, category.Name, category.Slug, category.Description, category.Icon,
+		category.Color, category.ParentID, category.IsActive, category.SortOrder,
+	)
+	if err != nil {
+		return categoryWriteError(err)
+	}
+	if err := expectAffected(result); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Reorder applies a batch of position changes atomically. Every entry sets
+// the parent and sort order of one category; an unknown ID rolls the whole
+// batch back with sql.ErrNoRows.
+func (r *CategoryRepository) Reorder(positions []models.CategoryPosition) error {
+	if !r.IsConnected() {
+		return sql.ErrConnDone
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, position := range positions {
+		if !isUUID(position.ID) {
+			return sql.ErrNoRows
+		}
+		if position.ParentID != nil && !isUUID(*position.ParentID) {
+			return ErrParentNotFound
+		}
+
+		result, err := tx.Exec(`UPDATE categories SET parent_id = $2, sort_order = $3 WHERE id = $1`,
+			position.ID, position.ParentID, position.SortOrder)
+		if err != nil {
+			return categoryWriteError(err)
+		}
+		if err := expectAffected(result); err != nil {
+			return err
+		}
+	}
+
+	// Check for cycles once every move is applied, since a batch may swap a
+	// parent and child
+	for _, position := range positions {
+		if position.ParentID == nil {
+			continue
+		}
+		if err := checkCategoryParent(tx, position.ID, *position.ParentID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// SetActive activates or deactivates a category. Deactivated categories and
+// everything below them disappear from the public tree.
+func (r *CategoryRepository) SetActive(id string, active bool) error {
+	if !r.IsConnected() {
+		return sql.ErrConnDone
+	}
+	if !isUUID(id) {
+		return sql.ErrNoRows
+	}
+
+	result, err := r.db.Exec(`UPDATE categories SET is_active = $2 WHERE id = $1`, id, active)
+	if err != nil {
+		return err
+	}
+
+	return expectAffected(result)
+}
+
+// checkCategoryParent returns ErrParentNotFound when parentID does not exist
+// and ErrCategoryCycle when it is id itself or one of its descendants.
+func checkCategoryParent(tx *sql.Tx, id, parentID string) error {
+	if !isUUID(parentID) {
+		return ErrParentNotFound
+	}
+
+	var exists, cycle bool
+	err := tx.QueryRow(`
+		WITH RECURSIVE ancestors AS (
+			SELECT id, parent_id FROM categories WHERE id = $2
+			UNION
+			SELECT c.id, c.parent_id FROM categories c
+			JOIN ancestors a ON c.id = a.parent_id
+		)
+		SELECT EXISTS (SELECT 1 FROM categories WHERE id = $2),
+			   EXISTS (SELECT 1 FROM ancestors WHERE id = $1)`, id, parentID).Scan(&exists, &cycle)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrParentNotFound
+	}
+	if cycle {
+		return ErrCategoryCycle
+	}
+	return nil
+}
+
+// categoryWriteError maps constraint violations on categories to the
+// repository's sentinel errors.
+func categoryWriteError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code.Name() {
+		case "unique_violation":
+			return ErrDuplicateSlug
+		case "foreign_key_violation":
+			return ErrParentNotFound
+		}
+	}
+	return err
+}
+
+// categoriesByID loads the categories with the given IDs, without item
+// counts, for attaching to listed products and courses.
+func categoriesByID(db *sql.DB, ids []string) (map[string]*models.Category, error) {
+	categories := make(map[string]*models.Category, len(ids))
+	if len(ids) == 0 {
+		return categories, nil
+	}
+
+	rows, err := db.Query(`
+		SELECT id, name, slug, description, icon, color, parent_id, is_active, sort_order, created_at
+		FROM categories
+		WHERE id = ANY($1::uuid[])`, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		category := &models.Category{}
+		err := rows.Scan(
+			&category.ID, &category.Name, &category.Slug, &category.Description,
+			&category.Icon, &category.Color, &category.ParentID, &category.IsActive,
+			&category.SortOrder, &category.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		categories[category.ID] = category
+	}
+	return categories, rows.Err()
+}
+
+func scanCategory(row rowScanner) (*models.Category, error) {
+	category := &models.Category{}
+	err := row.Scan(
+		&category.ID, &category.Name, &category.Slug, &category.Description,
+		&category.Icon, &category.Color, &category.ParentID, &category.IsActive,
+		&category.SortOrder, &category.CreatedAt, &category.ItemCount,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return category, nil
+}
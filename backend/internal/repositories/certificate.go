@@ -0,0 +1,111 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"viport-backend/internal/models"
+
+	"github.com/lib/pq"
+)
+
+var ErrCertificateExists = errors.New("certificate already issued")
+
+const certificateSelectColumns = `
+	c.id, c.user_id, c.course_id, c.verification_code, c.learner_name,
+	c.course_title, c.instructor_name, c.completed_at, c.url, c.issued_at`
+
+type CertificateRepository struct {
+	db *sql.DB
+}
+
+func NewCertificateRepository(db *sql.DB) *CertificateRepository {
+	return &CertificateRepository{db: db}
+}
+
+func (r *CertificateRepository) IsConnected() bool {
+	return r.db != nil
+}
+
+// Create records an issued certificate and links it from the learner's
+// enrollment. It returns ErrCertificateExists when the learner already has
+// a certificate for the course.
+func (r *CertificateRepository) Create(certificate *models.Certificate) error {
+	if !r.IsConnected() {
+		return sql.ErrConnDone
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
+		INSERT INTO certificates (
+			user_id, course_id, verification_code, learner_name, course_title,
+			instructor_name, completed_at, url
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, issued_at`,
+		certificate.UserID, certificate.CourseID, certificate.VerificationCode,
+		certificate.LearnerName, certificate.CourseTitle, certificate.InstructorName,
+		certificate.CompletedAt, certificate.URL,
+	).Scan(&certificate.ID, &certificate.IssuedAt)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code.Name() == "unique_violation" {
+			return ErrCertificateExists
+		}
+		return err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE enrollments SET certificate_url = $3
+		WHERE user_id = $1 AND course_id = $2`,
+		certificate.UserID, certificate.CourseID, certificate.URL)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetByCode returns the certificate with the verification code, or
+// sql.ErrNoRows.
+func (r *CertificateRepository) GetByCode(code string) (*models.Certificate, error) {
+	if !r.IsConnected() {
+		return nil, sql.ErrConnDone
+	}
+
+	return scanCertificate(r.db.QueryRow(`
+		SELECT `+certificateSelectColumns+` FROM certificates c
+		WHERE c.verification_code = $1`, code))
+}
+
+// GetByUserCourse returns the learner's certificate for the course, or
+// sql.ErrNoRows.
+func (r *CertificateRepository) GetByUserCourse(userID, courseID string) (*models.Certificate, error) {
+	if !r.IsConnected() {
+		return nil, sql.ErrConnDone
+	}
+	if !isUUID(userID) || !isUUID(courseID) {
+		return nil, sql.ErrNoRows
+	}
+
+	return scanCertificate(r.db.QueryRow(`
+		SELECT `+certificateSelectColumns+` FROM certificates c
+		WHERE c.user_id = $1 AND c.course_id = $2`, userID, courseID))
+}
+
+func scanCertificate(row rowScanner) (*models.Certificate, error) {
+	var certificate models.Certificate
+	err := row.Scan(
+		&certificate.ID, &certificate.UserID, &certificate.CourseID,
+		&certificate.VerificationCode, &certificate.LearnerName, &certificate.CourseTitle,
+		&certificate.InstructorName, &certificate.CompletedAt, &certificate.URL,
+		&certificate.IssuedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &certificate, nil
+}
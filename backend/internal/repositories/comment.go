@@ -0,0 +1,493 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+	"viport-backend/internal/models"
+	"viport-backend/pkg/pagination"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Tables that can be commented on, keyed by commentable_type.
+var commentableTables = map[string]string{
+	"post":    "posts",
+	"product": "products",
+	"course":  "courses",
+}
+
+// Commentable types whose table keeps a denormalized comment_count.
+var commentCountTables = map[string]string{
+	"post": "posts",
+}
+
+// Number of direct replies embedded under each comment in a thread listing.
+const commentReplyPreviewSize = 3
+
+// IsCommentableType reports whether comments are supported for the given type.
+func IsCommentableType(commentableType string) bool {
+	_, ok := commentableTables[commentableType]
+	return ok
+}
+
+type CommentRepository struct {
+	db *sql.DB
+}
+
+func NewCommentRepository(db *sql.DB) *CommentRepository {
+	return &CommentRepository{db: db}
+}
+
+func (r *CommentRepository) IsConnected() bool {
+	return r.db != nil
+}
+
+const commentSelectColumns = `
+	c.id, c.user_id, c.commentable_type, c.commentable_id, c.parent_id,
+	c.content, c.like_count, c.created_at, c.updated_at,
+	u.id, u.username, u.display_name, u.avatar_url, u.is_verified, u.is_creator`
+
+// Create inserts the comment and keeps the target's comment_count in sync.
+// It returns sql.ErrNoRows when the commented item or the parent comment does
+// not exist, or the parent belongs to a different item.
+func (r *CommentRepository) Create(comment *models.Comment) error {
+	if !r.IsConnected() {
+		return sql.ErrConnDone
+	}
+	table, ok := commentableTables[comment.CommentableType]
+	if !ok {
+		return fmt.Errorf("unsupported commentable type %q", comment.CommentableType)
+	}
+	if !isUUID(comment.CommentableID) {
+		return sql.ErrNoRows
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	err = tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM `+table+` WHERE id = $1)`, comment.CommentableID).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return sql.ErrNoRows
+	}
+
+	if comment.ParentID != nil {
+		if !isUUID(*comment.ParentID) {
+			return sql.ErrNoRows
+		}
+		err = tx.QueryRow(`
+			SELECT EXISTS (
+				SELECT 1 FROM comments
+				WHERE id = $1 AND commentable_type = $2 AND commentable_id = $3
+			)`, *comment.ParentID, comment.CommentableType, comment.CommentableID).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return sql.ErrNoRows
+		}
+	}
+
+	comment.ID = uuid.New().String()
+	comment.CreatedAt = time.Now()
+	comment.UpdatedAt = comment.CreatedAt
+
+	_, err = tx.Exec(`
+		INSERT INTO comments (
+			id, user_id, commentable_type, commentable_id, parent_id,
+			content, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		comment.ID, comment.UserID, comment.CommentableType, comment.CommentableID,
+		comment.ParentID, comment.Content, comment.CreatedAt, comment.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	if counterTable, ok := commentCountTables[comment.CommentableType]; ok {
+		_, err = tx.Exec(`UPDATE `+counterTable+` SET comment_count = comment_count + 1 WHERE id = $1`, comment.CommentableID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *CommentRepository) GetByID(id string) (*models.Comment, error) {
+	if !r.IsConnected() {
+		return nil, sql.ErrConnDone
+	}
+	if !isUUID(id) {
+		return nil, sql.ErrNoRows
+	}
+
+	query := `SELECT ` + commentSelectColumns + `
+		FROM comments c
+		JOIN users u ON u.id = c.user_id
+		WHERE c.id = $1`
+
+	comment, err := scanComment(r.db.QueryRow(query, id))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.attachReplyCounts([]*models.Comment{comment}); err != nil {
+		return nil, err
+	}
+
+	return comment, nil
+}
+
+// GetOwnerID returns the ID of the comment's author.
+func (r *CommentRepository) GetOwnerID(id string) (string, error) {
+	if !r.IsConnected() {
+		return "", sql.ErrConnDone
+	}
+	if !isUUID(id) {
+		return "", sql.ErrNoRows
+	}
+
+	var ownerID string
+	err := r.db.QueryRow(`SELECT user_id FROM comments WHERE id = $1`, id).Scan(&ownerID)
+	return ownerID, err
+}
+
+// ListThreads returns a page of top-level comments on an item, newest first.
+// Each comment carries its first few replies and its total reply count; the
+// rest of a thread is paged through ListReplies. The returned total counts
+// all top-level comments on the item. Up to limit+1 comments are returned so
+// the caller can tell whether another page follows.
+func (r *CommentRepository) ListThreads(commentableType, commentableID string, cursor *pagination.Cursor, limit int) ([]*models.Comment, int, error) {
+	if !r.IsConnected() {
+		return nil, 0, sql.ErrConnDone
+	}
+	if !isUUID(commentableID) {
+		return []*models.Comment{}, 0, nil
+	}
+
+	var total int
+	err := r.db.QueryRow(`
+		SELECT COUNT(*) FROM comments
+		WHERE commentable_type = $1 AND commentable_id = $2 AND parent_id IS NULL`,
+		commentableType, commentableID).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	args := []interface{}{commentableType, commentableID}
+	addArg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	keyset, orderBy := keysetClause("c.created_at", "timestamptz", "c.id", true, cursor, addArg)
+	if keyset != "" {
+		keyset = "AND " + keyset
+	}
+
+	query := fmt.Sprintf(`SELECT %s
+		FROM comments c
+		JOIN users u ON u.id = c.user_id
+		WHERE c.commentable_type = $1 AND c.commentable_id = $2 AND c.parent_id IS NULL %s
+		ORDER BY %s
+		LIMIT %s`, commentSelectColumns, keyset, orderBy, addArg(limit+1))
+
+	comments, err := r.queryComments(query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	reverseRows(comments, cursor)
+
+	if err := r.attachReplyPreviews(comments); err != nil {
+		return nil, 0, err
+	}
+
+	return comments, total, nil
+}
+
+// ListReplies returns a page of direct replies to a comment, oldest first,
+// each annotated with its own reply count. Like ListThreads it returns up to
+// limit+1 rows.
+func (r *CommentRepository) ListReplies(parentID string, cursor *pagination.Cursor, limit int) ([]*models.Comment, error) {
+	if !r.IsConnected() {
+		return nil, sql.ErrConnDone
+	}
+	if !isUUID(parentID) {
+		return []*models.Comment{}, nil
+	}
+
+	args := []interface{}{parentID}
+	addArg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	keyset, orderBy := keysetClause("c.created_at", "timestamptz", "c.id", false, cursor, addArg)
+	if keyset != "" {
+		keyset = "AND " + keyset
+	}
+
+	query := fmt.Sprintf(`SELECT %s
+		FROM comments c
+		JOIN users u ON u.id = c.user_id
+		WHERE c.parent_id = $1 %s
+		ORDER BY %s
+		LIMIT %s`, commentSelectColumns, keyset, orderBy, addArg(limit+1))
+
+	replies, err := r.queryComments(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	reverseRows(replies, cursor)
+
+	if err := r.attachReplyCounts(replies); err != nil {
+		return nil, err
+	}
+
+	return replies, nil
+}
+
+func (r *CommentRepository) Update(comment *models.Comment) error {
+	if !r.IsConnected() {
+		return sql.ErrConnDone
+	}
+
+	comment.UpdatedAt = time.Now()
+
+	result, err := r.db.Exec(`
+		UPDATE comments SET content = $2, updated_at = $3
+		WHERE id = $1`, comment.ID, comment.Content, comment.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	return expectAffected(result)
+}
+
+// Delete removes a comment together with all of its replies and their likes,
+// and lowers the target's comment_count by the number of rows removed.
+func (r *CommentRepository) Delete(id string) (int, error) {
+	if !r.IsConnected() {
+		return 0, sql.ErrConnDone
+	}
+	if !isUUID(id) {
+		return 0, sql.ErrNoRows
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var commentableType, commentableID string
+	err = tx.QueryRow(`SELECT commentable_type, commentable_id FROM comments WHERE id = $1 FOR UPDATE`, id).
+		Scan(&commentableType, &commentableID)
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := tx.Query(`
+		WITH RECURSIVE thread AS (
+			SELECT id FROM comments WHERE id = $1
+			UNION ALL
+			SELECT c.id FROM comments c JOIN thread t ON c.parent_id = t.id
+		)
+		SELECT id FROM thread`, id)
+	if err != nil {
+		return 0, err
+	}
+	var ids []string
+	for rows.Next() {
+		var threadID string
+		if err := rows.Scan(&threadID); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ids = append(ids, threadID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	_, err = tx.Exec(`DELETE FROM likes WHERE likeable_type = 'comment' AND likeable_id = ANY($1::uuid[])`, pq.Array(ids))
+	if err != nil {
+		return 0, err
+	}
+
+	// Parent and replies go in one statement so the self-referencing foreign
+	// key is only checked once the whole thread is gone.
+	result, err := tx.Exec(`DELETE FROM comments WHERE id = ANY($1::uuid[])`, pq.Array(ids))
+	if err != nil {
+		return 0, err
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	if counterTable, ok := commentCountTables[commentableType]; ok {
+		_, err = tx.Exec(`
+			UPDATE `+counterTable+` SET comment_count = GREATEST(comment_count - $2, 0)
+			WHERE id = $1`, commentableID, deleted)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return int(deleted), nil
+}
+
+func (r *CommentRepository) queryComments(query string, args ...interface{}) ([]*models.Comment, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	comments := []*models.Comment{}
+	for rows.Next() {
+		comment, err := scanComment(rows)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, comment)
+	}
+
+	return comments, rows.Err()
+}
+
+// attachReplyPreviews embeds the oldest few replies of every comment using a
+// single windowed query, then fills in reply counts for both levels.
+func (r *CommentRepository) attachReplyPreviews(comments []*models.Comment) error {
+	if len(comments) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(comments))
+	byID := make(map[string]*models.Comment, len(comments))
+	for i, comment := range comments {
+		ids[i] = comment.ID
+		byID[comment.ID] = comment
+	}
+
+	query := fmt.Sprintf(`SELECT %s
+		FROM (
+			SELECT *, ROW_NUMBER() OVER (PARTITION BY parent_id ORDER BY created_at, id) AS position
+			FROM comments
+			WHERE parent_id = ANY($1::uuid[])
+		) c
+		JOIN users u ON u.id = c.user_id
+		WHERE c.position <= $2
+		ORDER BY c.created_at ASC, c.id ASC`, commentSelectColumns)
+
+	replies, err := r.queryComments(query, pq.Array(ids), commentReplyPreviewSize)
+	if err != nil {
+		return err
+	}
+
+	if err := r.attachReplyCounts(append(append([]*models.Comment{}, comments...), replies...)); err != nil {
+		return err
+	}
+
+	for _, reply := range replies {
+		if parent, ok := byID[*reply.ParentID]; ok {
+			parent.Replies = append(parent.Replies, *reply)
+		}
+	}
+
+	return nil
+}
+
+func (r *CommentRepository) attachReplyCounts(comments []*models.Comment) error {
+	if len(comments) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(comments))
+	for i, comment := range comments {
+		ids[i] = comment.ID
+	}
+
+	rows, err := r.db.Query(`
+		SELECT parent_id, COUNT(*) FROM comments
+		WHERE parent_id = ANY($1::uuid[])
+		GROUP BY parent_id`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var parentID string
+		var count int
+		if err := rows.Scan(&parentID, &count); err != nil {
+			return err
+		}
+		counts[parentID] = count
+	}
+
+	for _, comment := range comments {
+		comment.ReplyCount = counts[comment.ID]
+	}
+
+	return rows.Err()
+}
+
+// deleteInteractions removes the likes and comments of an item inside tx,
+// with the likes on those comments. Both tables reference items by type and
+// ID without a foreign key, so nothing cascades when the item goes.
+func deleteInteractions(tx *sql.Tx, itemType, itemID string) error {
+	_, err := tx.Exec(`
+		DELETE FROM likes
+		WHERE likeable_type = 'comment' AND likeable_id IN (
+			SELECT id FROM comments WHERE commentable_type = $1 AND commentable_id = $2
+		)`, itemType, itemID)
+	if err != nil {
+		return err
+	}
+
+	// Threads go in one statement so the self-referencing foreign key is
+	// only checked once every reply is gone
+	_, err = tx.Exec(`DELETE FROM comments WHERE commentable_type = $1 AND commentable_id = $2`, itemType, itemID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`DELETE FROM likes WHERE likeable_type = $1 AND likeable_id = $2`, itemType, itemID)
+	return err
+}
+
+func scanComment(row rowScanner) (*models.Comment, error) {
+	comment := &models.Comment{}
+	user := &models.User{}
+
+	err := row.Scan(
+		&comment.ID, &comment.UserID, &comment.CommentableType, &comment.CommentableID,
+		&comment.ParentID, &comment.Content, &comment.LikeCount,
+		&comment.CreatedAt, &comment.UpdatedAt,
+		&user.ID, &user.Username, &user.DisplayName, &user.AvatarURL,
+		&user.IsVerified, &user.IsCreator,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	comment.User = user
+	return comment, nil
+}
@@ -0,0 +1,82 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"viport-backend/pkg/pagination"
+
+	"github.com/google/uuid"
+)
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// expectAffected turns an UPDATE or DELETE that matched nothing into
+// sql.ErrNoRows so callers can treat it like a failed lookup.
+func expectAffected(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// escapeLike escapes the ILIKE wildcards in user supplied search terms.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// isUUID reports whether id can be compared against a UUID column. Lookups
+// with malformed IDs are answered with sql.ErrNoRows instead of a cast error.
+func isUUID(id string) bool {
+	_, err := uuid.Parse(id)
+	return err == nil
+}
+
+// sortColumn is an allowlisted ORDER BY column and the SQL type cursor values
+// for it are cast to.
+type sortColumn struct {
+	column string
+	cast   string
+}
+
+// keysetClause builds the ORDER BY for a list sorted on column (cast to the
+// given SQL type when compared) with idColumn as tie breaker, and, when a
+// cursor is given, the condition selecting rows past it. Backward cursors
+// flip the scan direction; callers reverse the rows afterwards with
+// reverseRows so pages always come back in list order.
+func keysetClause(column, cast, idColumn string, desc bool, cursor *pagination.Cursor, addArg func(interface{}) string) (string, string) {
+	if cursor != nil && cursor.Backward {
+		desc = !desc
+	}
+
+	direction, comparison := "ASC", ">"
+	if desc {
+		direction, comparison = "DESC", "<"
+	}
+	orderBy := fmt.Sprintf("%s %s, %s %s", column, direction, idColumn, direction)
+
+	if cursor == nil {
+		return "", orderBy
+	}
+
+	condition := fmt.Sprintf("(%s, %s) %s (%s::%s, %s::uuid)",
+		column, idColumn, comparison, addArg(cursor.Value), cast, addArg(cursor.ID))
+	return condition, orderBy
+}
+
+// reverseRows restores list order for rows fetched with a backward cursor.
+func reverseRows[T any](rows []T, cursor *pagination.Cursor) {
+	if cursor == nil || !cursor.Backward {
+		return
+	}
+	for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+		rows[i], rows[j] = rows[j], rows[i]
+	}
+}
@@ -0,0 +1,749 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"viport-backend/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrCourseHasNoLessons = errors.New("a course needs at least one lesson to be published")
+	ErrLessonOrderInvalid = errors.New("lesson order must list every lesson of the course exactly once")
+)
+
+type CourseRepository struct {
+	db *sql.DB
+}
+
+func NewCourseRepository(db *sql.DB) *CourseRepository {
+	return &CourseRepository{db: db}
+}
+
+func (r *CourseRepository) IsConnected() bool {
+	return r.db != nil
+}
+
+// Columns selected for every course read, including the instructor summary.
+const courseSelectColumns = `
+	co.id, co.instructor_id, co.category_id, co.title, co.subtitle, co.description,
+	co.thumbnail_url, co.preview_video_url, co.price, co.is_free, co.level,
+	co.duration_hours, co.language, co.requirements, co.what_you_learn, co.status,
+	co.enrollment_count, co.rating_average, co.rating_count, co.like_count, co.created_at, co.updated_at,
+	u.id, u.username, u.display_name, u.avatar_url, u.is_verified, u.is_creator`
+
+const lessonSelectColumns = `
+	l.id, l.course_id, l.title, l.description, l.content, l.video_url,
+	l.duration_minutes, l.sort_order, l.is_preview, l.resources, l.created_at,
+	EXISTS (SELECT 1 FROM quizzes q WHERE q.lesson_id = l.id)`
+
+// Sortable columns for CourseFilter.SortBy and the SQL type their cursor
+// values are cast to. Anything else falls back to created_at.
+var courseSortColumns = map[string]sortColumn{
+	"created_at":       {"co.created_at", "timestamptz"},
+	"price":            {"co.price", "numeric"},
+	"rating_average":   {"co.rating_average", "numeric"},
+	"enrollment_count": {"co.enrollment_count", "integer"},
+}
+
+func (r *CourseRepository) Create(course *models.Course) error {
+	if !r.IsConnected() {
+		return sql.ErrConnDone
+	}
+
+	course.ID = uuid.New().String()
+	course.CreatedAt = time.Now()
+	course.UpdatedAt = course.CreatedAt
+	if len(course.WhatYouLearn) == 0 {
+		course.WhatYouLearn = []byte("[]")
+	}
+
+	_, err := r.db.Exec(`
+		INSERT INTO courses (
+			id, instructor_id, category_id, title, subtitle, description, thumbnail_url,
+			preview_video_url, price, is_free, level, duration_hours, language,
+			requirements, what_you_learn, status, created_at, updated_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18
+		)`,
+		course.ID, course.InstructorID, course.CategoryID, course.Title, course.Subtitle,
+		course.Description, course.ThumbnailURL, course.PreviewVideoURL, course.Price,
+		course.IsFree, course.Level, course.DurationHours, course.Language,
+		course.Requirements, []byte(course.WhatYouLearn), course.Status,
+		course.CreatedAt, course.UpdatedAt,
+	)
+	return courseWriteError(err)
+}
+
+func (r *CourseRepository) GetByID(id string) (*models.Course, error) {
+	if !r.IsConnected() {
+		return nil, sql.ErrConnDone
+	}
+	if !isUUID(id) {
+		return nil, sql.ErrNoRows
+	}
+
+	course, err := scanCourse(r.db.QueryRow(`SELECT `+courseSelectColumns+`
+		FROM courses co
+		JOIN users u ON u.id = co.instructor_id
+		WHERE co.id = $1`, id))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.attachCategories([]*models.Course{course}); err != nil {
+		return nil, err
+	}
+	return course, nil
+}
+
+// GetByIDs loads several courses at once, keyed by ID. Courses that do not
+// exist are left out.
+func (r *CourseRepository) GetByIDs(ids []string) (map[string]*models.Course, error) {
+	if !r.IsConnected() {
+		return nil, sql.ErrConnDone
+	}
+
+	found := make(map[string]*models.Course)
+	if len(ids) == 0 {
+		return found, nil
+	}
+
+	rows, err := r.db.Query(`SELECT `+courseSelectColumns+`
+		FROM courses co
+		JOIN users u ON u.id = co.instructor_id
+		WHERE co.id = ANY($1::uuid[])`, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var courses []*models.Course
+	for rows.Next() {
+		course, err := scanCourse(rows)
+		if err != nil {
+			return nil, err
+		}
+		courses = append(courses, course)
+		found[course.ID] = course
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := r.attachCategories(courses); err != nil {
+		return nil, err
+	}
+	return found, nil
+}
+
+// GetVisibleByID is GetByID restricted to courses the viewer may see.
+// Unpublished courses are reported as sql.ErrNoRows to everyone but their
+// instructor.
+func (r *CourseRepository) GetVisibleByID(id, viewerID string) (*models.Course, error) {
+	course, err := r.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if course.Status != "published" && course.InstructorID != viewerID {
+		return nil, sql.ErrNoRows
+	}
+	return course, nil
+}
+
+// List returns the page of courses matching filter together with the total
+// number of matching rows. When filter.Cursor is set it pages by keyset from
+// the cursor instead of by Offset. Up to Limit+1 courses are returned so the
+// caller can tell whether another page follows.
+func (r *CourseRepository) List(filter models.CourseFilter) ([]*models.Course, int, error) {
+	if !r.IsConnected() {
+		return nil, 0, sql.ErrConnDone
+	}
+
+	where, args := buildCourseWhere(filter)
+
+	var total int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM courses co`+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	sort, ok := courseSortColumns[filter.SortBy]
+	if !ok {
+		sort = courseSortColumns["created_at"]
+	}
+
+	addArg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	keyset, orderBy := keysetClause(sort.column, sort.cast, "co.id",
+		!strings.EqualFold(filter.SortOrder, "asc"), filter.Cursor, addArg)
+	if keyset != "" {
+		where += " AND " + keyset
+	}
+
+	offset := filter.Offset
+	if filter.Cursor != nil {
+		offset = 0
+	}
+
+	query := fmt.Sprintf(`SELECT %s
+		FROM courses co
+		JOIN users u ON u.id = co.instructor_id%s
+		ORDER BY %s
+		LIMIT %s OFFSET %s`,
+		courseSelectColumns, where, orderBy, addArg(filter.Limit+1), addArg(offset))
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	courses := []*models.Course{}
+	for rows.Next() {
+		course, err := scanCourse(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		courses = append(courses, course)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	reverseRows(courses, filter.Cursor)
+
+	if err := r.attachCategories(courses); err != nil {
+		return nil, 0, err
+	}
+
+	return courses, total, nil
+}
+
+// GetOwnerID returns the ID of the course's instructor.
+func (r *CourseRepository) GetOwnerID(id string) (string, error) {
+	if !r.IsConnected() {
+		return "", sql.ErrConnDone
+	}
+	if !isUUID(id) {
+		return "", sql.ErrNoRows
+	}
+
+	var ownerID string
+	err := r.db.QueryRow(`SELECT instructor_id FROM courses WHERE id = $1`, id).Scan(&ownerID)
+	return ownerID, err
+}
+
+// Update saves the editable course fields, Status included. It returns
+// ErrCourseHasNoLessons when publishing a course without lessons.
+func (r *CourseRepository) Update(course *models.Course) error {
+	if !r.IsConnected() {
+		return sql.ErrConnDone
+	}
+	if !isUUID(course.ID) {
+		return sql.ErrNoRows
+	}
+
+	course.UpdatedAt = time.Now()
+	if len(course.WhatYouLearn) == 0 {
+		course.WhatYouLearn = []byte("[]")
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Lock the course so lessons cannot be removed while it is published
+	var status string
+	if err := tx.QueryRow(`SELECT status FROM courses WHERE id = $1 FOR UPDATE`, course.ID).Scan(&status); err != nil {
+		return err
+	}
+
+	if course.Status == "published" && status != "published" {
+		var hasLessons bool
+		err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM lessons WHERE course_id = $1)`, course.ID).Scan(&hasLessons)
+		if err != nil {
+			return err
+		}
+		if !hasLessons {
+			return ErrCourseHasNoLessons
+		}
+	}
+
+	_, err = tx.Exec(`
+		UPDATE courses SET
+			category_id = $2, title = $3, subtitle = $4, description = $5,
+			thumbnail_url = $6, preview_video_url = $7, price = $8, is_free = $9,
+			level = $10, duration_hours = $11, language = $12, requirements = $13,
+			what_you_learn = $14, status = $15, updated_at = $16
+		WHERE id = $1`,
+		course.ID, course.CategoryID, course.Title, course.Subtitle, course.Description,
+		course.ThumbnailURL, course.PreviewVideoURL, course.Price, course.IsFree,
+		course.Level, course.DurationHours, course.Language, course.Requirements,
+		[]byte(course.WhatYouLearn), course.Status, course.UpdatedAt,
+	)
+	if err != nil {
+		return courseWriteError(err)
+	}
+
+	return tx.Commit()
+}
+
+// ListLessons returns the lessons of a course in order.
+func (r *CourseRepository) ListLessons(courseID string) ([]models.Lesson, error) {
+	if !r.IsConnected() {
+		return nil, sql.ErrConnDone
+	}
+	lessons := []models.Lesson{}
+	if !isUUID(courseID) {
+		return lessons, nil
+	}
+
+	rows, err := r.db.Query(`
+		SELECT `+lessonSelectColumns+`
+		FROM lessons l
+		WHERE l.course_id = $1
+		ORDER BY l.sort_order, l.created_at, l.id`, courseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		lesson, err := scanLesson(rows)
+		if err != nil {
+			return nil, err
+		}
+		lessons = append(lessons, *lesson)
+	}
+	return lessons, rows.Err()
+}
+
+// GetLesson returns a lesson of the given course, or sql.ErrNoRows.
+func (r *CourseRepository) GetLesson(courseID, lessonID string) (*models.Lesson, error) {
+	if !r.IsConnected() {
+		return nil, sql.ErrConnDone
+	}
+	if !isUUID(courseID) || !isUUID(lessonID) {
+		return nil, sql.ErrNoRows
+	}
+
+	return scanLesson(r.db.QueryRow(`
+		SELECT `+lessonSelectColumns+`
+		FROM lessons l
+		WHERE l.id = $1 AND l.course_id = $2`, lessonID, courseID))
+}
+
+// CreateLesson adds a lesson at position lesson.SortOrder, counted from 1,
+// moving later lessons down. A SortOrder of 0 or past the end appends it.
+func (r *CourseRepository) CreateLesson(lesson *models.Lesson) error {
+	if !r.IsConnected() {
+		return sql.ErrConnDone
+	}
+	if !isUUID(lesson.CourseID) {
+		return sql.ErrNoRows
+	}
+
+	lesson.ID = uuid.New().String()
+	lesson.CreatedAt = time.Now()
+	if len(lesson.Resources) == 0 {
+		lesson.Resources = []byte("[]")
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	order, err := lockLessonOrder(tx, lesson.CourseID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO lessons (
+			id, course_id, title, description, content, video_url, duration_minutes,
+			sort_order, is_preview, resources, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		lesson.ID, lesson.CourseID, lesson.Title, lesson.Description, lesson.Content,
+		lesson.VideoURL, lesson.DurationMinutes, len(order)+1, lesson.IsPreview,
+		[]byte(lesson.Resources), lesson.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	order = moveLesson(append(order, lesson.ID), lesson.ID, lesson.SortOrder)
+	if err := writeLessonOrder(tx, lesson.CourseID, order); err != nil {
+		return err
+	}
+	lesson.SortOrder = positionOf(order, lesson.ID)
+
+	// A new lesson lowers everyone's share of the course completed
+	if err := recomputeProgress(tx, lesson.CourseID, ""); err != nil {
+		return err
+	}
+
+	if err := touchCourse(tx, lesson.CourseID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UpdateLesson saves the editable lesson fields. When lesson.SortOrder
+// changes the lesson moves to that position, counted from 1, and the lessons
+// in between shift to make room.
+func (r *CourseRepository) UpdateLesson(lesson *models.Lesson) error {
+	if !r.IsConnected() {
+		return sql.ErrConnDone
+	}
+	if !isUUID(lesson.CourseID) || !isUUID(lesson.ID) {
+		return sql.ErrNoRows
+	}
+	if len(lesson.Resources) == 0 {
+		lesson.Resources = []byte("[]")
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	order, err := lockLessonOrder(tx, lesson.CourseID)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(`
+		UPDATE lessons SET
+			title = $3, description = $4, content = $5, video_url = $6,
+			duration_minutes = $7, is_preview = $8, resources = $9
+		WHERE id = $1 AND course_id = $2`,
+		lesson.ID, lesson.CourseID, lesson.Title, lesson.Description, lesson.Content,
+		lesson.VideoURL, lesson.DurationMinutes, lesson.IsPreview, []byte(lesson.Resources),
+	)
+	if err != nil {
+		return err
+	}
+	if err := expectAffected(result); err != nil {
+		return err
+	}
+
+	if lesson.SortOrder != positionOf(order, lesson.ID) {
+		order = moveLesson(order, lesson.ID, lesson.SortOrder)
+		if err := writeLessonOrder(tx, lesson.CourseID, order); err != nil {
+			return err
+		}
+		lesson.SortOrder = positionOf(order, lesson.ID)
+	}
+
+	if err := touchCourse(tx, lesson.CourseID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteLesson removes a lesson and closes the gap it leaves in the order.
+// It returns ErrCourseHasNoLessons when removing the last lesson of a
+// published course.
+func (r *CourseRepository) DeleteLesson(courseID, lessonID string) error {
+	if !r.IsConnected() {
+		return sql.ErrConnDone
+	}
+	if !isUUID(courseID) || !isUUID(lessonID) {
+		return sql.ErrNoRows
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	order, err := lockLessonOrder(tx, courseID)
+	if err != nil {
+		return err
+	}
+	if positionOf(order, lessonID) == 0 {
+		return sql.ErrNoRows
+	}
+
+	var status string
+	if err := tx.QueryRow(`SELECT status FROM courses WHERE id = $1`, courseID).Scan(&status); err != nil {
+		return err
+	}
+	if status == "published" && len(order) == 1 {
+		return ErrCourseHasNoLessons
+	}
+
+	if _, err := tx.Exec(`DELETE FROM lessons WHERE id = $1 AND course_id = $2`, lessonID, courseID); err != nil {
+		return err
+	}
+
+	order = moveLesson(order, lessonID, -1)
+	if err := writeLessonOrder(tx, courseID, order); err != nil {
+		return err
+	}
+
+	if err := recomputeProgress(tx, courseID, ""); err != nil {
+		return err
+	}
+
+	if err := touchCourse(tx, courseID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ReorderLessons puts the course's lessons in the given order. lessonIDs
+// must list every lesson of the course exactly once.
+func (r *CourseRepository) ReorderLessons(courseID string, lessonIDs []string) error {
+	if !r.IsConnected() {
+		return sql.ErrConnDone
+	}
+	if !isUUID(courseID) {
+		return sql.ErrNoRows
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	order, err := lockLessonOrder(tx, courseID)
+	if err != nil {
+		return err
+	}
+
+	if len(lessonIDs) != len(order) {
+		return ErrLessonOrderInvalid
+	}
+	seen := make(map[string]bool, len(lessonIDs))
+	for _, id := range lessonIDs {
+		if seen[id] || positionOf(order, id) == 0 {
+			return ErrLessonOrderInvalid
+		}
+		seen[id] = true
+	}
+
+	if err := writeLessonOrder(tx, courseID, lessonIDs); err != nil {
+		return err
+	}
+
+	if err := touchCourse(tx, courseID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// lockLessonOrder locks the course row, serializing lesson changes, and
+// returns its lesson IDs in order. It returns sql.ErrNoRows when the course
+// does not exist.
+func lockLessonOrder(tx *sql.Tx, courseID string) ([]string, error) {
+	var id string
+	if err := tx.QueryRow(`SELECT id FROM courses WHERE id = $1 FOR UPDATE`, courseID).Scan(&id); err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.Query(`
+		SELECT id FROM lessons
+		WHERE course_id = $1
+		ORDER BY sort_order, created_at, id`, courseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var order []string
+	for rows.Next() {
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		order = append(order, id)
+	}
+	return order, rows.Err()
+}
+
+// writeLessonOrder numbers the course's lessons 1..n in the given order.
+func writeLessonOrder(tx *sql.Tx, courseID string, order []string) error {
+	_, err := tx.Exec(`
+		UPDATE lessons l SET sort_order = o.idx
+		FROM unnest($2::uuid[]) WITH ORDINALITY AS o(id, idx)
+		WHERE l.id = o.id AND l.course_id = $1 AND l.sort_order <> o.idx`,
+		courseID, pq.Array(order))
+	return err
+}
+
+// moveLesson returns order with lessonID moved to the 1-based position,
+// clamped to the list, or removed when position is negative. A position of
+// 0 moves it to the end.
+func moveLesson(order []string, lessonID string, position int) []string {
+	moved := make([]string, 0, len(order))
+	for _, id := range order {
+		if id != lessonID {
+			moved = append(moved, id)
+		}
+	}
+	if position < 0 {
+		return moved
+	}
+	if position == 0 || position > len(moved)+1 {
+		position = len(moved) + 1
+	}
+
+	moved = append(moved, "")
+	copy(moved[position:], moved[position-1:])
+	moved[position-1] = lessonID
+	return moved
+}
+
+// positionOf returns the 1-based position of lessonID in order, or 0.
+func positionOf(order []string, lessonID string) int {
+	for i, id := range order {
+		if id == lessonID {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+func touchCourse(tx *sql.Tx, courseID string) error {
+	_, err := tx.Exec(`UPDATE courses SET updated_at = NOW() WHERE id = $1`, courseID)
+	return err
+}
+
+func buildCourseWhere(filter models.CourseFilter) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	addArg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	// Only published courses are listed, apart from the viewer's own
+	conditions = append(conditions, "(co.status = 'published' OR co.instructor_id = "+addArg(viewerArg(filter.ViewerID))+")")
+
+	if filter.InstructorID != nil {
+		if !isUUID(*filter.InstructorID) {
+			conditions = append(conditions, "FALSE")
+		} else {
+			conditions = append(conditions, "co.instructor_id = "+addArg(*filter.InstructorID))
+		}
+	}
+	if filter.CategoryID != nil {
+		if !isUUID(*filter.CategoryID) {
+			conditions = append(conditions, "FALSE")
+		} else {
+			conditions = append(conditions, "co.category_id = "+addArg(*filter.CategoryID))
+		}
+	}
+	if filter.Status != nil {
+		conditions = append(conditions, "co.status = "+addArg(*filter.Status))
+	}
+	if filter.IsFree != nil {
+		conditions = append(conditions, "co.is_free = "+addArg(*filter.IsFree))
+	}
+	if filter.Level != nil {
+		conditions = append(conditions, "co.level = "+addArg(*filter.Level))
+	}
+	if filter.Language != nil {
+		conditions = append(conditions, "co.language = "+addArg(*filter.Language))
+	}
+	if filter.MinPrice != nil {
+		conditions = append(conditions, "co.price >= "+addArg(*filter.MinPrice))
+	}
+	if filter.MaxPrice != nil {
+		conditions = append(conditions, "co.price <= "+addArg(*filter.MaxPrice))
+	}
+	if filter.Search != nil && *filter.Search != "" {
+		pattern := addArg("%" + escapeLike(*filter.Search) + "%")
+		conditions = append(conditions,
+			"(co.title ILIKE "+pattern+" OR co.subtitle ILIKE "+pattern+" OR co.description ILIKE "+pattern+")")
+	}
+
+	return "\n\t\tWHERE " + strings.Join(conditions, " AND "), args
+}
+
+func (r *CourseRepository) attachCategories(courses []*models.Course) error {
+	var ids []string
+	seen := make(map[string]bool)
+	for _, course := range courses {
+		if course.CategoryID != nil && !seen[*course.CategoryID] {
+			seen[*course.CategoryID] = true
+			ids = append(ids, *course.CategoryID)
+		}
+	}
+
+	categories, err := categoriesByID(r.db, ids)
+	if err != nil {
+		return err
+	}
+
+	for _, course := range courses {
+		if course.CategoryID != nil {
+			course.Category = categories[*course.CategoryID]
+		}
+	}
+	return nil
+}
+
+func courseWriteError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code.Name() == "foreign_key_violation" {
+		return ErrCategoryNotFound
+	}
+	return err
+}
+
+func scanCourse(row rowScanner) (*models.Course, error) {
+	course := &models.Course{}
+	user := &models.User{}
+	var whatYouLearn []byte
+
+	err := row.Scan(
+		&course.ID, &course.InstructorID, &course.CategoryID, &course.Title,
+		&course.Subtitle, &course.Description, &course.ThumbnailURL,
+		&course.PreviewVideoURL, &course.Price, &course.IsFree, &course.Level,
+		&course.DurationHours, &course.Language, &course.Requirements, &whatYouLearn,
+		&course.Status, &course.EnrollmentCount, &course.RatingAverage,
+		&course.RatingCount, &course.LikeCount, &course.CreatedAt, &course.UpdatedAt,
+		&user.ID, &user.Username, &user.DisplayName, &user.AvatarURL,
+		&user.IsVerified, &user.IsCreator,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	course.WhatYouLearn = whatYouLearn
+	course.Instructor = user
+	return course, nil
+}
+
+func scanLesson(row rowScanner) (*models.Lesson, error) {
+	lesson := &models.Lesson{}
+	var resources []byte
+
+	err := row.Scan(
+		&lesson.ID, &lesson.CourseID, &lesson.Title, &lesson.Description, &lesson.Content,
+		&lesson.VideoURL, &lesson.DurationMinutes, &lesson.SortOrder, &lesson.IsPreview,
+		&resources, &lesson.CreatedAt, &lesson.HasQuiz,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	lesson.Resources = resources
+	return lesson, nil
+}
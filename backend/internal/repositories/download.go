@@ -0,0 +1,85 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+)
+
+var ErrDownloadLimitReached = errors.New("download limit reached")
+
+type DownloadRepository struct {
+	db *sql.DB
+}
+
+func NewDownloadRepository(db *sql.DB) *DownloadRepository {
+	return &DownloadRepository{db: db}
+}
+
+func (r *DownloadRepository) IsConnected() bool {
+	return r.db != nil
+}
+
+// CountByBuyer returns how many times the user downloaded files of the
+// product.
+func (r *DownloadRepository) CountByBuyer(productID, userID string) (int, error) {
+	if !r.IsConnected() {
+		return 0, sql.ErrConnDone
+	}
+	if !isUUID(productID) || !isUUID(userID) {
+		return 0, nil
+	}
+
+	var count int
+	err := r.db.QueryRow(`
+		SELECT COUNT(*) FROM product_downloads
+		WHERE product_id = $1 AND user_id = $2`, productID, userID).Scan(&count)
+	return count, err
+}
+
+// Record counts one download of a product file by a buyer and adds it to
+// the product's download count. It returns ErrDownloadLimitReached, counting
+// nothing, once the buyer has used limit downloads.
+func (r *DownloadRepository) Record(productID, userID string, fileIndex, limit int) error {
+	if !r.IsConnected() {
+		return sql.ErrConnDone
+	}
+	if !isUUID(productID) || !isUUID(userID) {
+		return sql.ErrNoRows
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Serialise concurrent downloads by the same buyer so the limit holds
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock(hashtext($1 || ':' || $2))`, productID, userID); err != nil {
+		return err
+	}
+
+	var count int
+	err = tx.QueryRow(`
+		SELECT COUNT(*) FROM product_downloads
+		WHERE product_id = $1 AND user_id = $2`, productID, userID).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count >= limit {
+		return ErrDownloadLimitReached
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO product_downloads (product_id, user_id, file_index) VALUES ($1, $2, $3)`,
+		productID, userID, fileIndex)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`UPDATE products SET download_count = download_count + 1 WHERE id = $1`, productID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
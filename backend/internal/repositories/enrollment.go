@@ -0,0 +1,369 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"viport-backend/internal/models"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrAlreadyEnrolled = errors.New("already enrolled in this course")
+	ErrNotEnrolled     = errors.New("not enrolled in this course")
+)
+
+const enrollmentSelectColumns = `
+	e.id, e.user_id, e.course_id, e.enrollment_date, e.completion_date,
+	e.progress_percentage, e.last_accessed_lesson_id, e.last_accessed_at, e.certificate_url`
+
+// countedCompletion holds for completions lc that count towards progress:
+// the lesson has no quiz that must be passed first, or the student passed it.
+const countedCompletion = `NOT EXISTS (
+	SELECT 1 FROM quizzes q
+	WHERE q.lesson_id = lc.lesson_id AND q.required_to_complete
+		AND NOT EXISTS (
+			SELECT 1 FROM quiz_attempts a
+			WHERE a.quiz_id = q.id AND a.user_id = lc.user_id AND a.passed
+		)
+)`
+
+type EnrollmentRepository struct {
+	db *sql.DB
+}
+
+func NewEnrollmentRepository(db *sql.DB) *EnrollmentRepository {
+	return &EnrollmentRepository{db: db}
+}
+
+func (r *EnrollmentRepository) IsConnected() bool {
+	return r.db != nil
+}
+
+// Enroll enrolls the user in a course directly, as for free courses. Paid
+// courses are enrolled in when their purchase completes. It returns
+// ErrAlreadyEnrolled when the user is enrolled already.
+func (r *EnrollmentRepository) Enroll(userID, courseID string) (*models.Enrollment, error) {
+	if !r.IsConnected() {
+		return nil, sql.ErrConnDone
+	}
+	if !isUUID(courseID) {
+		return nil, sql.ErrNoRows
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	created, err := enroll(tx, userID, courseID)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		return nil, ErrAlreadyEnrolled
+	}
+
+	enrollment, err := scanEnrollment(tx.QueryRow(`
+		SELECT `+enrollmentSelectColumns+` FROM enrollments e
+		WHERE e.user_id = $1 AND e.course_id = $2`, userID, courseID))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return enrollment, nil
+}
+
+// Get returns the user's enrollment in a course, or ErrNotEnrolled.
+func (r *EnrollmentRepository) Get(userID, courseID string) (*models.Enrollment, error) {
+	if !r.IsConnected() {
+		return nil, sql.ErrConnDone
+	}
+	if !isUUID(userID) || !isUUID(courseID) {
+		return nil, ErrNotEnrolled
+	}
+
+	enrollment, err := scanEnrollment(r.db.QueryRow(`
+		SELECT `+enrollmentSelectColumns+` FROM enrollments e
+		WHERE e.user_id = $1 AND e.course_id = $2`, userID, courseID))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotEnrolled
+	}
+	return enrollment, err
+}
+
+// ProgressByCourse returns the user's progress in each of the given courses
+// they are enrolled in, keyed by course ID.
+func (r *EnrollmentRepository) ProgressByCourse(userID string, courseIDs []string) (map[string]int, error) {
+	progress := make(map[string]int)
+	if !r.IsConnected() {
+		return nil, sql.ErrConnDone
+	}
+	if !isUUID(userID) || len(courseIDs) == 0 {
+		return progress, nil
+	}
+
+	rows, err := r.db.Query(`
+		SELECT course_id, progress_percentage FROM enrollments
+		WHERE user_id = $1 AND course_id = ANY($2::uuid[])`,
+		userID, pq.Array(courseIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var courseID string
+		var percentage int
+		if err := rows.Scan(&courseID, &percentage); err != nil {
+			return nil, err
+		}
+		progress[courseID] = percentage
+	}
+	return progress, rows.Err()
+}
+
+// ListByUser returns a page of the user's enrollments, most recently
+// studied first, and the total count.
+func (r *EnrollmentRepository) ListByUser(userID string, limit, offset int) ([]*models.Enrollment, int, error) {
+	if !r.IsConnected() {
+		return nil, 0, sql.ErrConnDone
+	}
+
+	var total int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM enrollments WHERE user_id = $1`, userID).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.db.Query(`
+		SELECT `+enrollmentSelectColumns+` FROM enrollments e
+		WHERE e.user_id = $1
+		ORDER BY e.last_accessed_at DESC NULLS LAST, e.enrollment_date DESC, e.id
+		LIMIT $2 OFFSET $3`, userID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	enrollments := []*models.Enrollment{}
+	for rows.Next() {
+		enrollment, err := scanEnrollment(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		enrollments = append(enrollments, enrollment)
+	}
+	return enrollments, total, rows.Err()
+}
+
+// CompletedLessonIDs returns the IDs of the course lessons the user
+// completed, leaving out those still waiting on a required quiz.
+func (r *EnrollmentRepository) CompletedLessonIDs(userID, courseID string) (map[string]bool, error) {
+	completed := make(map[string]bool)
+	if !r.IsConnected() {
+		return nil, sql.ErrConnDone
+	}
+	if !isUUID(userID) || !isUUID(courseID) {
+		return completed, nil
+	}
+
+	rows, err := r.db.Query(`
+		SELECT lc.lesson_id FROM lesson_completions lc
+		WHERE lc.user_id = $1 AND lc.course_id = $2 AND `+countedCompletion, userID, courseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var lessonID string
+		if err := rows.Scan(&lessonID); err != nil {
+			return nil, err
+		}
+		completed[lessonID] = true
+	}
+	return completed, rows.Err()
+}
+
+// SetLessonCompleted marks a lesson of the course completed, or not, for an
+// enrolled user, recomputes their progress and records the lesson as where
+// they left off. It returns ErrNotEnrolled when the user is not enrolled,
+// sql.ErrNoRows when the lesson is not part of the course and
+// ErrQuizNotPassed when completing a lesson whose quiz must be passed first.
+func (r *EnrollmentRepository) SetLessonCompleted(userID, courseID, lessonID string, completed bool) (*models.Enrollment, error) {
+	if !r.IsConnected() {
+		return nil, sql.ErrConnDone
+	}
+	if !isUUID(courseID) || !isUUID(lessonID) {
+		return nil, sql.ErrNoRows
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var enrollmentID string
+	err = tx.QueryRow(`
+		SELECT id FROM enrollments WHERE user_id = $1 AND course_id = $2
+		FOR UPDATE`, userID, courseID).Scan(&enrollmentID)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotEnrolled
+	} else if err != nil {
+		return nil, err
+	}
+
+	var exists bool
+	err = tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM lessons WHERE id = $1 AND course_id = $2)`,
+		lessonID, courseID).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, sql.ErrNoRows
+	}
+
+	if completed {
+		var counts bool
+		err := tx.QueryRow(`
+			SELECT `+countedCompletion+`
+			FROM (SELECT $1::uuid AS user_id, $2::uuid AS lesson_id) lc`, userID, lessonID).Scan(&counts)
+		if err != nil {
+			return nil, err
+		}
+		if !counts {
+			return nil, ErrQuizNotPassed
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO lesson_completions (user_id, lesson_id, course_id) VALUES ($1, $2, $3)
+			ON CONFLICT (user_id, lesson_id) DO NOTHING`, userID, lessonID, courseID)
+	} else {
+		_, err = tx.Exec(`DELETE FROM lesson_completions WHERE user_id = $1 AND lesson_id = $2`, userID, lessonID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := recomputeProgress(tx, courseID, userID); err != nil {
+		return nil, err
+	}
+
+	enrollment, err := scanEnrollment(tx.QueryRow(`
+		UPDATE enrollments e SET last_accessed_lesson_id = $2, last_accessed_at = NOW()
+		WHERE e.id = $1
+		RETURNING `+enrollmentSelectColumns, enrollmentID, lessonID))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return enrollment, nil
+}
+
+// RecordAccess records the lesson as where an enrolled user left off in the
+// course. It does nothing for users who are not enrolled.
+func (r *EnrollmentRepository) RecordAccess(userID, courseID, lessonID string) error {
+	if !r.IsConnected() {
+		return sql.ErrConnDone
+	}
+	if !isUUID(userID) || !isUUID(courseID) || !isUUID(lessonID) {
+		return nil
+	}
+
+	_, err := r.db.Exec(`
+		UPDATE enrollments SET last_accessed_lesson_id = $3, last_accessed_at = NOW()
+		WHERE user_id = $1 AND course_id = $2`, userID, courseID, lessonID)
+	return err
+}
+
+// enroll enrolls the user in the course within tx and counts the new
+// enrollment, reporting whether one was created. Lessons completed during
+// an earlier enrollment count towards the new one.
+func enroll(tx *sql.Tx, userID, courseID string) (bool, error) {
+	result, err := tx.Exec(`
+		INSERT INTO enrollments (user_id, course_id) VALUES ($1, $2)
+		ON CONFLICT (user_id, course_id) DO NOTHING`, userID, courseID)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code.Name() == "foreign_key_violation" {
+			return false, sql.ErrNoRows
+		}
+		return false, err
+	}
+	if err := expectAffected(result); err == sql.ErrNoRows {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	_, err = tx.Exec(`UPDATE courses SET enrollment_count = enrollment_count + 1 WHERE id = $1`, courseID)
+	if err != nil {
+		return false, err
+	}
+	return true, recomputeProgress(tx, courseID, userID)
+}
+
+// unenroll removes the user's enrollment in the course within tx. Their
+// lesson completions are kept in case they enroll again.
+func unenroll(tx *sql.Tx, userID, courseID string) error {
+	result, err := tx.Exec(`DELETE FROM enrollments WHERE user_id = $1 AND course_id = $2`, userID, courseID)
+	if err != nil {
+		return err
+	}
+	if err := expectAffected(result); err == sql.ErrNoRows {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE courses SET enrollment_count = GREATEST(enrollment_count - 1, 0)
+		WHERE id = $1`, courseID)
+	return err
+}
+
+// recomputeProgress sets the progress of the user's enrollment in the
+// course, or of every enrollment in it when userID is empty, to the share of
+// lessons completed, counting lessons with a required quiz once it is
+// passed. Reaching 100% records the completion date, which is kept if
+// lessons are added later.
+func recomputeProgress(tx *sql.Tx, courseID, userID string) error {
+	_, err := tx.Exec(`
+		UPDATE enrollments e SET
+			progress_percentage = p.progress,
+			completion_date = CASE WHEN p.progress = 100
+				THEN COALESCE(e.completion_date, NOW()) ELSE e.completion_date END
+		FROM (
+			SELECT en.id, COALESCE(
+				(SELECT COUNT(*) FROM lesson_completions lc
+					WHERE lc.user_id = en.user_id AND lc.course_id = en.course_id
+						AND `+countedCompletion+`) * 100
+				/ NULLIF((SELECT COUNT(*) FROM lessons l WHERE l.course_id = en.course_id), 0),
+				0) AS progress
+			FROM enrollments en
+			WHERE en.course_id = $1 AND ($2::uuid IS NULL OR en.user_id = $2)
+		) p
+		WHERE e.id = p.id`, courseID, viewerArg(userID))
+	return err
+}
+
+func scanEnrollment(row rowScanner) (*models.Enrollment, error) {
+	var enrollment models.Enrollment
+	err := row.Scan(
+		&enrollment.ID, &enrollment.UserID, &enrollment.CourseID, &enrollment.EnrollmentDate,
+		&enrollment.CompletionDate, &enrollment.ProgressPercentage, &enrollment.LastAccessedLessonID,
+		&enrollment.LastAccessedAt, &enrollment.CertificateURL,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &enrollment, nil
+}
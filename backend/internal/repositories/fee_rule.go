@@ -0,0 +1,183 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+	"viport-backend/internal/models"
+
+	"github.com/lib/pq"
+)
+
+var ErrCategoryNotFound = errors.New("category not found")
+
+const feeRuleSelectColumns = `
+	f.id, f.name, f.description, f.category_id, f.license_type, f.verification_level,
+	f.percentage, f.fixed_amount, f.priority, f.starts_at, f.ends_at, f.is_active,
+	f.created_at, f.updated_at`
+
+// FeeCriteria describes a sale for fee rule matching.
+type FeeCriteria struct {
+	CategoryID  *string
+	LicenseType string
+	SellerID    string
+}
+
+type FeeRuleRepository struct {
+	db *sql.DB
+}
+
+func NewFeeRuleRepository(db *sql.DB) *FeeRuleRepository {
+	return &FeeRuleRepository{db: db}
+}
+
+func (r *FeeRuleRepository) IsConnected() bool {
+	return r.db != nil
+}
+
+// List returns every fee rule, active ones first, in matching order.
+func (r *FeeRuleRepository) List() ([]*models.FeeRule, error) {
+	if !r.IsConnected() {
+		return nil, sql.ErrConnDone
+	}
+
+	rows, err := r.db.Query(`
+		SELECT ` + feeRuleSelectColumns + `
+		FROM fee_rules f
+		ORDER BY f.is_active DESC, f.priority DESC, f.created_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := []*models.FeeRule{}
+	for rows.Next() {
+		rule, err := scanFeeRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+func (r *FeeRuleRepository) GetByID(id string) (*models.FeeRule, error) {
+	if !r.IsConnected() {
+		return nil, sql.ErrConnDone
+	}
+	if !isUUID(id) {
+		return nil, sql.ErrNoRows
+	}
+
+	return scanFeeRule(r.db.QueryRow(`SELECT `+feeRuleSelectColumns+` FROM fee_rules f WHERE f.id = $1`, id))
+}
+
+// Match returns the rule pricing a sale at the given time: the active rule
+// in its window with the highest priority, then the most criteria, then the
+// closest category, then the newest. Category rules also match sales in
+// their subcategories. It returns sql.ErrNoRows when no rule matches.
+func (r *FeeRuleRepository) Match(criteria FeeCriteria, at time.Time) (*models.FeeRule, error) {
+	if !r.IsConnected() {
+		return nil, sql.ErrConnDone
+	}
+
+	categoryID := criteria.CategoryID
+	if categoryID != nil && !isUUID(*categoryID) {
+		categoryID = nil
+	}
+	var sellerID *string
+	if isUUID(criteria.SellerID) {
+		sellerID = &criteria.SellerID
+	}
+
+	return scanFeeRule(r.db.QueryRow(`
+		WITH RECURSIVE ancestors AS (
+			SELECT id, parent_id, 0 AS depth FROM categories WHERE id = $1::uuid
+			UNION ALL
+			SELECT c.id, c.parent_id, a.depth + 1
+			FROM categories c
+			JOIN ancestors a ON c.id = a.parent_id
+			WHERE a.depth < 32
+		)
+		SELECT `+feeRuleSelectColumns+`
+		FROM fee_rules f
+		LEFT JOIN ancestors a ON a.id = f.category_id
+		WHERE f.is_active
+			AND (f.starts_at IS NULL OR f.starts_at <= $4)
+			AND (f.ends_at IS NULL OR f.ends_at > $4)
+			AND (f.category_id IS NULL OR a.id IS NOT NULL)
+			AND (f.license_type IS NULL OR f.license_type = $2)
+			AND (f.verification_level IS NULL OR f.verification_level =
+				(SELECT verification_level FROM users WHERE id = $3::uuid))
+		ORDER BY f.priority DESC,
+			(f.category_id IS NOT NULL)::int + (f.license_type IS NOT NULL)::int +
+			(f.verification_level IS NOT NULL)::int +
+			(f.starts_at IS NOT NULL OR f.ends_at IS NOT NULL)::int DESC,
+			a.depth ASC NULLS LAST,
+			f.created_at DESC
+		LIMIT 1`, categoryID, criteria.LicenseType, sellerID, at))
+}
+
+// Create inserts a fee rule. It returns ErrCategoryNotFound when CategoryID
+// names no category.
+func (r *FeeRuleRepository) Create(rule *models.FeeRule) error {
+	if !r.IsConnected() {
+		return sql.ErrConnDone
+	}
+
+	err := r.db.QueryRow(`
+		INSERT INTO fee_rules (
+			name, description, category_id, license_type, verification_level,
+			percentage, fixed_amount, priority, starts_at, ends_at, is_active
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id, created_at, updated_at`,
+		rule.Name, rule.Description, rule.CategoryID, rule.LicenseType, rule.VerificationLevel,
+		rule.Percentage, rule.FixedAmount, rule.Priority, rule.StartsAt, rule.EndsAt, rule.IsActive,
+	).Scan(&rule.ID, &rule.CreatedAt, &rule.UpdatedAt)
+	return feeRuleWriteError(err)
+}
+
+// Update replaces every editable field of a fee rule. Transactions keep
+// pointing at the rule, so rules are deactivated rather than deleted.
+func (r *FeeRuleRepository) Update(rule *models.FeeRule) error {
+	if !r.IsConnected() {
+		return sql.ErrConnDone
+	}
+	if !isUUID(rule.ID) {
+		return sql.ErrNoRows
+	}
+
+	err := r.db.QueryRow(`
+		UPDATE fee_rules SET
+			name = $2, description = $3, category_id = $4, license_type = $5,
+			verification_level = $6, percentage = $7, fixed_amount = $8, priority = $9,
+			starts_at = $10, ends_at = $11, is_active = $12, updated_at = NOW()
+		WHERE id = $1
+		RETURNING created_at, updated_at`,
+		rule.ID, rule.Name, rule.Description, rule.CategoryID, rule.LicenseType,
+		rule.VerificationLevel, rule.Percentage, rule.FixedAmount, rule.Priority,
+		rule.StartsAt, rule.EndsAt, rule.IsActive,
+	).Scan(&rule.CreatedAt, &rule.UpdatedAt)
+	return feeRuleWriteError(err)
+}
+
+func feeRuleWriteError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code.Name() == "foreign_key_violation" {
+		return ErrCategoryNotFound
+	}
+	return err
+}
+
+func scanFeeRule(row rowScanner) (*models.FeeRule, error) {
+	rule := &models.FeeRule{}
+	err := row.Scan(
+		&rule.ID, &rule.Name, &rule.Description, &rule.CategoryID, &rule.LicenseType,
+		&rule.VerificationLevel, &rule.Percentage, &rule.FixedAmount, &rule.Priority,
+		&rule.StartsAt, &rule.EndsAt, &rule.IsActive, &rule.CreatedAt, &rule.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
@@ -0,0 +1,207 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"viport-backend/internal/models"
+	"viport-backend/pkg/pagination"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrAlreadyFollowing = errors.New("already following this user")
+	ErrNotFollowing     = errors.New("not following this user")
+)
+
+type FollowRepository struct {
+	db *sql.DB
+}
+
+func NewFollowRepository(db *sql.DB) *FollowRepository {
+	return &FollowRepository{db: db}
+}
+
+func (r *FollowRepository) IsConnected() bool {
+	return r.db != nil
+}
+
+// Follow makes followerID follow followingID. It returns sql.ErrNoRows when
+// the followed user does not exist and ErrAlreadyFollowing on duplicates.
+func (r *FollowRepository) Follow(followerID, followingID string) error {
+	if !r.IsConnected() {
+		return sql.ErrConnDone
+	}
+	if !isUUID(followingID) {
+		return sql.ErrNoRows
+	}
+
+	result, err := r.db.Exec(`
+		INSERT INTO follows (follower_id, following_id)
+		SELECT $1, id FROM users WHERE id = $2
+		ON CONFLICT (follower_id, following_id) DO NOTHING`, followerID, followingID)
+	if err != nil {
+		return err
+	}
+
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if inserted > 0 {
+		return nil
+	}
+
+	// Nothing inserted: either the user is missing or the follow already exists
+	var exists bool
+	if err := r.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM users WHERE id = $1)`, followingID).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return sql.ErrNoRows
+	}
+	return ErrAlreadyFollowing
+}
+
+func (r *FollowRepository) Unfollow(followerID, followingID string) error {
+	if !r.IsConnected() {
+		return sql.ErrConnDone
+	}
+	if !isUUID(followingID) {
+		return ErrNotFollowing
+	}
+
+	result, err := r.db.Exec(`DELETE FROM follows WHERE follower_id = $1 AND following_id = $2`, followerID, followingID)
+	if err != nil {
+		return err
+	}
+
+	if err := expectAffected(result); err == sql.ErrNoRows {
+		return ErrNotFollowing
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetFollowers lists the users following userID, most recent first, along
+// with the total number of followers. Like the other lists, it fetches
+// limit+1 rows so callers can tell whether another page follows; a cursor,
+// keyed on FollowedAt, replaces offset.
+func (r *FollowRepository) GetFollowers(userID string, limit, offset int, cursor *pagination.Cursor) ([]*models.User, int, error) {
+	return r.listUsers(userID, "following_id", "follower_id", limit, offset, cursor)
+}
+
+// GetFollowing lists the users userID follows, most recent first, along with
+// the total number of followed accounts, paged like GetFollowers.
+func (r *FollowRepository) GetFollowing(userID string, limit, offset int, cursor *pagination.Cursor) ([]*models.User, int, error) {
+	return r.listUsers(userID, "follower_id", "following_id", limit, offset, cursor)
+}
+
+func (r *FollowRepository) listUsers(userID, matchColumn, userColumn string, limit, offset int, cursor *pagination.Cursor) ([]*models.User, int, error) {
+	if !r.IsConnected() {
+		return nil, 0, sql.ErrConnDone
+	}
+	if !isUUID(userID) {
+		return []*models.User{}, 0, nil
+	}
+
+	var total int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM follows WHERE `+matchColumn+` = $1`, userID).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	args := []interface{}{userID}
+	addArg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	where := "WHERE f." + matchColumn + " = $1"
+	keyset, orderBy := keysetClause("f.created_at", "timestamptz", "u.id", true, cursor, addArg)
+	if keyset != "" {
+		where += " AND " + keyset
+		offset = 0
+	}
+
+	rows, err := r.db.Query(`
+		SELECT u.id, u.username, u.display_name, u.bio, u.avatar_url,
+			   u.is_verified, u.is_creator, u.account_type, u.created_at, f.created_at
+		FROM follows f
+		JOIN users u ON u.id = f.`+userColumn+`
+		`+where+`
+		ORDER BY `+orderBy+`
+		LIMIT `+addArg(limit+1)+` OFFSET `+addArg(offset), args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	users := []*models.User{}
+	for rows.Next() {
+		user := &models.User{}
+		err := rows.Scan(
+			&user.ID, &user.Username, &user.DisplayName, &user.Bio, &user.AvatarURL,
+			&user.IsVerified, &user.IsCreator, &user.AccountType, &user.CreatedAt,
+			&user.FollowedAt,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	reverseRows(users, cursor)
+	return users, total, nil
+}
+
+// IsFollowing reports whether followerID follows followingID.
+func (r *FollowRepository) IsFollowing(followerID, followingID string) (bool, error) {
+	if !r.IsConnected() {
+		return false, sql.ErrConnDone
+	}
+	if followerID == "" || !isUUID(followingID) {
+		return false, nil
+	}
+
+	var following bool
+	err := r.db.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM follows WHERE follower_id = $1 AND following_id = $2)`,
+		followerID, followingID).Scan(&following)
+	return following, err
+}
+
+// FollowingIDs returns the subset of userIDs that followerID follows.
+func (r *FollowRepository) FollowingIDs(followerID string, userIDs []string) (map[string]bool, error) {
+	following := make(map[string]bool)
+	if !r.IsConnected() {
+		return following, sql.ErrConnDone
+	}
+	if followerID == "" || len(userIDs) == 0 {
+		return following, nil
+	}
+
+	rows, err := r.db.Query(`
+		SELECT following_id FROM follows
+		WHERE follower_id = $1 AND following_id = ANY($2::uuid[])`,
+		followerID, pq.Array(userIDs))
+	if err != nil {
+		return following, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return following, err
+		}
+		following[id] = true
+	}
+
+	return following, rows.Err()
+}
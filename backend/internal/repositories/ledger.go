@@ -0,0 +1,357 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+	"viport-backend/internal/models"
+)
+
+var ErrUnbalancedJournal = errors.New("ledger journal does not balance")
+
+// Ledger accounts, see migrations/006_ledger.sql.
+const (
+	AccountCash           = "cash"
+	AccountPlatformFees   = "platform_fees"
+	AccountCreatorPayable = "creator_payable"
+)
+
+// Ledger journal kinds.
+const (
+	JournalOpening = "opening"
+	JournalSale    = "sale"
+	JournalRefund  = "refund"
+	JournalPayout  = "payout"
+)
+
+const ledgerEntrySelectColumns = `
+	e.id, e.journal_id, j.kind, e.account, e.user_id, j.transaction_id, j.refund_id,
+	j.payout_id, e.amount, j.currency, j.description, e.created_at`
+
+// ledgerLine is one entry of a journal being posted.
+type ledgerLine struct {
+	account string
+	userID  *string
+	amount  int64
+}
+
+// ledgerJournal is a set of entries posted together. Its lines must sum to
+// zero.
+type ledgerJournal struct {
+	kind          string
+	transactionID *string
+	refundID      *string
+	payoutID      *string
+	currency      string
+	description   string
+	lines         []ledgerLine
+}
+
+type LedgerRepository struct {
+	db *sql.DB
+}
+
+func NewLedgerRepository(db *sql.DB) *LedgerRepository {
+	return &LedgerRepository{db: db}
+}
+
+func (r *LedgerRepository) IsConnected() bool {
+	return r.db != nil
+}
+
+// postJournal writes a journal and its non-zero lines inside tx. It returns
+// ErrUnbalancedJournal, writing nothing, when the lines do not sum to zero.
+func postJournal(tx *sql.Tx, journal ledgerJournal) error {
+	var sum int64
+	lines := journal.lines[:0:0]
+	for _, line := range journal.lines {
+		sum += line.amount
+		if line.amount != 0 {
+			lines = append(lines, line)
+		}
+	}
+	if sum != 0 {
+		return ErrUnbalancedJournal
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+
+	var journalID string
+	err := tx.QueryRow(`
+		INSERT INTO ledger_journals (kind, transaction_id, refund_id, payout_id, currency, description)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id`,
+		journal.kind, journal.transactionID, journal.refundID, journal.payoutID, journal.currency, journal.description,
+	).Scan(&journalID)
+	if err != nil {
+		return err
+	}
+
+	for _, line := range lines {
+		_, err := tx.Exec(`
+			INSERT INTO ledger_entries (journal_id, account, user_id, amount)
+			VALUES ($1, $2, $3, $4)`, journalID, line.account, line.userID, line.amount)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// postSale recognises a completed transaction: the buyer's charge lands in
+// cash and is split between the platform fee and what the seller is owed.
+func postSale(tx *sql.Tx, transaction *models.Transaction) error {
+	amount := toCents(transaction.Amount)
+	fee := toCents(transaction.FeeAmount)
+	net := toCents(transaction.NetAmount)
+
+	return postJournal(tx, ledgerJournal{
+		kind:          JournalSale,
+		transactionID: &transaction.ID,
+		currency:      transaction.Currency,
+		description:   "Sale of " + transaction.ItemType + " " + transaction.ItemID,
+		lines: []ledgerLine{
+			{account: AccountCash, amount: amount},
+			{account: AccountPlatformFees, amount: -fee},
+			{account: AccountCreatorPayable, userID: &transaction.SellerID, amount: -net},
+		},
+	})
+}
+
+// postRefund reverses part of a sale: the refunded amount leaves cash and is
+// taken back from the platform fee and the seller in the given shares.
+func postRefund(tx *sql.Tx, transaction *models.Transaction, refundID *string, feeShare, netShare int64) error {
+	return postJournal(tx, ledgerJournal{
+		kind:          JournalRefund,
+		transactionID: &transaction.ID,
+		refundID:      refundID,
+		currency:      transaction.Currency,
+		description:   "Refund of transaction " + transaction.ID,
+		lines: []ledgerLine{
+			{account: AccountCash, amount: -(feeShare + netShare)},
+			{account: AccountPlatformFees, amount: feeShare},
+			{account: AccountCreatorPayable, userID: &transaction.SellerID, amount: netShare},
+		},
+	})
+}
+
+// postRemainingRefund reverses whatever the ledger still holds of a
+// transaction's fee and seller share, for refunds that return the rest of a
+// transaction at once.
+func postRemainingRefund(tx *sql.Tx, transaction *models.Transaction) error {
+	var fee, net int64
+	err := tx.QueryRow(`
+		SELECT
+			COALESCE(-SUM(e.amount) FILTER (WHERE e.account = 'platform_fees'), 0),
+			COALESCE(-SUM(e.amount) FILTER (WHERE e.account = 'creator_payable'), 0)
+		FROM ledger_entries e
+		JOIN ledger_journals j ON j.id = e.journal_id
+		WHERE j.transaction_id = $1`, transaction.ID).Scan(&fee, &net)
+	if err != nil {
+		return err
+	}
+	return postRefund(tx, transaction, nil, fee, net)
+}
+
+// postPayout records money paid out to a creator: it leaves cash and
+// settles that much of what the creator is owed.
+func postPayout(tx *sql.Tx, payout *models.Payout) error {
+	return postJournal(tx, ledgerJournal{
+		kind:        JournalPayout,
+		payoutID:    &payout.ID,
+		currency:    payout.Currency,
+		description: "Payout " + payout.ID,
+		lines: []ledgerLine{
+			{account: AccountCreatorPayable, userID: &payout.UserID, amount: payout.Amount},
+			{account: AccountCash, amount: -payout.Amount},
+		},
+	})
+}
+
+// Balances sums a creator's payable account per currency.
+func (r *LedgerRepository) Balances(userID string) ([]*models.LedgerBalance, error) {
+	if !r.IsConnected() {
+		return nil, sql.ErrConnDone
+	}
+	balances := []*models.LedgerBalance{}
+	if !isUUID(userID) {
+		return balances, nil
+	}
+
+	rows, err := r.db.Query(`
+		SELECT j.currency,
+			COALESCE(-SUM(e.amount) FILTER (WHERE j.kind IN ('opening', 'sale')), 0),
+			COALESCE(SUM(e.amount) FILTER (WHERE j.kind = 'refund'), 0),
+			COALESCE(SUM(e.amount) FILTER (WHERE j.kind = 'payout'), 0),
+			-SUM(e.amount)
+		FROM ledger_entries e
+		JOIN ledger_journals j ON j.id = e.journal_id
+		WHERE e.account = 'creator_payable' AND e.user_id = $1
+		GROUP BY j.currency
+		ORDER BY j.currency`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		balance := &models.LedgerBalance{}
+		if err := rows.Scan(&balance.Currency, &balance.Earned, &balance.Refunded, &balance.PaidOut, &balance.Available); err != nil {
+			return nil, err
+		}
+		balances = append(balances, balance)
+	}
+	return balances, rows.Err()
+}
+
+// ListEntries returns the entries of a creator's payable account, newest
+// first, with the total count.
+func (r *LedgerRepository) ListEntries(userID string, limit, offset int) ([]*models.LedgerEntry, int, error) {
+	if !r.IsConnected() {
+		return nil, 0, sql.ErrConnDone
+	}
+	entries := []*models.LedgerEntry{}
+	if !isUUID(userID) {
+		return entries, 0, nil
+	}
+
+	var total int
+	err := r.db.QueryRow(`
+		SELECT COUNT(*) FROM ledger_entries
+		WHERE account = 'creator_payable' AND user_id = $1`, userID).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.db.Query(`
+		SELECT `+ledgerEntrySelectColumns+`
+		FROM ledger_entries e
+		JOIN ledger_journals j ON j.id = e.journal_id
+		WHERE e.account = 'creator_payable' AND e.user_id = $1
+		ORDER BY e.id DESC
+		LIMIT $2 OFFSET $3`, userID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		entry := &models.LedgerEntry{}
+		err := rows.Scan(
+			&entry.ID, &entry.JournalID, &entry.Kind, &entry.Account, &entry.UserID,
+			&entry.TransactionID, &entry.RefundID, &entry.PayoutID, &entry.Amount, &entry.Currency,
+			&entry.Description, &entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, total, rows.Err()
+}
+
+// Verify checks the ledger invariants: every journal sums to zero, and for
+// every settled transaction the ledger holds exactly the cash still kept
+// (amount less refunds), the platform fee and the seller's net amount
+// recorded on the transaction.
+func (r *LedgerRepository) Verify() (*models.LedgerReport, error) {
+	if !r.IsConnected() {
+		return nil, sql.ErrConnDone
+	}
+
+	report := &models.LedgerReport{
+		Imbalances: []*models.LedgerImbalance{},
+		CheckedAt:  time.Now(),
+	}
+
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM ledger_journals`).Scan(&report.Journals); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(`
+		SELECT j.id, j.transaction_id, COALESCE(SUM(e.amount), 0)
+		FROM ledger_journals j
+		LEFT JOIN ledger_entries e ON e.journal_id = j.id
+		GROUP BY j.id
+		HAVING COALESCE(SUM(e.amount), 0) <> 0 OR COUNT(e.id) = 0`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		imbalance := &models.LedgerImbalance{Check: "journal_balance"}
+		if err := rows.Scan(&imbalance.JournalID, &imbalance.TransactionID, &imbalance.Actual); err != nil {
+			return nil, err
+		}
+		report.Imbalances = append(report.Imbalances, imbalance)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = r.db.Query(`
+		WITH ledger AS (
+			SELECT j.transaction_id,
+				COALESCE(SUM(e.amount) FILTER (WHERE e.account = 'cash'), 0) AS cash,
+				COALESCE(-SUM(e.amount) FILTER (WHERE e.account = 'platform_fees'), 0) AS fee,
+				COALESCE(-SUM(e.amount) FILTER (WHERE e.account = 'creator_payable'), 0) AS net
+			FROM ledger_entries e
+			JOIN ledger_journals j ON j.id = e.journal_id
+			WHERE j.transaction_id IS NOT NULL
+			GROUP BY j.transaction_id
+		), expected AS (
+			SELECT id,
+				ROUND((amount - refunded_amount) * 100)::BIGINT AS cash,
+				ROUND(COALESCE(fee_amount, 0) * 100)::BIGINT AS fee,
+				ROUND(net_amount * 100)::BIGINT AS net
+			FROM transactions
+			WHERE payment_status IN ('completed', 'refunded')
+		)
+		SELECT COALESCE(x.id, l.transaction_id),
+			COALESCE(x.cash, 0), COALESCE(l.cash, 0),
+			COALESCE(x.fee, 0), COALESCE(l.fee, 0),
+			COALESCE(x.net, 0), COALESCE(l.net, 0)
+		FROM expected x
+		FULL JOIN ledger l ON l.transaction_id = x.id
+		WHERE COALESCE(x.cash, 0) <> COALESCE(l.cash, 0)
+			OR COALESCE(x.fee, 0) <> COALESCE(l.fee, 0)
+			OR COALESCE(x.net, 0) <> COALESCE(l.net, 0)`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var transactionID string
+		var cash, fee, net [2]int64
+		if err := rows.Scan(&transactionID, &cash[0], &cash[1], &fee[0], &fee[1], &net[0], &net[1]); err != nil {
+			return nil, err
+		}
+		for _, check := range []struct {
+			name   string
+			values [2]int64
+		}{
+			{"transaction_cash", cash},
+			{"transaction_fee", fee},
+			{"transaction_payable", net},
+		} {
+			if check.values[0] != check.values[1] {
+				id := transactionID
+				report.Imbalances = append(report.Imbalances, &models.LedgerImbalance{
+					Check:         check.name,
+					TransactionID: &id,
+					Expected:      check.values[0],
+					Actual:        check.values[1],
+				})
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	report.Balanced = len(report.Imbalances) == 0
+	return report, nil
+}
@@ -0,0 +1,162 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// likeableTable is a table holding a denormalized like_count. Visible, when
+// set, is the SQL predicate on the table aliased as t deciding whether the
+// user bound to $2 may see a row.
+type likeableTable struct {
+	name    string
+	visible string
+}
+
+// Likeable tables, keyed by likeable_type. Products and courses are only
+// visible once active or published, except to their own creator; handlers
+// check post visibility, for posts and comments on them, before liking.
+var likeableTables = map[string]likeableTable{
+	"post":    {"posts", ""},
+	"product": {"products", "(t.status = 'active' OR t.user_id = $2)"},
+	"course":  {"courses", "(t.status = 'published' OR t.instructor_id = $2)"},
+	"comment": {"comments", ""},
+}
+
+// IsLikeableType reports whether likes are supported for the given type.
+func IsLikeableType(likeableType string) bool {
+	_, ok := likeableTables[likeableType]
+	return ok
+}
+
+type LikeRepository struct {
+	db *sql.DB
+}
+
+func NewLikeRepository(db *sql.DB) *LikeRepository {
+	return &LikeRepository{db: db}
+}
+
+func (r *LikeRepository) IsConnected() bool {
+	return r.db != nil
+}
+
+// Like records a like and bumps the target's like_count in one transaction.
+// Liking something twice is a no-op. It returns the resulting like count, or
+// sql.ErrNoRows when the target does not exist or the user may not see it.
+func (r *LikeRepository) Like(userID, likeableType, likeableID string) (int, error) {
+	return r.toggle(userID, likeableType, likeableID, true)
+}
+
+// Unlike removes a like and decrements the target's like_count in one
+// transaction. Unliking something that was never liked is a no-op.
+func (r *LikeRepository) Unlike(userID, likeableType, likeableID string) (int, error) {
+	return r.toggle(userID, likeableType, likeableID, false)
+}
+
+func (r *LikeRepository) toggle(userID, likeableType, likeableID string, like bool) (int, error) {
+	if !r.IsConnected() {
+		return 0, sql.ErrConnDone
+	}
+	table, ok := likeableTables[likeableType]
+	if !ok {
+		return 0, fmt.Errorf("unsupported likeable type %q", likeableType)
+	}
+	if !isUUID(likeableID) {
+		return 0, sql.ErrNoRows
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// Lock the target row so concurrent likes serialize on the counter
+	query := `SELECT t.like_count FROM ` + table.name + ` t WHERE t.id = $1`
+	args := []interface{}{likeableID}
+	if table.visible != "" {
+		query += ` AND ` + table.visible
+		args = append(args, viewerArg(userID))
+	}
+
+	var likeCount int
+	err = tx.QueryRow(query+` FOR UPDATE`, args...).Scan(&likeCount)
+	if err != nil {
+		return 0, err
+	}
+
+	var result sql.Result
+	if like {
+		result, err = tx.Exec(`
+			INSERT INTO likes (user_id, likeable_type, likeable_id)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (user_id, likeable_type, likeable_id) DO NOTHING`,
+			userID, likeableType, likeableID)
+	} else {
+		result, err = tx.Exec(`
+			DELETE FROM likes
+			WHERE user_id = $1 AND likeable_type = $2 AND likeable_id = $3`,
+			userID, likeableType, likeableID)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	changed, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	if changed > 0 {
+		delta := 1
+		if !like {
+			delta = -1
+		}
+		err = tx.QueryRow(`
+			UPDATE `+table.name+` SET like_count = GREATEST(like_count + $2, 0)
+			WHERE id = $1
+			RETURNING like_count`, likeableID, delta).Scan(&likeCount)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return likeCount, nil
+}
+
+// LikedIDs returns the subset of likeableIDs the user has liked.
+func (r *LikeRepository) LikedIDs(userID, likeableType string, likeableIDs []string) (map[string]bool, error) {
+	liked := make(map[string]bool)
+	if !r.IsConnected() {
+		return liked, sql.ErrConnDone
+	}
+	if userID == "" || len(likeableIDs) == 0 {
+		return liked, nil
+	}
+
+	rows, err := r.db.Query(`
+		SELECT likeable_id FROM likes
+		WHERE user_id = $1 AND likeable_type = $2 AND likeable_id = ANY($3::uuid[])`,
+		userID, likeableType, pq.Array(likeableIDs))
+	if err != nil {
+		return liked, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return liked, err
+		}
+		liked[id] = true
+	}
+
+	return liked, rows.Err()
+}
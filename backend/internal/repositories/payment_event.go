@@ -0,0 +1,46 @@
+package repositories
+
+import (
+	"database/sql"
+	"viport-backend/internal/models"
+)
+
+// PaymentEventRepository records webhook events handled outside
+// TransactionRepository.ApplyEvent, whose handlers are idempotent on their
+// own and only need redeliveries recognised.
+type PaymentEventRepository struct {
+	db *sql.DB
+}
+
+func NewPaymentEventRepository(db *sql.DB) *PaymentEventRepository {
+	return &PaymentEventRepository{db: db}
+}
+
+func (r *PaymentEventRepository) IsConnected() bool {
+	return r.db != nil
+}
+
+// Seen reports whether the event was recorded before.
+func (r *PaymentEventRepository) Seen(eventID string) (bool, error) {
+	if !r.IsConnected() {
+		return false, sql.ErrConnDone
+	}
+
+	var seen bool
+	err := r.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM payment_events WHERE id = $1)`, eventID).Scan(&seen)
+	return seen, err
+}
+
+// Record stores the event with its outcome. Recording an event twice is a
+// no-op.
+func (r *PaymentEventRepository) Record(event models.PaymentEvent, transactionID *string, outcome string) error {
+	if !r.IsConnected() {
+		return sql.ErrConnDone
+	}
+
+	_, err := r.db.Exec(`
+		INSERT INTO payment_events (id, type, transaction_id, outcome)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (id) DO NOTHING`, event.ID, event.Type, transactionID, outcome)
+	return err
+}
@@ -0,0 +1,591 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+	"time"
+	"viport-backend/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// payoutBatchLock is the advisory lock key held while a payout batch is
+// created, so concurrent runs cannot pay the same balance twice.
+const payoutBatchLock = 7301
+
+const payoutMethodSelectColumns = `
+	m.id, m.user_id, m.kind, m.label, m.destination, m.destination_hint, m.is_default, m.created_at`
+
+const payoutSelectColumns = `
+	p.id, p.user_id, p.method_id, p.batch_id, p.amount, p.currency, p.status,
+	p.provider_reference, p.failure_reason, p.hold_reason, p.created_at, p.updated_at, p.paid_at`
+
+type PayoutRepository struct {
+	db *sql.DB
+}
+
+func NewPayoutRepository(db *sql.DB) *PayoutRepository {
+	return &PayoutRepository{db: db}
+}
+
+func (r *PayoutRepository) IsConnected() bool {
+	return r.db != nil
+}
+
+// CreateMethod adds a payout method. The creator's first method, or one
+// created with IsDefault, becomes the default.
+func (r *PayoutRepository) CreateMethod(method *models.PayoutMethod) error {
+	if !r.IsConnected() {
+		return sql.ErrConnDone
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if !method.IsDefault {
+		err := tx.QueryRow(`
+			SELECT NOT EXISTS (
+				SELECT 1 FROM payout_methods WHERE user_id = $1 AND removed_at IS NULL
+			)`, method.UserID).Scan(&method.IsDefault)
+		if err != nil {
+			return err
+		}
+	} else if err := clearDefaultMethod(tx, method.UserID); err != nil {
+		return err
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO payout_methods (user_id, kind, label, destination, destination_hint, is_default)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at`,
+		method.UserID, method.Kind, method.Label, method.Destination, method.DestinationHint, method.IsDefault,
+	).Scan(&method.ID, &method.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func clearDefaultMethod(tx *sql.Tx, userID string) error {
+	_, err := tx.Exec(`
+		UPDATE payout_methods SET is_default = FALSE
+		WHERE user_id = $1 AND is_default AND removed_at IS NULL`, userID)
+	return err
+}
+
+// ListMethods returns the creator's payout methods, default first.
+func (r *PayoutRepository) ListMethods(userID string) ([]*models.PayoutMethod, error) {
+	if !r.IsConnected() {
+		return nil, sql.ErrConnDone
+	}
+	methods := []*models.PayoutMethod{}
+	if !isUUID(userID) {
+		return methods, nil
+	}
+
+	rows, err := r.db.Query(`
+		SELECT `+payoutMethodSelectColumns+`
+		FROM payout_methods m
+		WHERE m.user_id = $1 AND m.removed_at IS NULL
+		ORDER BY m.is_default DESC, m.created_at`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		method, err := scanPayoutMethod(rows)
+		if err != nil {
+			return nil, err
+		}
+		methods = append(methods, method)
+	}
+	return methods, rows.Err()
+}
+
+// SetDefaultMethod makes one of the creator's methods the default. It
+// returns sql.ErrNoRows for methods the creator does not have.
+func (r *PayoutRepository) SetDefaultMethod(userID, id string) error {
+	if !r.IsConnected() {
+		return sql.ErrConnDone
+	}
+	if !isUUID(userID) || !isUUID(id) {
+		return sql.ErrNoRows
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := clearDefaultMethod(tx, userID); err != nil {
+		return err
+	}
+	result, err := tx.Exec(`
+		UPDATE payout_methods SET is_default = TRUE
+		WHERE id = $1 AND user_id = $2 AND removed_at IS NULL`, id, userID)
+	if err != nil {
+		return err
+	}
+	if err := expectAffected(result); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RemoveMethod retires one of the creator's methods. Payouts already using
+// it keep it; new payouts wait until the creator has a default again.
+func (r *PayoutRepository) RemoveMethod(userID, id string) error {
+	if !r.IsConnected() {
+		return sql.ErrConnDone
+	}
+	if !isUUID(userID) || !isUUID(id) {
+		return sql.ErrNoRows
+	}
+
+	result, err := r.db.Exec(`
+		UPDATE payout_methods SET removed_at = NOW(), is_default = FALSE
+		WHERE id = $1 AND user_id = $2 AND removed_at IS NULL`, id, userID)
+	if err != nil {
+		return err
+	}
+	return expectAffected(result)
+}
+
+// GetSettings returns the creator's payout settings, or the defaults with
+// defaultMinimum when they never changed them.
+func (r *PayoutRepository) GetSettings(userID string, defaultMinimum int64) (*models.PayoutSettings, error) {
+	if !r.IsConnected() {
+		return nil, sql.ErrConnDone
+	}
+
+	settings := &models.PayoutSettings{UserID: userID, MinimumAmount: defaultMinimum}
+	if !isUUID(userID) {
+		return settings, nil
+	}
+
+	err := r.db.QueryRow(`
+		SELECT minimum_amount, on_hold, hold_reason, updated_at
+		FROM payout_settings WHERE user_id = $1`, userID,
+	).Scan(&settings.MinimumAmount, &settings.OnHold, &settings.HoldReason, &settings.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return settings, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// SetMinimum stores the creator's payout threshold.
+func (r *PayoutRepository) SetMinimum(userID string, minimum int64) error {
+	if !r.IsConnected() {
+		return sql.ErrConnDone
+	}
+
+	_, err := r.db.Exec(`
+		INSERT INTO payout_settings (user_id, minimum_amount) VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET minimum_amount = EXCLUDED.minimum_amount, updated_at = NOW()`,
+		userID, minimum)
+	return err
+}
+
+// SetHold stops or resumes payouts to a creator. defaultMinimum is stored
+// for creators without settings yet.
+func (r *PayoutRepository) SetHold(userID string, onHold bool, reason *string, defaultMinimum int64) error {
+	if !r.IsConnected() {
+		return sql.ErrConnDone
+	}
+	if !isUUID(userID) {
+		return sql.ErrNoRows
+	}
+
+	_, err := r.db.Exec(`
+		INSERT INTO payout_settings (user_id, minimum_amount, on_hold, hold_reason) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id) DO UPDATE SET
+			on_hold = EXCLUDED.on_hold, hold_reason = EXCLUDED.hold_reason, updated_at = NOW()`,
+		userID, defaultMinimum, onHold, reason)
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code.Name() == "foreign_key_violation" {
+		return sql.ErrNoRows
+	}
+	return err
+}
+
+// CreateBatch opens a payout batch with one pending payout per creator and
+// currency whose unpaid balance, less payouts already under way, reaches
+// their minimum. Creators on hold or without a default method are skipped.
+func (r *PayoutRepository) CreateBatch(defaultMinimum int64) (*models.PayoutBatch, error) {
+	if !r.IsConnected() {
+		return nil, sql.ErrConnDone
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock($1)`, payoutBatchLock); err != nil {
+		return nil, err
+	}
+
+	batch := &models.PayoutBatch{}
+	err = tx.QueryRow(`INSERT INTO payout_batches DEFAULT VALUES RETURNING id, created_at`).
+		Scan(&batch.ID, &batch.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.QueryRow(`
+		WITH balances AS (
+			SELECT e.user_id, j.currency, -SUM(e.amount) AS balance
+			FROM ledger_entries e
+			JOIN ledger_journals j ON j.id = e.journal_id
+			WHERE e.account = 'creator_payable'
+			GROUP BY e.user_id, j.currency
+		), in_flight AS (
+			SELECT user_id, currency, SUM(amount) AS amount
+			FROM payouts
+			WHERE status IN ('pending', 'held', 'processing')
+			GROUP BY user_id, currency
+		), created AS (
+			INSERT INTO payouts (user_id, method_id, batch_id, amount, currency)
+			SELECT b.user_id, m.id, $1, b.balance - COALESCE(f.amount, 0), b.currency
+			FROM balances b
+			JOIN payout_methods m ON m.user_id = b.user_id AND m.is_default AND m.removed_at IS NULL
+			LEFT JOIN payout_settings s ON s.user_id = b.user_id
+			LEFT JOIN in_flight f ON f.user_id = b.user_id AND f.currency = b.currency
+			WHERE NOT COALESCE(s.on_hold, FALSE)
+				AND b.balance - COALESCE(f.amount, 0) >= COALESCE(s.minimum_amount, $2)
+			RETURNING amount
+		)
+		SELECT COUNT(*), COALESCE(SUM(amount), 0) FROM created`, batch.ID, defaultMinimum,
+	).Scan(&batch.PayoutCount, &batch.TotalAmount)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE payout_batches SET payout_count = $2, total_amount = $3 WHERE id = $1`,
+		batch.ID, batch.PayoutCount, batch.TotalAmount)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
+
+// CompleteBatch records how many payouts of a batch were paid and failed.
+func (r *PayoutRepository) CompleteBatch(batch *models.PayoutBatch) error {
+	if !r.IsConnected() {
+		return sql.ErrConnDone
+	}
+
+	now := time.Now()
+	_, err := r.db.Exec(`
+		UPDATE payout_batches SET paid_count = $2, failed_count = $3, completed_at = $4
+		WHERE id = $1`, batch.ID, batch.PaidCount, batch.FailedCount, now)
+	if err != nil {
+		return err
+	}
+	batch.CompletedAt = &now
+	return nil
+}
+
+// ListPending returns up to limit pending payouts with their method, oldest
+// first, for sending to the provider.
+func (r *PayoutRepository) ListPending(limit int) ([]*models.Payout, error) {
+	if !r.IsConnected() {
+		return nil, sql.ErrConnDone
+	}
+
+	rows, err := r.db.Query(`
+		SELECT `+payoutSelectColumns+`, `+payoutMethodSelectColumns+`
+		FROM payouts p
+		JOIN payout_methods m ON m.id = p.method_id
+		WHERE p.status = 'pending'
+		ORDER BY p.created_at
+		LIMIT $1`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	payouts := []*models.Payout{}
+	for rows.Next() {
+		payout, err := scanPayoutWithMethod(rows)
+		if err != nil {
+			return nil, err
+		}
+		payouts = append(payouts, payout)
+	}
+	return payouts, rows.Err()
+}
+
+// ListStaleProcessing returns up to limit payouts, with their method, that
+// have been processing without an update for longer than olderThan, oldest
+// first, so their outcome can be looked up with the provider.
+func (r *PayoutRepository) ListStaleProcessing(olderThan time.Duration, limit int) ([]*models.Payout, error) {
+	if !r.IsConnected() {
+		return nil, sql.ErrConnDone
+	}
+
+	rows, err := r.db.Query(`
+		SELECT `+payoutSelectColumns+`, `+payoutMethodSelectColumns+`
+		FROM payouts p
+		JOIN payout_methods m ON m.id = p.method_id
+		WHERE p.status = 'processing'
+		  AND p.updated_at < NOW() - $1 * INTERVAL '1 second'
+		ORDER BY p.updated_at
+		LIMIT $2`, int64(olderThan/time.Second), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	payouts := []*models.Payout{}
+	for rows.Next() {
+		payout, err := scanPayoutWithMethod(rows)
+		if err != nil {
+			return nil, err
+		}
+		payouts = append(payouts, payout)
+	}
+	return payouts, rows.Err()
+}
+
+// List returns a page of payouts, newest first, with the total count. An
+// empty userID lists every creator's payouts and an empty status every
+// status.
+func (r *PayoutRepository) List(userID, status string, limit, offset int) ([]*models.Payout, int, error) {
+	if !r.IsConnected() {
+		return nil, 0, sql.ErrConnDone
+	}
+	payouts := []*models.Payout{}
+	if userID != "" && !isUUID(userID) {
+		return payouts, 0, nil
+	}
+
+	where := ` WHERE TRUE`
+	args := []interface{}{}
+	if userID != "" {
+		args = append(args, userID)
+		where += ` AND p.user_id = $` + strconv.Itoa(len(args))
+	}
+	if status != "" {
+		args = append(args, status)
+		where += ` AND p.status = $` + strconv.Itoa(len(args))
+	}
+
+	var total int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM payouts p`+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	args = append(args, limit, offset)
+	rows, err := r.db.Query(`
+		SELECT `+payoutSelectColumns+`, `+payoutMethodSelectColumns+`
+		FROM payouts p
+		JOIN payout_methods m ON m.id = p.method_id`+where+`
+		ORDER BY p.created_at DESC, p.id DESC
+		LIMIT $`+strconv.Itoa(len(args)-1)+` OFFSET $`+strconv.Itoa(len(args)), args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		payout, err := scanPayoutWithMethod(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		payouts = append(payouts, payout)
+	}
+	return payouts, total, rows.Err()
+}
+
+func (r *PayoutRepository) GetByID(id string) (*models.Payout, error) {
+	if !r.IsConnected() {
+		return nil, sql.ErrConnDone
+	}
+	if !isUUID(id) {
+		return nil, sql.ErrNoRows
+	}
+
+	return scanPayoutWithMethod(r.db.QueryRow(`
+		SELECT `+payoutSelectColumns+`, `+payoutMethodSelectColumns+`
+		FROM payouts p
+		JOIN payout_methods m ON m.id = p.method_id
+		WHERE p.id = $1`, id))
+}
+
+// UpdateStatus moves a payout from one status to another, recording the
+// provider reference and failure reason when given. The hold reason is
+// replaced, so only holds keep one. It returns
+// ErrStatusConflict when the payout is no longer in status from.
+func (r *PayoutRepository) UpdateStatus(id, from, to string, reference, failureReason, holdReason *string) error {
+	if !r.IsConnected() {
+		return sql.ErrConnDone
+	}
+	if !isUUID(id) {
+		return sql.ErrNoRows
+	}
+
+	result, err := r.db.Exec(`
+		UPDATE payouts SET
+			status = $3,
+			provider_reference = COALESCE($4, provider_reference),
+			failure_reason = COALESCE($5, failure_reason),
+			hold_reason = $6,
+			updated_at = NOW()
+		WHERE id = $1 AND status = $2`, id, from, to, reference, failureReason, holdReason)
+	if err != nil {
+		return err
+	}
+	if err := expectAffected(result); err == sql.ErrNoRows {
+		return ErrStatusConflict
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+// MarkPaid settles a processing payout and posts it to the ledger in the
+// same database transaction.
+func (r *PayoutRepository) MarkPaid(id string, reference *string) (*models.Payout, error) {
+	if !r.IsConnected() {
+		return nil, sql.ErrConnDone
+	}
+	if !isUUID(id) {
+		return nil, sql.ErrNoRows
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	payout, err := scanPayout(tx.QueryRow(`
+		UPDATE payouts p SET
+			status = 'paid',
+			provider_reference = COALESCE($2, provider_reference),
+			paid_at = NOW(),
+			updated_at = NOW()
+		WHERE p.id = $1 AND p.status = 'processing'
+		RETURNING `+payoutSelectColumns, id, reference))
+	if err == sql.ErrNoRows {
+		return nil, ErrStatusConflict
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := postPayout(tx, payout); err != nil {
+		return nil, err
+	}
+	if err := notifyPayout(tx, payout, "payout_paid", "Payout sent"); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return payout, nil
+}
+
+// MarkFailed fails a processing payout. Its amount returns to the
+// creator's balance for the next batch.
+func (r *PayoutRepository) MarkFailed(id string, reference *string, reason string) error {
+	if !r.IsConnected() {
+		return sql.ErrConnDone
+	}
+	if !isUUID(id) {
+		return sql.ErrNoRows
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	payout, err := scanPayout(tx.QueryRow(`
+		UPDATE payouts p SET
+			status = 'failed',
+			provider_reference = COALESCE($2, provider_reference),
+			failure_reason = $3,
+			updated_at = NOW()
+		WHERE p.id = $1 AND p.status = 'processing'
+		RETURNING `+payoutSelectColumns, id, reference, reason))
+	if err == sql.ErrNoRows {
+		return ErrStatusConflict
+	} else if err != nil {
+		return err
+	}
+
+	if err := notifyPayout(tx, payout, "payout_failed", "Payout failed"); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func notifyPayout(tx *sql.Tx, payout *models.Payout, notificationType, title string) error {
+	_, err := tx.Exec(`
+		INSERT INTO notifications (user_id, type, title, data)
+		VALUES ($1, $2, $3, json_build_object('payoutId', $4::text, 'amount', $5::bigint, 'currency', $6::text))`,
+		payout.UserID, notificationType, title, payout.ID, payout.Amount, payout.Currency)
+	return err
+}
+
+func scanPayout(row rowScanner) (*models.Payout, error) {
+	payout := &models.Payout{}
+	err := row.Scan(
+		&payout.ID, &payout.UserID, &payout.MethodID, &payout.BatchID, &payout.Amount,
+		&payout.Currency, &payout.Status, &payout.ProviderReference, &payout.FailureReason,
+		&payout.HoldReason, &payout.CreatedAt, &payout.UpdatedAt, &payout.PaidAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return payout, nil
+}
+
+func scanPayoutWithMethod(row rowScanner) (*models.Payout, error) {
+	payout := &models.Payout{Method: &models.PayoutMethod{}}
+	method := payout.Method
+	err := row.Scan(
+		&payout.ID, &payout.UserID, &payout.MethodID, &payout.BatchID, &payout.Amount,
+		&payout.Currency, &payout.Status, &payout.ProviderReference, &payout.FailureReason,
+		&payout.HoldReason, &payout.CreatedAt, &payout.UpdatedAt, &payout.PaidAt,
+		&method.ID, &method.UserID, &method.Kind, &method.Label, &method.Destination,
+		&method.DestinationHint, &method.IsDefault, &method.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return payout, nil
+}
+
+func scanPayoutMethod(row rowScanner) (*models.PayoutMethod, error) {
+	method := &models.PayoutMethod{}
+	err := row.Scan(
+		&method.ID, &method.UserID, &method.Kind, &method.Label, &method.Destination,
+		&method.DestinationHint, &method.IsDefault, &method.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return method, nil
+}
@@ -0,0 +1,480 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+	"viport-backend/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type PostRepository struct {
+	db *sql.DB
+}
+
+func NewPostRepository(db *sql.DB) *PostRepository {
+	return &PostRepository{db: db}
+}
+
+func (r *PostRepository) IsConnected() bool {
+	return r.db != nil
+}
+
+// Columns selected for every post read, including the author summary.
+const postSelectColumns = `
+	p.id, p.user_id, p.title, p.content, p.media_urls, p.media_type, p.visibility,
+	p.is_featured, p.like_count, p.comment_count, p.share_count, p.view_count,
+	p.created_at, p.updated_at,
+	u.id, u.username, u.display_name, u.avatar_url, u.is_verified, u.is_creator`
+
+// Sortable columns for PostFilter.SortBy and the SQL type their cursor values
+// are cast to. Anything else falls back to created_at.
+var postSortColumns = map[string]sortColumn{
+	"created_at": {"p.created_at", "timestamptz"},
+	"like_count": {"p.like_count", "integer"},
+	"view_count": {"p.view_count", "integer"},
+}
+
+// Create inserts the post and links it to post.Tags, creating missing tags,
+// in one transaction. post.Tags is replaced by the stored tags.
+func (r *PostRepository) Create(post *models.Post) error {
+	if !r.IsConnected() {
+		return sql.ErrConnDone
+	}
+
+	post.ID = uuid.New().String()
+	post.CreatedAt = time.Now()
+	post.UpdatedAt = post.CreatedAt
+	if len(post.MediaURLs) == 0 {
+		post.MediaURLs = []byte("[]")
+	}
+
+	query := `
+		INSERT INTO posts (
+			id, user_id, title, content, media_urls, media_type, visibility,
+			is_featured, created_at, updated_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+		)`
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(
+		query,
+		post.ID, post.UserID, post.Title, post.Content, []byte(post.MediaURLs),
+		post.MediaType, post.Visibility, post.IsFeatured,
+		post.CreatedAt, post.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	if len(post.Tags) > 0 {
+		if post.Tags, err = setTags(tx, taggableTables["post"], post.ID, post.Tags); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *PostRepository) GetByID(id string) (*models.Post, error) {
+	if !r.IsConnected() {
+		return nil, sql.ErrConnDone
+	}
+	if !isUUID(id) {
+		return nil, sql.ErrNoRows
+	}
+
+	query := `SELECT ` + postSelectColumns + `
+		FROM posts p
+		JOIN users u ON u.id = p.user_id
+		WHERE p.id = $1`
+
+	post, err := scanPost(r.db.QueryRow(query, id))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.attachTags([]*models.Post{post}); err != nil {
+		return nil, err
+	}
+
+	return post, nil
+}
+
+// GetByIDs loads several posts at once, keyed by ID. Posts that do not exist
+// are left out.
+func (r *PostRepository) GetByIDs(ids []string) (map[string]*models.Post, error) {
+	if !r.IsConnected() {
+		return nil, sql.ErrConnDone
+	}
+
+	found := make(map[string]*models.Post)
+	if len(ids) == 0 {
+		return found, nil
+	}
+
+	rows, err := r.db.Query(`SELECT `+postSelectColumns+`
+		FROM posts p
+		JOIN users u ON u.id = p.user_id
+		WHERE p.id = ANY($1::uuid[])`, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var posts []*models.Post
+	for rows.Next() {
+		post, err := scanPost(rows)
+		if err != nil {
+			return nil, err
+		}
+		posts = append(posts, post)
+		found[post.ID] = post
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := r.attachTags(posts); err != nil {
+		return nil, err
+	}
+	return found, nil
+}
+
+// List returns the page of posts matching filter together with the total
+// number of matching rows. When filter.Cursor is set it pages by keyset from
+// the cursor instead of by Offset. Up to Limit+1 posts are returned so the
+// caller can tell whether another page follows.
+func (r *PostRepository) List(filter models.PostFilter) ([]*models.Post, int, error) {
+	if !r.IsConnected() {
+		return nil, 0, sql.ErrConnDone
+	}
+
+	if filter.Cursor != nil {
+		filter.SortBy, filter.SortOrder = filter.Cursor.SortBy, filter.Cursor.SortOrder
+	}
+
+	where, args := buildPostWhere(filter)
+
+	var total int
+	countQuery := `SELECT COUNT(*) FROM posts p` + where
+	if err := r.db.QueryRow(countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	sort, ok := postSortColumns[filter.SortBy]
+	if !ok {
+		sort = postSortColumns["created_at"]
+	}
+
+	addArg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	keyset, orderBy := keysetClause(sort.column, sort.cast, "p.id",
+		!strings.EqualFold(filter.SortOrder, "asc"), filter.Cursor, addArg)
+	if keyset != "" {
+		if where == "" {
+			where = "\n\t\tWHERE " + keyset
+		} else {
+			where += " AND " + keyset
+		}
+	}
+
+	offset := filter.Offset
+	if filter.Cursor != nil {
+		offset = 0
+	}
+
+	query := fmt.Sprintf(`SELECT %s
+		FROM posts p
+		JOIN users u ON u.id = p.user_id%s
+		ORDER BY %s
+		LIMIT %s OFFSET %s`,
+		postSelectColumns, where, orderBy, addArg(filter.Limit+1), addArg(offset))
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	posts := []*models.Post{}
+	for rows.Next() {
+		post, err := scanPost(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		posts = append(posts, post)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	reverseRows(posts, filter.Cursor)
+
+	if err := r.attachTags(posts); err != nil {
+		return nil, 0, err
+	}
+
+	return posts, total, nil
+}
+
+// GetVisibleByID is GetByID restricted to posts the viewer may see. Hidden
+// posts are reported as sql.ErrNoRows so their existence does not leak.
+func (r *PostRepository) GetVisibleByID(id, viewerID string) (*models.Post, error) {
+	if err := r.CanView(id, viewerID); err != nil {
+		return nil, err
+	}
+	return r.GetByID(id)
+}
+
+// CanView returns sql.ErrNoRows unless the post exists and is visible to the
+// viewer.
+func (r *PostRepository) CanView(id, viewerID string) error {
+	if !r.IsConnected() {
+		return sql.ErrConnDone
+	}
+	if !isUUID(id) {
+		return sql.ErrNoRows
+	}
+
+	var visible bool
+	query := `SELECT EXISTS (SELECT 1 FROM posts p WHERE p.id = $1 AND ` + postVisibleCondition("$2") + `)`
+	if err := r.db.QueryRow(query, id, viewerArg(viewerID)).Scan(&visible); err != nil {
+		return err
+	}
+	if !visible {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetOwnerID returns the ID of the user that authored the post.
+func (r *PostRepository) GetOwnerID(id string) (string, error) {
+	if !r.IsConnected() {
+		return "", sql.ErrConnDone
+	}
+	if !isUUID(id) {
+		return "", sql.ErrNoRows
+	}
+
+	var ownerID string
+	err := r.db.QueryRow(`SELECT user_id FROM posts WHERE id = $1`, id).Scan(&ownerID)
+	return ownerID, err
+}
+
+// Update saves the post and, unless tags is nil, replaces its tags in the
+// same transaction, storing them in post.Tags. An empty tags clears them.
+func (r *PostRepository) Update(post *models.Post, tags []models.Tag) error {
+	if !r.IsConnected() {
+		return sql.ErrConnDone
+	}
+
+	post.UpdatedAt = time.Now()
+
+	query := `
+		UPDATE posts SET
+			title = $2, content = $3, media_urls = $4, visibility = $5,
+			is_featured = $6, updated_at = $7
+		WHERE id = $1`
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(
+		query,
+		post.ID, post.Title, post.Content, []byte(post.MediaURLs),
+		post.Visibility, post.IsFeatured, post.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	if err := expectAffected(result); err != nil {
+		return err
+	}
+
+	if tags != nil {
+		if post.Tags, err = setTags(tx, taggableTables["post"], post.ID, tags); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *PostRepository) Delete(id string) error {
+	if !r.IsConnected() {
+		return sql.ErrConnDone
+	}
+	if !isUUID(id) {
+		return sql.ErrNoRows
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	tagIDs, err := detachTags(tx, "post", id)
+	if err != nil {
+		return err
+	}
+	if err := deleteInteractions(tx, "post", id); err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(`DELETE FROM posts WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if err := expectAffected(result); err != nil {
+		return err
+	}
+
+	if err := recountTagUsage(tx, tagIDs); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func buildPostWhere(filter models.PostFilter) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	addArg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	viewer := addArg(viewerArg(filter.ViewerID))
+	conditions = append(conditions, postVisibleCondition(viewer))
+	if filter.FollowingOnly {
+		conditions = append(conditions,
+			"(p.user_id = "+viewer+" OR p.user_id IN (SELECT following_id FROM follows WHERE follower_id = "+viewer+"))")
+	}
+
+	if filter.UserID != nil {
+		if !isUUID(*filter.UserID) {
+			conditions = append(conditions, "FALSE")
+		} else {
+			conditions = append(conditions, "p.user_id = "+addArg(*filter.UserID))
+		}
+	}
+	if filter.MediaType != nil {
+		conditions = append(conditions, "p.media_type = "+addArg(*filter.MediaType))
+	}
+	if filter.Visibility != nil {
+		conditions = append(conditions, "p.visibility = "+addArg(*filter.Visibility))
+	}
+	if filter.IsFeatured != nil {
+		conditions = append(conditions, "p.is_featured = "+addArg(*filter.IsFeatured))
+	}
+	if len(filter.TagIDs) > 0 {
+		tagIDs := make([]string, 0, len(filter.TagIDs))
+		for _, id := range filter.TagIDs {
+			if isUUID(id) {
+				tagIDs = append(tagIDs, id)
+			}
+		}
+		conditions = append(conditions,
+			"p.id IN (SELECT post_id FROM post_tags WHERE tag_id = ANY("+addArg(pq.Array(tagIDs))+"::uuid[]))")
+	}
+	if filter.Search != nil && *filter.Search != "" {
+		pattern := addArg("%" + escapeLike(*filter.Search) + "%")
+		conditions = append(conditions, "(p.title ILIKE "+pattern+" OR p.content ILIKE "+pattern+")")
+	}
+
+	return "\n\t\tWHERE " + strings.Join(conditions, " AND "), args
+}
+
+// postVisibleCondition is the SQL predicate deciding whether the viewer bound
+// to the given placeholder may see post p: public posts are visible to
+// everyone, "followers" posts to the author's followers, and private posts
+// only to the author.
+func postVisibleCondition(viewer string) string {
+	return `(p.visibility = 'public' OR p.user_id = ` + viewer + ` OR (p.visibility = 'followers' AND EXISTS (
+		SELECT 1 FROM follows f WHERE f.follower_id = ` + viewer + ` AND f.following_id = p.user_id)))`
+}
+
+// viewerArg binds anonymous viewers as NULL so they match no user or follow.
+func viewerArg(viewerID string) interface{} {
+	if !isUUID(viewerID) {
+		return nil
+	}
+	return viewerID
+}
+
+// attachTags loads the tags for all posts in a single query.
+func (r *PostRepository) attachTags(posts []*models.Post) error {
+	if len(posts) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(posts))
+	byID := make(map[string]*models.Post, len(posts))
+	for i, post := range posts {
+		ids[i] = post.ID
+		byID[post.ID] = post
+	}
+
+	rows, err := r.db.Query(`
+		SELECT pt.post_id, t.id, t.name, t.slug, t.usage_count, t.created_at
+		FROM post_tags pt
+		JOIN tags t ON t.id = pt.tag_id
+		WHERE pt.post_id = ANY($1::uuid[])
+		ORDER BY t.name`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var postID string
+		var tag models.Tag
+		if err := rows.Scan(&postID, &tag.ID, &tag.Name, &tag.Slug, &tag.UsageCount, &tag.CreatedAt); err != nil {
+			return err
+		}
+		if post, ok := byID[postID]; ok {
+			post.Tags = append(post.Tags, tag)
+		}
+	}
+
+	return rows.Err()
+}
+
+func scanPost(row rowScanner) (*models.Post, error) {
+	post := &models.Post{}
+	user := &models.User{}
+	var mediaURLs []byte
+
+	err := row.Scan(
+		&post.ID, &post.UserID, &post.Title, &post.Content, &mediaURLs,
+		&post.MediaType, &post.Visibility, &post.IsFeatured,
+		&post.LikeCount, &post.CommentCount, &post.ShareCount, &post.ViewCount,
+		&post.CreatedAt, &post.UpdatedAt,
+		&user.ID, &user.Username, &user.DisplayName, &user.AvatarURL,
+		&user.IsVerified, &user.IsCreator,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	post.MediaURLs = mediaURLs
+	post.User = user
+	return post, nil
+}
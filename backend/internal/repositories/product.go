@@ -0,0 +1,576 @@
+package repositories
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"viport-backend/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// ErrProductHasSales is returned when deleting a product buyers have paid
+// for; their downloads depend on it, so it can only be unpublished.
+var ErrProductHasSales = errors.New("product has completed sales")
+
+type ProductRepository struct {
+	db *sql.DB
+}
+
+func NewProductRepository(db *sql.DB) *ProductRepository {
+	return &ProductRepository{db: db}
+}
+
+func (r *ProductRepository) IsConnected() bool {
+	return r.db != nil
+}
+
+// Columns selected for every product read, including the seller summary.
+// Download files are deliberately left out of catalog reads.
+const productSelectColumns = `
+	p.id, p.user_id, p.category_id, p.title, p.description, p.short_description,
+	p.thumbnail_url, p.preview_images, p.demo_url, p.price, p.original_price,
+	p.is_free, p.license_type, p.file_size, p.file_format, p.compatibility,
+	p.requirements, p.status, p.download_count, p.download_limit, p.rating_average,
+	p.rating_count, p.like_count, p.created_at, p.updated_at,
+	u.id, u.username, u.display_name, u.avatar_url, u.is_verified, u.is_creator`
+
+// Sortable columns for ProductFilter.SortBy and the SQL type their cursor
+// values are cast to. Anything else falls back to created_at.
+var productSortColumns = map[string]sortColumn{
+	"created_at":     {"p.created_at", "timestamptz"},
+	"price":          {"p.price", "numeric"},
+	"rating_average": {"p.rating_average", "numeric"},
+	"download_count": {"p.download_count", "integer"},
+}
+
+// Create inserts the product and links it to product.Tags, creating missing
+// tags, in one transaction. product.Tags is replaced by the stored tags.
+func (r *ProductRepository) Create(product *models.Product) error {
+	if !r.IsConnected() {
+		return sql.ErrConnDone
+	}
+
+	product.ID = uuid.New().String()
+	product.CreatedAt = time.Now()
+	product.UpdatedAt = product.CreatedAt
+	if len(product.PreviewImages) == 0 {
+		product.PreviewImages = []byte("[]")
+	}
+	if len(product.FileURLs) == 0 {
+		product.FileURLs = []byte("[]")
+	}
+
+	query := `
+		INSERT INTO products (
+			id, user_id, category_id, title, description, short_description,
+			thumbnail_url, preview_images, file_urls, demo_url, price, original_price,
+			is_free, license_type, file_size, file_format, compatibility, requirements,
+			status, download_limit, created_at, updated_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16,
+			$17, $18, $19, $20, $21, $22
+		)`
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(
+		query,
+		product.ID, product.UserID, product.CategoryID, product.Title, product.Description,
+		product.ShortDescription, product.ThumbnailURL, []byte(product.PreviewImages),
+		[]byte(product.FileURLs), product.DemoURL, product.Price, product.OriginalPrice,
+		product.IsFree, product.LicenseType, product.FileSize, product.FileFormat,
+		product.Compatibility, product.Requirements, product.Status,
+		product.DownloadLimit, product.CreatedAt, product.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	if len(product.Tags) > 0 {
+		if product.Tags, err = setTags(tx, taggableTables["product"], product.ID, product.Tags); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *ProductRepository) GetByID(id string) (*models.Product, error) {
+	if !r.IsConnected() {
+		return nil, sql.ErrConnDone
+	}
+	if !isUUID(id) {
+		return nil, sql.ErrNoRows
+	}
+
+	query := `SELECT ` + productSelectColumns + `
+		FROM products p
+		JOIN users u ON u.id = p.user_id
+		WHERE p.id = $1`
+
+	product, err := scanProduct(r.db.QueryRow(query, id))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.attachRelations([]*models.Product{product}); err != nil {
+		return nil, err
+	}
+
+	return product, nil
+}
+
+// GetByIDs loads several products at once, keyed by ID. Products that do
+// not exist are left out.
+func (r *ProductRepository) GetByIDs(ids []string) (map[string]*models.Product, error) {
+	if !r.IsConnected() {
+		return nil, sql.ErrConnDone
+	}
+
+	found := make(map[string]*models.Product)
+	if len(ids) == 0 {
+		return found, nil
+	}
+
+	rows, err := r.db.Query(`SELECT `+productSelectColumns+`
+		FROM products p
+		JOIN users u ON u.id = p.user_id
+		WHERE p.id = ANY($1::uuid[])`, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var products []*models.Product
+	for rows.Next() {
+		product, err := scanProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, product)
+		found[product.ID] = product
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := r.attachRelations(products); err != nil {
+		return nil, err
+	}
+	return found, nil
+}
+
+// GetVisibleByID is GetByID restricted to products the viewer may see.
+// Unpublished products are reported as sql.ErrNoRows to everyone but their
+// seller.
+func (r *ProductRepository) GetVisibleByID(id, viewerID string) (*models.Product, error) {
+	product, err := r.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if product.Status != "active" && product.UserID != viewerID {
+		return nil, sql.ErrNoRows
+	}
+	return product, nil
+}
+
+// List returns the page of products matching filter together with the total
+// number of matching rows. When filter.Cursor is set it pages by keyset from
+// the cursor instead of by Offset. Up to Limit+1 products are returned so the
+// caller can tell whether another page follows.
+func (r *ProductRepository) List(filter models.ProductFilter) ([]*models.Product, int, error) {
+	if !r.IsConnected() {
+		return nil, 0, sql.ErrConnDone
+	}
+
+	if filter.Cursor != nil {
+		filter.SortBy, filter.SortOrder = filter.Cursor.SortBy, filter.Cursor.SortOrder
+	}
+
+	where, args := buildProductWhere(filter)
+
+	var total int
+	countQuery := `SELECT COUNT(*) FROM products p` + where
+	if err := r.db.QueryRow(countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	sort, ok := productSortColumns[filter.SortBy]
+	if !ok {
+		sort = productSortColumns["created_at"]
+	}
+
+	addArg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	keyset, orderBy := keysetClause(sort.column, sort.cast, "p.id",
+		!strings.EqualFold(filter.SortOrder, "asc"), filter.Cursor, addArg)
+	if keyset != "" {
+		where += " AND " + keyset
+	}
+
+	offset := filter.Offset
+	if filter.Cursor != nil {
+		offset = 0
+	}
+
+	query := fmt.Sprintf(`SELECT %s
+		FROM products p
+		JOIN users u ON u.id = p.user_id%s
+		ORDER BY %s
+		LIMIT %s OFFSET %s`,
+		productSelectColumns, where, orderBy, addArg(filter.Limit+1), addArg(offset))
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	products := []*models.Product{}
+	for rows.Next() {
+		product, err := scanProduct(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		products = append(products, product)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	reverseRows(products, filter.Cursor)
+
+	if err := r.attachRelations(products); err != nil {
+		return nil, 0, err
+	}
+
+	return products, total, nil
+}
+
+// GetOwnerID returns the ID of the creator selling the product.
+func (r *ProductRepository) GetOwnerID(id string) (string, error) {
+	if !r.IsConnected() {
+		return "", sql.ErrConnDone
+	}
+	if !isUUID(id) {
+		return "", sql.ErrNoRows
+	}
+
+	var ownerID string
+	err := r.db.QueryRow(`SELECT user_id FROM products WHERE id = $1`, id).Scan(&ownerID)
+	return ownerID, err
+}
+
+// GetFiles returns the downloadable files of a product.
+func (r *ProductRepository) GetFiles(id string) ([]models.ProductFile, error) {
+	if !r.IsConnected() {
+		return nil, sql.ErrConnDone
+	}
+	if !isUUID(id) {
+		return nil, sql.ErrNoRows
+	}
+
+	var raw []byte
+	if err := r.db.QueryRow(`SELECT file_urls FROM products WHERE id = $1`, id).Scan(&raw); err != nil {
+		return nil, err
+	}
+
+	files := []models.ProductFile{}
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &files); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// Update saves the editable product fields. FileURLs is only written when
+// set, since catalog reads never load it. Users who saved the product are
+// notified when the update drops its price below the original price.
+// Update saves the product and, unless tags is nil, replaces its tags in the
+// same transaction, storing them in product.Tags. An empty tags clears them.
+func (r *ProductRepository) Update(product *models.Product, tags []models.Tag) error {
+	if !r.IsConnected() {
+		return sql.ErrConnDone
+	}
+
+	product.UpdatedAt = time.Now()
+	if len(product.PreviewImages) == 0 {
+		product.PreviewImages = []byte("[]")
+	}
+
+	var fileURLs interface{}
+	if len(product.FileURLs) > 0 {
+		fileURLs = []byte(product.FileURLs)
+	}
+
+	query := `
+		UPDATE products SET
+			category_id = $2, title = $3, description = $4, short_description = $5,
+			thumbnail_url = $6, preview_images = $7, file_urls = COALESCE($8, file_urls),
+			demo_url = $9, price = $10, original_price = $11, is_free = $12,
+			license_type = $13, file_size = $14, file_format = $15, compatibility = $16,
+			requirements = $17, status = $18, download_limit = $19, updated_at = $20
+		WHERE id = $1`
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(
+		query,
+		product.ID, product.CategoryID, product.Title, product.Description,
+		product.ShortDescription, product.ThumbnailURL, []byte(product.PreviewImages),
+		fileURLs, product.DemoURL, product.Price, product.OriginalPrice, product.IsFree,
+		product.LicenseType, product.FileSize, product.FileFormat, product.Compatibility,
+		product.Requirements, product.Status, product.DownloadLimit, product.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	if err := expectAffected(result); err != nil {
+		return err
+	}
+
+	if tags != nil {
+		if product.Tags, err = setTags(tx, taggableTables["product"], product.ID, tags); err != nil {
+			return err
+		}
+	}
+
+	if err := notifyPriceDrop(tx, product); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Delete removes a product that has never been sold, together with its
+// likes, comments, saves and reviews. Sold products return ErrProductHasSales.
+func (r *ProductRepository) Delete(id string) error {
+	if !r.IsConnected() {
+		return sql.ErrConnDone
+	}
+	if !isUUID(id) {
+		return sql.ErrNoRows
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Lock the product so a purchase completing concurrently is either seen
+	// here or finds the product gone
+	var locked string
+	if err := tx.QueryRow(`SELECT id FROM products WHERE id = $1 FOR UPDATE`, id).Scan(&locked); err != nil {
+		return err
+	}
+
+	var sold bool
+	err = tx.QueryRow(`
+		SELECT EXISTS(
+			SELECT 1 FROM transactions
+			WHERE item_type = 'product' AND item_id = $1 AND payment_status = 'completed'
+		)`, id).Scan(&sold)
+	if err != nil {
+		return err
+	}
+	if sold {
+		return ErrProductHasSales
+	}
+
+	tagIDs, err := detachTags(tx, "product", id)
+	if err != nil {
+		return err
+	}
+
+	// Polymorphic rows have no foreign key to cascade through
+	if err := deleteInteractions(tx, "product", id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM saved_items WHERE saveable_type = 'product' AND saveable_id = $1`, id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM reviews WHERE reviewable_type = 'product' AND reviewable_id = $1`, id); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM products WHERE id = $1`, id); err != nil {
+		return err
+	}
+
+	if err := recountTagUsage(tx, tagIDs); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func buildProductWhere(filter models.ProductFilter) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	addArg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	// Only published products are listed, apart from the viewer's own
+	conditions = append(conditions, "(p.status = 'active' OR p.user_id = "+addArg(viewerArg(filter.ViewerID))+")")
+
+	if filter.UserID != nil {
+		if !isUUID(*filter.UserID) {
+			conditions = append(conditions, "FALSE")
+		} else {
+			conditions = append(conditions, "p.user_id = "+addArg(*filter.UserID))
+		}
+	}
+	if filter.CategoryID != nil {
+		if !isUUID(*filter.CategoryID) {
+			conditions = append(conditions, "FALSE")
+		} else {
+			conditions = append(conditions, "p.category_id = "+addArg(*filter.CategoryID))
+		}
+	}
+	if filter.Status != nil {
+		conditions = append(conditions, "p.status = "+addArg(*filter.Status))
+	}
+	if filter.IsFree != nil {
+		conditions = append(conditions, "p.is_free = "+addArg(*filter.IsFree))
+	}
+	if filter.LicenseType != nil {
+		conditions = append(conditions, "p.license_type = "+addArg(*filter.LicenseType))
+	}
+	if filter.MinPrice != nil {
+		conditions = append(conditions, "p.price >= "+addArg(*filter.MinPrice))
+	}
+	if filter.MaxPrice != nil {
+		conditions = append(conditions, "p.price <= "+addArg(*filter.MaxPrice))
+	}
+	if len(filter.TagIDs) > 0 {
+		tagIDs := make([]string, 0, len(filter.TagIDs))
+		for _, id := range filter.TagIDs {
+			if isUUID(id) {
+				tagIDs = append(tagIDs, id)
+			}
+		}
+		conditions = append(conditions,
+			"p.id IN (SELECT product_id FROM product_tags WHERE tag_id = ANY("+addArg(pq.Array(tagIDs))+"::uuid[]))")
+	}
+	if filter.Search != nil && *filter.Search != "" {
+		pattern := addArg("%" + escapeLike(*filter.Search) + "%")
+		conditions = append(conditions,
+			"(p.title ILIKE "+pattern+" OR p.short_description ILIKE "+pattern+" OR p.description ILIKE "+pattern+")")
+	}
+
+	return "\n\t\tWHERE " + strings.Join(conditions, " AND "), args
+}
+
+// attachRelations loads the categories and tags for all products, one query
+// each regardless of the number of products.
+func (r *ProductRepository) attachRelations(products []*models.Product) error {
+	if err := r.attachCategories(products); err != nil {
+		return err
+	}
+	return r.attachTags(products)
+}
+
+func (r *ProductRepository) attachCategories(products []*models.Product) error {
+	var ids []string
+	seen := make(map[string]bool)
+	for _, product := range products {
+		if product.CategoryID != nil && !seen[*product.CategoryID] {
+			seen[*product.CategoryID] = true
+			ids = append(ids, *product.CategoryID)
+		}
+	}
+
+	categories, err := categoriesByID(r.db, ids)
+	if err != nil {
+		return err
+	}
+
+	for _, product := range products {
+		if product.CategoryID != nil {
+			product.Category = categories[*product.CategoryID]
+		}
+	}
+	return nil
+}
+
+func (r *ProductRepository) attachTags(products []*models.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(products))
+	byID := make(map[string]*models.Product, len(products))
+	for i, product := range products {
+		ids[i] = product.ID
+		byID[product.ID] = product
+	}
+
+	rows, err := r.db.Query(`
+		SELECT pt.product_id, t.id, t.name, t.slug, t.usage_count, t.created_at
+		FROM product_tags pt
+		JOIN tags t ON t.id = pt.tag_id
+		WHERE pt.product_id = ANY($1::uuid[])
+		ORDER BY t.name`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var productID string
+		var tag models.Tag
+		if err := rows.Scan(&productID, &tag.ID, &tag.Name, &tag.Slug, &tag.UsageCount, &tag.CreatedAt); err != nil {
+			return err
+		}
+		if product, ok := byID[productID]; ok {
+			product.Tags = append(product.Tags, tag)
+		}
+	}
+
+	return rows.Err()
+}
+
+func scanProduct(row rowScanner) (*models.Product, error) {
+	product := &models.Product{}
+	user := &models.User{}
+	var previewImages []byte
+
+	err := row.Scan(
+		&product.ID, &product.UserID, &product.CategoryID, &product.Title,
+		&product.Description, &product.ShortDescription, &product.ThumbnailURL,
+		&previewImages, &product.DemoURL, &product.Price, &product.OriginalPrice,
+		&product.IsFree, &product.LicenseType, &product.FileSize, &product.FileFormat,
+		&product.Compatibility, &product.Requirements, &product.Status,
+		&product.DownloadCount, &product.DownloadLimit, &product.RatingAverage, &product.RatingCount,
+		&product.LikeCount, &product.CreatedAt, &product.UpdatedAt,
+		&user.ID, &user.Username, &user.DisplayName, &user.AvatarURL,
+		&user.IsVerified, &user.IsCreator,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	product.PreviewImages = previewImages
+	product.User = user
+	return product, nil
+}
@@ -0,0 +1,318 @@
+package repositories
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+	"viport-backend/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrQuizNotPassed     = errors.New("pass the lesson's quiz to complete it")
+	ErrAttemptsExhausted = errors.New("no quiz attempts left")
+)
+
+const quizSelectColumns = `
+	q.id, q.lesson_id, q.course_id, q.title, q.description, q.pass_mark,
+	q.max_attempts, q.required_to_complete, q.questions, q.created_at, q.updated_at`
+
+const quizAttemptSelectColumns = `
+	a.id, a.quiz_id, a.user_id, a.answers, a.results, a.score, a.max_score,
+	a.percentage, a.passed, a.created_at`
+
+type QuizRepository struct {
+	db *sql.DB
+}
+
+func NewQuizRepository(db *sql.DB) *QuizRepository {
+	return &QuizRepository{db: db}
+}
+
+func (r *QuizRepository) IsConnected() bool {
+	return r.db != nil
+}
+
+// GetByLesson returns the quiz of a lesson of the course, or sql.ErrNoRows.
+func (r *QuizRepository) GetByLesson(courseID, lessonID string) (*models.Quiz, error) {
+	if !r.IsConnected() {
+		return nil, sql.ErrConnDone
+	}
+	if !isUUID(courseID) || !isUUID(lessonID) {
+		return nil, sql.ErrNoRows
+	}
+
+	return scanQuiz(r.db.QueryRow(`
+		SELECT `+quizSelectColumns+` FROM quizzes q
+		WHERE q.lesson_id = $1 AND q.course_id = $2`, lessonID, courseID))
+}
+
+// Save creates the quiz of quiz.LessonID or replaces the one it has, and
+// recomputes the progress of the course's students, whose completions may
+// now count or wait on the quiz. It returns sql.ErrNoRows when the lesson is
+// not part of the course.
+func (r *QuizRepository) Save(quiz *models.Quiz) error {
+	if !r.IsConnected() {
+		return sql.ErrConnDone
+	}
+	if !isUUID(quiz.CourseID) || !isUUID(quiz.LessonID) {
+		return sql.ErrNoRows
+	}
+
+	questions, err := json.Marshal(quiz.Questions)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Lock the course, as lesson changes do, so the lesson cannot go away
+	if _, err := lockLessonOrder(tx, quiz.CourseID); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	err = tx.QueryRow(`
+		INSERT INTO quizzes (
+			id, lesson_id, course_id, title, description, pass_mark, max_attempts,
+			required_to_complete, questions, created_at, updated_at
+		)
+		SELECT $1, l.id, l.course_id, $4, $5, $6, $7, $8, $9, $10, $10
+		FROM lessons l WHERE l.id = $2 AND l.course_id = $3
+		ON CONFLICT (lesson_id) DO UPDATE SET
+			title = EXCLUDED.title, description = EXCLUDED.description,
+			pass_mark = EXCLUDED.pass_mark, max_attempts = EXCLUDED.max_attempts,
+			required_to_complete = EXCLUDED.required_to_complete,
+			questions = EXCLUDED.questions, updated_at = EXCLUDED.updated_at
+		RETURNING id, created_at, updated_at`,
+		uuid.New().String(), quiz.LessonID, quiz.CourseID, quiz.Title, quiz.Description,
+		quiz.PassMark, quiz.MaxAttempts, quiz.RequiredToComplete, questions, now,
+	).Scan(&quiz.ID, &quiz.CreatedAt, &quiz.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	if err := recomputeProgress(tx, quiz.CourseID, ""); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Delete removes the quiz of a lesson with its attempts, after which the
+// lesson's completions count again.
+func (r *QuizRepository) Delete(courseID, lessonID string) error {
+	if !r.IsConnected() {
+		return sql.ErrConnDone
+	}
+	if !isUUID(courseID) || !isUUID(lessonID) {
+		return sql.ErrNoRows
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`DELETE FROM quizzes WHERE lesson_id = $1 AND course_id = $2`, lessonID, courseID)
+	if err != nil {
+		return err
+	}
+	if err := expectAffected(result); err != nil {
+		return err
+	}
+
+	if err := recomputeProgress(tx, courseID, ""); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// CreateAttempt records a scored attempt by an enrolled student. Passing
+// completes the quiz's lesson for them and recomputes their progress; the
+// returned enrollment reflects it. It returns ErrNotEnrolled for students
+// who are not enrolled and ErrAttemptsExhausted once they used maxAttempts.
+func (r *QuizRepository) CreateAttempt(quiz *models.Quiz, attempt *models.QuizAttempt) (*models.Enrollment, error) {
+	if !r.IsConnected() {
+		return nil, sql.ErrConnDone
+	}
+
+	answers, err := json.Marshal(attempt.Answers)
+	if err != nil {
+		return nil, err
+	}
+	results, err := json.Marshal(attempt.Results)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Locking the enrollment serializes the student's attempts, so
+	// concurrent submissions cannot exceed the limit
+	var enrollmentID string
+	err = tx.QueryRow(`
+		SELECT id FROM enrollments WHERE user_id = $1 AND course_id = $2
+		FOR UPDATE`, attempt.UserID, quiz.CourseID).Scan(&enrollmentID)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotEnrolled
+	} else if err != nil {
+		return nil, err
+	}
+
+	if quiz.MaxAttempts != nil {
+		var used int
+		err := tx.QueryRow(`SELECT COUNT(*) FROM quiz_attempts WHERE quiz_id = $1 AND user_id = $2`,
+			quiz.ID, attempt.UserID).Scan(&used)
+		if err != nil {
+			return nil, err
+		}
+		if used >= *quiz.MaxAttempts {
+			return nil, ErrAttemptsExhausted
+		}
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO quiz_attempts (quiz_id, user_id, answers, results, score, max_score, percentage, passed)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at`,
+		quiz.ID, attempt.UserID, answers, results, attempt.Score, attempt.MaxScore,
+		attempt.Percentage, attempt.Passed,
+	).Scan(&attempt.ID, &attempt.CreatedAt)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code.Name() == "foreign_key_violation" {
+			return nil, sql.ErrNoRows
+		}
+		return nil, err
+	}
+	attempt.QuizID = quiz.ID
+
+	if attempt.Passed {
+		_, err := tx.Exec(`
+			INSERT INTO lesson_completions (user_id, lesson_id, course_id) VALUES ($1, $2, $3)
+			ON CONFLICT (user_id, lesson_id) DO NOTHING`, attempt.UserID, quiz.LessonID, quiz.CourseID)
+		if err != nil {
+			return nil, err
+		}
+		if err := recomputeProgress(tx, quiz.CourseID, attempt.UserID); err != nil {
+			return nil, err
+		}
+	}
+
+	enrollment, err := scanEnrollment(tx.QueryRow(`
+		UPDATE enrollments e SET last_accessed_lesson_id = $2, last_accessed_at = NOW()
+		WHERE e.id = $1
+		RETURNING `+enrollmentSelectColumns, enrollmentID, quiz.LessonID))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return enrollment, nil
+}
+
+// ListAttempts returns the student's attempts at a quiz, newest first.
+func (r *QuizRepository) ListAttempts(quizID, userID string) ([]*models.QuizAttempt, error) {
+	if !r.IsConnected() {
+		return nil, sql.ErrConnDone
+	}
+
+	rows, err := r.db.Query(`
+		SELECT `+quizAttemptSelectColumns+` FROM quiz_attempts a
+		WHERE a.quiz_id = $1 AND a.user_id = $2
+		ORDER BY a.created_at DESC, a.id`, quizID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attempts := []*models.QuizAttempt{}
+	for rows.Next() {
+		attempt, err := scanQuizAttempt(rows)
+		if err != nil {
+			return nil, err
+		}
+		attempts = append(attempts, attempt)
+	}
+	return attempts, rows.Err()
+}
+
+// AttemptSummary returns how many attempts the student made at a quiz, their
+// best percentage, nil before the first attempt, and whether they passed.
+func (r *QuizRepository) AttemptSummary(quizID, userID string) (int, *int, bool, error) {
+	if !r.IsConnected() {
+		return 0, nil, false, sql.ErrConnDone
+	}
+
+	var used int
+	var best sql.NullInt64
+	var passed bool
+	err := r.db.QueryRow(`
+		SELECT COUNT(*), MAX(percentage), COALESCE(BOOL_OR(passed), FALSE)
+		FROM quiz_attempts
+		WHERE quiz_id = $1 AND user_id = $2`, quizID, userID).Scan(&used, &best, &passed)
+	if err != nil {
+		return 0, nil, false, err
+	}
+
+	if !best.Valid {
+		return used, nil, passed, nil
+	}
+	bestPercentage := int(best.Int64)
+	return used, &bestPercentage, passed, nil
+}
+
+func scanQuiz(row rowScanner) (*models.Quiz, error) {
+	quiz := &models.Quiz{}
+	var questions []byte
+
+	err := row.Scan(
+		&quiz.ID, &quiz.LessonID, &quiz.CourseID, &quiz.Title, &quiz.Description,
+		&quiz.PassMark, &quiz.MaxAttempts, &quiz.RequiredToComplete, &questions,
+		&quiz.CreatedAt, &quiz.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(questions, &quiz.Questions); err != nil {
+		return nil, err
+	}
+	return quiz, nil
+}
+
+func scanQuizAttempt(row rowScanner) (*models.QuizAttempt, error) {
+	attempt := &models.QuizAttempt{}
+	var answers, results []byte
+
+	err := row.Scan(
+		&attempt.ID, &attempt.QuizID, &attempt.UserID, &answers, &results,
+		&attempt.Score, &attempt.MaxScore, &attempt.Percentage, &attempt.Passed,
+		&attempt.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(answers, &attempt.Answers); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(results, &attempt.Results); err != nil {
+		return nil, err
+	}
+	return attempt, nil
+}
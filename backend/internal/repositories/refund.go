@@ -0,0 +1,339 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"math"
+	"time"
+	"viport-backend/internal/models"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrNotRefundable        = errors.New("transaction cannot be refunded")
+	ErrRefundExceedsBalance = errors.New("refund exceeds the amount left to refund")
+	ErrRefundOpen           = errors.New("a refund is already open for this transaction")
+)
+
+const refundSelectColumns = `
+	r.id, r.transaction_id, r.kind, r.requested_by, r.reason, r.amount, r.status,
+	r.reviewed_by, r.review_note, r.provider_reference, r.created_at, r.resolved_at`
+
+type RefundRepository struct {
+	db *sql.DB
+}
+
+func NewRefundRepository(db *sql.DB) *RefundRepository {
+	return &RefundRepository{db: db}
+}
+
+func (r *RefundRepository) IsConnected() bool {
+	return r.db != nil
+}
+
+// Create opens a refund against a completed transaction. A zero Amount asks
+// for everything not refunded yet. It returns ErrNotRefundable unless the
+// transaction is completed, ErrRefundExceedsBalance when Amount is larger
+// than what is left and ErrRefundOpen when another refund is still open.
+func (r *RefundRepository) Create(refund *models.Refund) error {
+	if !r.IsConnected() {
+		return sql.ErrConnDone
+	}
+	if !isUUID(refund.TransactionID) {
+		return sql.ErrNoRows
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var status string
+	var amount, refunded float64
+	err = tx.QueryRow(`
+		SELECT payment_status, amount, refunded_amount FROM transactions
+		WHERE id = $1 FOR UPDATE`, refund.TransactionID).Scan(&status, &amount, &refunded)
+	if err != nil {
+		return err
+	}
+	if status != "completed" {
+		return ErrNotRefundable
+	}
+
+	remaining := toCents(amount) - toCents(refunded)
+	if refund.Amount == 0 {
+		refund.Amount = fromCents(remaining)
+	}
+	if toCents(refund.Amount) > remaining || remaining == 0 {
+		return ErrRefundExceedsBalance
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO refunds (transaction_id, kind, requested_by, reason, amount, status, provider_reference)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at`,
+		refund.TransactionID, refund.Kind, refund.RequestedBy, refund.Reason, refund.Amount,
+		refund.Status, refund.ProviderReference,
+	).Scan(&refund.ID, &refund.CreatedAt)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code.Name() == "unique_violation" {
+			return ErrRefundOpen
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetByID returns the refund with its transaction.
+func (r *RefundRepository) GetByID(id string) (*models.Refund, error) {
+	if !r.IsConnected() {
+		return nil, sql.ErrConnDone
+	}
+	if !isUUID(id) {
+		return nil, sql.ErrNoRows
+	}
+
+	return scanRefundWithTransaction(r.db.QueryRow(`
+		SELECT `+refundSelectColumns+`, `+transactionSelectColumns+`
+		FROM refunds r
+		JOIN transactions t ON t.id = r.transaction_id
+		WHERE r.id = $1`, id))
+}
+
+// GetByProviderReference finds a dispute by the provider's dispute ID.
+func (r *RefundRepository) GetByProviderReference(reference string) (*models.Refund, error) {
+	if !r.IsConnected() {
+		return nil, sql.ErrConnDone
+	}
+
+	return scanRefundWithTransaction(r.db.QueryRow(`
+		SELECT `+refundSelectColumns+`, `+transactionSelectColumns+`
+		FROM refunds r
+		JOIN transactions t ON t.id = r.transaction_id
+		WHERE r.provider_reference = $1`, reference))
+}
+
+// ListForUser returns the refunds on the user's purchases (role "buyer") or
+// sales (role "seller"), newest first, with the total count.
+func (r *RefundRepository) ListForUser(userID, role string, limit, offset int) ([]*models.Refund, int, error) {
+	if !r.IsConnected() {
+		return nil, 0, sql.ErrConnDone
+	}
+	if !isUUID(userID) {
+		return []*models.Refund{}, 0, nil
+	}
+
+	column := "t.buyer_id"
+	if role == "seller" {
+		column = "t.seller_id"
+	}
+
+	var total int
+	err := r.db.QueryRow(`
+		SELECT COUNT(*) FROM refunds r
+		JOIN transactions t ON t.id = r.transaction_id
+		WHERE `+column+` = $1`, userID).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.db.Query(`
+		SELECT `+refundSelectColumns+`, `+transactionSelectColumns+`
+		FROM refunds r
+		JOIN transactions t ON t.id = r.transaction_id
+		WHERE `+column+` = $1
+		ORDER BY r.created_at DESC, r.id DESC
+		LIMIT $2 OFFSET $3`, userID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	refunds := []*models.Refund{}
+	for rows.Next() {
+		refund, err := scanRefundWithTransaction(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		refunds = append(refunds, refund)
+	}
+
+	return refunds, total, rows.Err()
+}
+
+// UpdateStatus moves a refund from one status to another, recording the
+// reviewer, note and provider reference when given. Final statuses set
+// resolved_at. It returns ErrStatusConflict when the refund is no longer in
+// status from.
+func (r *RefundRepository) UpdateStatus(id, from, to string, reviewerID, note, reference *string) error {
+	if !r.IsConnected() {
+		return sql.ErrConnDone
+	}
+
+	result, err := r.db.Exec(`
+		UPDATE refunds SET
+			status = $3,
+			reviewed_by = COALESCE($4, reviewed_by),
+			review_note = COALESCE($5, review_note),
+			provider_reference = COALESCE($6, provider_reference),
+			resolved_at = CASE WHEN $3 IN ('completed', 'rejected', 'failed') THEN NOW() END
+		WHERE id = $1 AND status = $2`, id, from, to, reviewerID, note, reference)
+	if err != nil {
+		return err
+	}
+
+	if err := expectAffected(result); err == sql.ErrNoRows {
+		return ErrStatusConflict
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Complete settles an open refund: it is marked completed and its amount is
+// booked against the transaction, taking the platform fee and the seller's
+// net share down in proportion. Once the whole transaction is refunded it
+// moves to "refunded", which revokes the buyer's access. It returns
+// ErrStatusConflict unless the refund is in the from status, which is
+// "processing" for refunds the provider settles: they must have been
+// approved, or opened by the provider as disputes.
+func (r *RefundRepository) Complete(id, from string, reviewerID, note, reference *string) (*models.Refund, error) {
+	if !r.IsConnected() {
+		return nil, sql.ErrConnDone
+	}
+	if !isUUID(id) {
+		return nil, sql.ErrNoRows
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	refund, err := scanRefundWithTransaction(tx.QueryRow(`
+		SELECT `+refundSelectColumns+`, `+transactionSelectColumns+`
+		FROM refunds r
+		JOIN transactions t ON t.id = r.transaction_id
+		WHERE r.id = $1
+		FOR UPDATE`, id))
+	if err != nil {
+		return nil, err
+	}
+	if refund.Status != from {
+		return nil, ErrStatusConflict
+	}
+
+	transaction := refund.Transaction
+	if transaction.PaymentStatus != "completed" {
+		return nil, ErrNotRefundable
+	}
+
+	// Split the refund between fee and net in proportion to what is left
+	remaining := toCents(transaction.Amount) - toCents(transaction.RefundedAmount)
+	refunded := toCents(refund.Amount)
+	if refunded > remaining {
+		return nil, ErrRefundExceedsBalance
+	}
+	fee := toCents(transaction.FeeAmount)
+	feeShare := int64(math.Round(float64(fee) * float64(refunded) / float64(remaining)))
+	netShare := refunded - feeShare
+
+	transaction.RefundedAmount = fromCents(toCents(transaction.RefundedAmount) + refunded)
+	transaction.FeeAmount = fromCents(fee - feeShare)
+	transaction.NetAmount = fromCents(toCents(transaction.NetAmount) - netShare)
+
+	_, err = tx.Exec(`
+		UPDATE transactions SET refunded_amount = $2, fee_amount = $3, net_amount = $4
+		WHERE id = $1`, transaction.ID, transaction.RefundedAmount, transaction.FeeAmount, transaction.NetAmount)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := postRefund(tx, transaction, &refund.ID, feeShare, netShare); err != nil {
+		return nil, err
+	}
+
+	if refunded == remaining {
+		_, err := tx.Exec(`UPDATE transactions SET payment_status = 'refunded' WHERE id = $1`, transaction.ID)
+		if err != nil {
+			return nil, err
+		}
+		transaction.PaymentStatus = "refunded"
+		if err := applyStatusEffects(tx, transaction); err != nil {
+			return nil, err
+		}
+	} else if err := notify(tx, transaction.BuyerID, "purchase_refunded", "Partial refund issued", transaction); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	_, err = tx.Exec(`
+		UPDATE refunds SET
+			status = 'completed',
+			reviewed_by = COALESCE($2, reviewed_by),
+			review_note = COALESCE($3, review_note),
+			provider_reference = COALESCE($4, provider_reference),
+			resolved_at = $5
+		WHERE id = $1`, refund.ID, reviewerID, note, reference, now)
+	if err != nil {
+		return nil, err
+	}
+	refund.Status = "completed"
+	refund.ResolvedAt = &now
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return refund, nil
+}
+
+// RejectOpen rejects the requested refund on a transaction, if any, with
+// the given note. Disputes use it to take over from a pending request.
+func (r *RefundRepository) RejectOpen(transactionID, note string) error {
+	if !r.IsConnected() {
+		return sql.ErrConnDone
+	}
+	if !isUUID(transactionID) {
+		return nil
+	}
+
+	_, err := r.db.Exec(`
+		UPDATE refunds SET status = 'rejected', review_note = $2, resolved_at = NOW()
+		WHERE transaction_id = $1 AND status = 'requested'`, transactionID, note)
+	return err
+}
+
+func scanRefundWithTransaction(row rowScanner) (*models.Refund, error) {
+	refund := &models.Refund{}
+	transaction := &models.Transaction{}
+	err := row.Scan(
+		&refund.ID, &refund.TransactionID, &refund.Kind, &refund.RequestedBy, &refund.Reason,
+		&refund.Amount, &refund.Status, &refund.ReviewedBy, &refund.ReviewNote,
+		&refund.ProviderReference, &refund.CreatedAt, &refund.ResolvedAt,
+		&transaction.ID, &transaction.BuyerID, &transaction.SellerID, &transaction.ItemType,
+		&transaction.ItemID, &transaction.Amount, &transaction.FeeAmount, &transaction.NetAmount,
+		&transaction.Currency, &transaction.PaymentMethod, &transaction.PaymentStatus,
+		&transaction.TransactionID, &transaction.RefundedAmount, &transaction.FeeRuleID,
+		&transaction.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	refund.Transaction = transaction
+	return refund, nil
+}
+
+// toCents converts a DECIMAL(10,2) amount to integer cents for exact
+// arithmetic.
+func toCents(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+func fromCents(cents int64) float64 {
+	return float64(cents) / 100
+}
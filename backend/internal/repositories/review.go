@@ -0,0 +1,523 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"viport-backend/internal/models"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrAlreadyReviewed = errors.New("item already reviewed")
+	ErrSelfReview      = errors.New("creators cannot review their own items")
+	ErrSelfVote        = errors.New("authors cannot vote on their own reviews")
+)
+
+// reviewable is a table that can be reviewed, the column naming its
+// creator and the status it must have to be reviewed.
+type reviewable struct {
+	table       string
+	ownerColumn string
+	liveStatus  string
+}
+
+// Reviewable tables, keyed by reviewable_type. Each keeps denormalized
+// rating_average and rating_count columns.
+var reviewableTables = map[string]reviewable{
+	"product": {"products", "user_id", "active"},
+	"course":  {"courses", "instructor_id", "published"},
+}
+
+// Sortable columns for ReviewFilter.SortBy; lists are always descending.
+var reviewSortColumns = map[string]sortColumn{
+	"created_at":    {"r.created_at", "timestamptz"},
+	"helpful_count": {"r.helpful_count", "integer"},
+}
+
+// IsReviewableType reports whether reviews are supported for the given type.
+func IsReviewableType(reviewableType string) bool {
+	_, ok := reviewableTables[reviewableType]
+	return ok
+}
+
+const reviewSelectColumns = `
+	r.id, r.user_id, r.reviewable_type, r.reviewable_id, r.rating, r.title, r.content,
+	r.is_verified_purchase, r.helpful_count, r.created_at, r.updated_at,
+	u.id, u.username, u.display_name, u.avatar_url, u.is_verified, u.is_creator`
+
+// verifiedPurchaseCondition is true when the user bound to $1 owns the item
+// of type $2 and ID $3, through a completed purchase or, for courses, an
+// enrollment.
+const verifiedPurchaseCondition = `(
+	EXISTS (SELECT 1 FROM transactions
+		WHERE buyer_id = $1 AND item_type = $2 AND item_id = $3 AND payment_status = 'completed')
+	OR ($2 = 'course' AND EXISTS (SELECT 1 FROM enrollments WHERE user_id = $1 AND course_id = $3)))`
+
+type ReviewRepository struct {
+	db *sql.DB
+}
+
+func NewReviewRepository(db *sql.DB) *ReviewRepository {
+	return &ReviewRepository{db: db}
+}
+
+func (r *ReviewRepository) IsConnected() bool {
+	return r.db != nil
+}
+
+// Create inserts the user's review of an item and recomputes the item's
+// rating in the same transaction. IsVerifiedPurchase is derived from the
+// user's purchases and enrollments. It returns sql.ErrNoRows when the item
+// does not exist or is not live, ErrSelfReview for the item's own creator
+// and ErrAlreadyReviewed when the user reviewed it before.
+func (r *ReviewRepository) Create(review *models.Review) error {
+	if !r.IsConnected() {
+		return sql.ErrConnDone
+	}
+	target, ok := reviewableTables[review.ReviewableType]
+	if !ok {
+		return fmt.Errorf("unsupported reviewable type %q", review.ReviewableType)
+	}
+	if !isUUID(review.ReviewableID) {
+		return sql.ErrNoRows
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Lock the item so concurrent reviews serialize on its rating
+	var ownerID, status string
+	err = tx.QueryRow(`SELECT `+target.ownerColumn+`, status FROM `+target.table+` WHERE id = $1 FOR UPDATE`,
+		review.ReviewableID).Scan(&ownerID, &status)
+	if err != nil {
+		return err
+	}
+	if status != target.liveStatus {
+		return sql.ErrNoRows
+	}
+	if ownerID == review.UserID {
+		return ErrSelfReview
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO reviews (user_id, reviewable_type, reviewable_id, rating, title, content, is_verified_purchase)
+		VALUES ($1, $2, $3, $4, $5, $6, `+verifiedPurchaseCondition+`)
+		RETURNING id, is_verified_purchase, helpful_count, created_at, updated_at`,
+		review.UserID, review.ReviewableType, review.ReviewableID, review.Rating, review.Title, review.Content,
+	).Scan(&review.ID, &review.IsVerifiedPurchase, &review.HelpfulCount, &review.CreatedAt, &review.UpdatedAt)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code.Name() == "unique_violation" {
+			return ErrAlreadyReviewed
+		}
+		return err
+	}
+
+	if err := recomputeRating(tx, review.ReviewableType, review.ReviewableID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *ReviewRepository) GetByID(id string) (*models.Review, error) {
+	if !r.IsConnected() {
+		return nil, sql.ErrConnDone
+	}
+	if !isUUID(id) {
+		return nil, sql.ErrNoRows
+	}
+
+	return scanReview(r.db.QueryRow(`
+		SELECT `+reviewSelectColumns+`
+		FROM reviews r
+		JOIN users u ON u.id = r.user_id
+		WHERE r.id = $1`, id))
+}
+
+// GetOwnerID returns the ID of the review's author.
+func (r *ReviewRepository) GetOwnerID(id string) (string, error) {
+	if !r.IsConnected() {
+		return "", sql.ErrConnDone
+	}
+	if !isUUID(id) {
+		return "", sql.ErrNoRows
+	}
+
+	var ownerID string
+	err := r.db.QueryRow(`SELECT user_id FROM reviews WHERE id = $1`, id).Scan(&ownerID)
+	return ownerID, err
+}
+
+// CanView returns sql.ErrNoRows unless the item exists and is live, or the
+// viewer is its creator.
+func (r *ReviewRepository) CanView(reviewableType, reviewableID, viewerID string) error {
+	if !r.IsConnected() {
+		return sql.ErrConnDone
+	}
+	target, ok := reviewableTables[reviewableType]
+	if !ok || !isUUID(reviewableID) {
+		return sql.ErrNoRows
+	}
+
+	var visible bool
+	err := r.db.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM `+target.table+`
+			WHERE id = $1 AND (status = $2 OR `+target.ownerColumn+` = $3))`,
+		reviewableID, target.liveStatus, viewerArg(viewerID)).Scan(&visible)
+	if err != nil {
+		return err
+	}
+	if !visible {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// Update saves the rating, title and content of a review, re-derives its
+// verified purchase badge and recomputes the item's rating.
+func (r *ReviewRepository) Update(review *models.Review) error {
+	if !r.IsConnected() {
+		return sql.ErrConnDone
+	}
+	target, ok := reviewableTables[review.ReviewableType]
+	if !ok {
+		return fmt.Errorf("unsupported reviewable type %q", review.ReviewableType)
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`SELECT 1 FROM `+target.table+` WHERE id = $1 FOR UPDATE`, review.ReviewableID); err != nil {
+		return err
+	}
+
+	err = tx.QueryRow(`
+		UPDATE reviews SET
+			rating = $5, title = $6, content = $7,
+			is_verified_purchase = `+verifiedPurchaseCondition+`, updated_at = NOW()
+		WHERE id = $4 AND user_id = $1 AND reviewable_type = $2 AND reviewable_id = $3
+		RETURNING is_verified_purchase, helpful_count, updated_at`,
+		review.UserID, review.ReviewableType, review.ReviewableID, review.ID,
+		review.Rating, review.Title, review.Content,
+	).Scan(&review.IsVerifiedPurchase, &review.HelpfulCount, &review.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	if err := recomputeRating(tx, review.ReviewableType, review.ReviewableID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Delete removes a review, its votes included, and recomputes the item's
+// rating.
+func (r *ReviewRepository) Delete(id string) error {
+	if !r.IsConnected() {
+		return sql.ErrConnDone
+	}
+	if !isUUID(id) {
+		return sql.ErrNoRows
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var reviewableType, reviewableID string
+	err = tx.QueryRow(`SELECT reviewable_type, reviewable_id FROM reviews WHERE id = $1`, id).
+		Scan(&reviewableType, &reviewableID)
+	if err != nil {
+		return err
+	}
+	if target, ok := reviewableTables[reviewableType]; ok {
+		if _, err := tx.Exec(`SELECT 1 FROM `+target.table+` WHERE id = $1 FOR UPDATE`, reviewableID); err != nil {
+			return err
+		}
+	}
+
+	result, err := tx.Exec(`DELETE FROM reviews WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if err := expectAffected(result); err != nil {
+		return err
+	}
+
+	if err := recomputeRating(tx, reviewableType, reviewableID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// List returns the page of reviews on an item matching filter together with
+// the total number of matching reviews, newest or most helpful first. Up to
+// Limit+1 reviews are returned so the caller can tell whether another page
+// follows.
+func (r *ReviewRepository) List(filter models.ReviewFilter) ([]*models.Review, int, error) {
+	if !r.IsConnected() {
+		return nil, 0, sql.ErrConnDone
+	}
+	if !isUUID(filter.ReviewableID) {
+		return []*models.Review{}, 0, nil
+	}
+
+	args := []interface{}{filter.ReviewableType, filter.ReviewableID}
+	where := ` WHERE r.reviewable_type = $1 AND r.reviewable_id = $2`
+	if filter.Rating != nil {
+		args = append(args, *filter.Rating)
+		where += fmt.Sprintf(" AND r.rating = $%d", len(args))
+	}
+
+	var total int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM reviews r`+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	sort, ok := reviewSortColumns[filter.SortBy]
+	if !ok {
+		sort = reviewSortColumns["created_at"]
+	}
+
+	addArg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	keyset, orderBy := keysetClause(sort.column, sort.cast, "r.id", true, filter.Cursor, addArg)
+	if keyset != "" {
+		where += " AND " + keyset
+	}
+
+	offset := filter.Offset
+	if filter.Cursor != nil {
+		offset = 0
+	}
+
+	rows, err := r.db.Query(fmt.Sprintf(`SELECT %s
+		FROM reviews r
+		JOIN users u ON u.id = r.user_id%s
+		ORDER BY %s
+		LIMIT %s OFFSET %s`,
+		reviewSelectColumns, where, orderBy, addArg(filter.Limit+1), addArg(offset)), args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	reviews := []*models.Review{}
+	for rows.Next() {
+		review, err := scanReview(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		reviews = append(reviews, review)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	reverseRows(reviews, filter.Cursor)
+
+	return reviews, total, nil
+}
+
+// Summary returns the rating aggregate and histogram of an item's reviews.
+func (r *ReviewRepository) Summary(reviewableType, reviewableID string) (*models.RatingSummary, error) {
+	if !r.IsConnected() {
+		return nil, sql.ErrConnDone
+	}
+
+	summary := &models.RatingSummary{
+		ReviewableType: reviewableType,
+		ReviewableID:   reviewableID,
+		Histogram:      map[int]int{1: 0, 2: 0, 3: 0, 4: 0, 5: 0},
+	}
+	if !isUUID(reviewableID) {
+		return summary, nil
+	}
+
+	rows, err := r.db.Query(`
+		SELECT rating, COUNT(*) FROM reviews
+		WHERE reviewable_type = $1 AND reviewable_id = $2
+		GROUP BY rating`, reviewableType, reviewableID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sum int
+	for rows.Next() {
+		var rating, count int
+		if err := rows.Scan(&rating, &count); err != nil {
+			return nil, err
+		}
+		summary.Histogram[rating] = count
+		summary.RatingCount += count
+		sum += rating * count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if summary.RatingCount > 0 {
+		// Rounded to two places like the stored rating_average
+		summary.RatingAverage = float64((sum*200+summary.RatingCount)/(summary.RatingCount*2)) / 100
+	}
+	return summary, nil
+}
+
+// Vote marks the review as helpful to the user and returns its new helpful
+// count. Voting twice is a no-op.
+func (r *ReviewRepository) Vote(reviewID, userID string) (int, error) {
+	return r.toggleVote(reviewID, userID, true)
+}
+
+// Unvote withdraws the user's helpful vote and returns the new helpful
+// count. Withdrawing a vote never cast is a no-op.
+func (r *ReviewRepository) Unvote(reviewID, userID string) (int, error) {
+	return r.toggleVote(reviewID, userID, false)
+}
+
+func (r *ReviewRepository) toggleVote(reviewID, userID string, helpful bool) (int, error) {
+	if !r.IsConnected() {
+		return 0, sql.ErrConnDone
+	}
+	if !isUUID(reviewID) {
+		return 0, sql.ErrNoRows
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// Lock the review so concurrent votes serialize on the counter
+	var authorID string
+	var helpfulCount int
+	err = tx.QueryRow(`SELECT user_id, helpful_count FROM reviews WHERE id = $1 FOR UPDATE`, reviewID).
+		Scan(&authorID, &helpfulCount)
+	if err != nil {
+		return 0, err
+	}
+	if authorID == userID {
+		return 0, ErrSelfVote
+	}
+
+	var result sql.Result
+	if helpful {
+		result, err = tx.Exec(`
+			INSERT INTO review_votes (review_id, user_id) VALUES ($1, $2)
+			ON CONFLICT (review_id, user_id) DO NOTHING`, reviewID, userID)
+	} else {
+		result, err = tx.Exec(`DELETE FROM review_votes WHERE review_id = $1 AND user_id = $2`, reviewID, userID)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	changed, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	if changed > 0 {
+		delta := 1
+		if !helpful {
+			delta = -1
+		}
+		err = tx.QueryRow(`
+			UPDATE reviews SET helpful_count = GREATEST(helpful_count + $2, 0)
+			WHERE id = $1
+			RETURNING helpful_count`, reviewID, delta).Scan(&helpfulCount)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return helpfulCount, nil
+}
+
+// HelpfulIDs returns the subset of reviewIDs the user voted helpful.
+func (r *ReviewRepository) HelpfulIDs(userID string, reviewIDs []string) (map[string]bool, error) {
+	helpful := make(map[string]bool)
+	if !r.IsConnected() {
+		return helpful, sql.ErrConnDone
+	}
+	if !isUUID(userID) || len(reviewIDs) == 0 {
+		return helpful, nil
+	}
+
+	rows, err := r.db.Query(`
+		SELECT review_id FROM review_votes
+		WHERE user_id = $1 AND review_id = ANY($2::uuid[])`,
+		userID, pq.Array(reviewIDs))
+	if err != nil {
+		return helpful, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return helpful, err
+		}
+		helpful[id] = true
+	}
+
+	return helpful, rows.Err()
+}
+
+// recomputeRating rewrites an item's rating_average and rating_count from
+// its reviews. Callers hold the item's row lock.
+func recomputeRating(tx *sql.Tx, reviewableType, reviewableID string) error {
+	target, ok := reviewableTables[reviewableType]
+	if !ok {
+		return nil
+	}
+
+	_, err := tx.Exec(`
+		UPDATE `+target.table+` SET
+			rating_average = COALESCE(stats.average, 0),
+			rating_count = stats.count
+		FROM (
+			SELECT ROUND(AVG(rating), 2) AS average, COUNT(*) AS count
+			FROM reviews WHERE reviewable_type = $1 AND reviewable_id = $2
+		) stats
+		WHERE id = $2`, reviewableType, reviewableID)
+	return err
+}
+
+func scanReview(row rowScanner) (*models.Review, error) {
+	review := &models.Review{}
+	user := &models.User{}
+	err := row.Scan(
+		&review.ID, &review.UserID, &review.ReviewableType, &review.ReviewableID,
+		&review.Rating, &review.Title, &review.Content, &review.IsVerifiedPurchase,
+		&review.HelpfulCount, &review.CreatedAt, &review.UpdatedAt,
+		&user.ID, &user.Username, &user.DisplayName, &user.AvatarURL,
+		&user.IsVerified, &user.IsCreator,
+	)
+	if err != nil {
+		return nil, err
+	}
+	review.User = user
+	return review, nil
+}
@@ -0,0 +1,361 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"viport-backend/internal/models"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrCollectionNotFound = errors.New("collection not found")
+	ErrCollectionExists   = errors.New("collection already exists")
+)
+
+// IsSaveableType reports whether items of the given type can be saved.
+func IsSaveableType(saveableType string) bool {
+	switch saveableType {
+	case "post", "product", "course":
+		return true
+	}
+	return false
+}
+
+const savedItemSelectColumns = `
+	s.id, s.user_id, s.saveable_type, s.saveable_id, s.collection_id, s.created_at`
+
+const savedCollectionSelectColumns = `
+	c.id, c.user_id, c.name, c.description,
+	(SELECT COUNT(*) FROM saved_items s WHERE s.collection_id = c.id),
+	c.created_at, c.updated_at`
+
+type SavedItemRepository struct {
+	db *sql.DB
+}
+
+func NewSavedItemRepository(db *sql.DB) *SavedItemRepository {
+	return &SavedItemRepository{db: db}
+}
+
+func (r *SavedItemRepository) IsConnected() bool {
+	return r.db != nil
+}
+
+// Save adds the item to the user's saved items, filed under collectionID
+// when it is set. Saving an item again moves it to the given collection. It
+// returns sql.ErrNoRows when the item does not exist or is hidden from the
+// user, and ErrCollectionNotFound when the collection is not theirs.
+func (r *SavedItemRepository) Save(userID, saveableType, saveableID string, collectionID *string) (*models.SavedItem, error) {
+	if !r.IsConnected() {
+		return nil, sql.ErrConnDone
+	}
+	if !isUUID(saveableID) {
+		return nil, sql.ErrNoRows
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if collectionID != nil {
+		if !isUUID(*collectionID) {
+			return nil, ErrCollectionNotFound
+		}
+		// Lock the collection so it cannot be deleted under the new item
+		var id string
+		err := tx.QueryRow(`SELECT id FROM saved_collections WHERE id = $1 AND user_id = $2 FOR UPDATE`,
+			*collectionID, userID).Scan(&id)
+		if err == sql.ErrNoRows {
+			return nil, ErrCollectionNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Products already on sale start out alerted at their current price so
+	// only later drops notify.
+	item, err := scanSavedItem(tx.QueryRow(`
+		INSERT INTO saved_items AS s (user_id, saveable_type, saveable_id, collection_id, alerted_price)
+		SELECT $1, $2, $3, $4,
+			(SELECT price FROM products WHERE $2 = 'product' AND id = $3 AND price < original_price)
+		WHERE `+saveableVisibleCondition("$2", "$3", "$1")+`
+		ON CONFLICT (user_id, saveable_type, saveable_id)
+			DO UPDATE SET collection_id = EXCLUDED.collection_id
+		RETURNING `+savedItemSelectColumns,
+		userID, saveableType, saveableID, collectionID))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// Unsave removes the item from the user's saved items. Removing an item
+// that was never saved is a no-op.
+func (r *SavedItemRepository) Unsave(userID, saveableType, saveableID string) error {
+	if !r.IsConnected() {
+		return sql.ErrConnDone
+	}
+	if !isUUID(saveableID) {
+		return nil
+	}
+
+	_, err := r.db.Exec(`
+		DELETE FROM saved_items
+		WHERE user_id = $1 AND saveable_type = $2 AND saveable_id = $3`,
+		userID, saveableType, saveableID)
+	return err
+}
+
+// SavedIDs returns the subset of saveableIDs the user has saved.
+func (r *SavedItemRepository) SavedIDs(userID, saveableType string, saveableIDs []string) (map[string]bool, error) {
+	saved := make(map[string]bool)
+	if !r.IsConnected() {
+		return saved, sql.ErrConnDone
+	}
+	if !isUUID(userID) || len(saveableIDs) == 0 {
+		return saved, nil
+	}
+
+	rows, err := r.db.Query(`
+		SELECT saveable_id FROM saved_items
+		WHERE user_id = $1 AND saveable_type = $2 AND saveable_id = ANY($3::uuid[])`,
+		userID, saveableType, pq.Array(saveableIDs))
+	if err != nil {
+		return saved, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return saved, err
+		}
+		saved[id] = true
+	}
+
+	return saved, rows.Err()
+}
+
+// List returns a page of the user's saved items, newest first, with the
+// total count. An empty saveableType lists every type and a nil
+// collectionID every collection. Items since deleted or hidden from the
+// user are left out.
+func (r *SavedItemRepository) List(userID, saveableType string, collectionID *string, limit, offset int) ([]*models.SavedItem, int, error) {
+	if !r.IsConnected() {
+		return nil, 0, sql.ErrConnDone
+	}
+	items := []*models.SavedItem{}
+	if !isUUID(userID) {
+		return items, 0, nil
+	}
+
+	where := ` WHERE s.user_id = $1 AND ($2 = '' OR s.saveable_type = $2)
+		AND ($3::uuid IS NULL OR s.collection_id = $3)
+		AND ` + saveableVisibleCondition("s.saveable_type", "s.saveable_id", "$1")
+
+	var total int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM saved_items s`+where,
+		userID, saveableType, collectionID).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.db.Query(`
+		SELECT `+savedItemSelectColumns+`
+		FROM saved_items s`+where+`
+		ORDER BY s.created_at DESC, s.id DESC
+		LIMIT $4 OFFSET $5`, userID, saveableType, collectionID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		item, err := scanSavedItem(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		items = append(items, item)
+	}
+	return items, total, rows.Err()
+}
+
+// ListCollections returns the user's collections by name.
+func (r *SavedItemRepository) ListCollections(userID string) ([]*models.SavedCollection, error) {
+	if !r.IsConnected() {
+		return nil, sql.ErrConnDone
+	}
+	collections := []*models.SavedCollection{}
+	if !isUUID(userID) {
+		return collections, nil
+	}
+
+	rows, err := r.db.Query(`
+		SELECT `+savedCollectionSelectColumns+`
+		FROM saved_collections c
+		WHERE c.user_id = $1
+		ORDER BY c.name`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		collection, err := scanSavedCollection(rows)
+		if err != nil {
+			return nil, err
+		}
+		collections = append(collections, collection)
+	}
+	return collections, rows.Err()
+}
+
+// GetCollection returns one of the user's collections, or sql.ErrNoRows.
+func (r *SavedItemRepository) GetCollection(id, userID string) (*models.SavedCollection, error) {
+	if !r.IsConnected() {
+		return nil, sql.ErrConnDone
+	}
+	if !isUUID(id) {
+		return nil, sql.ErrNoRows
+	}
+
+	return scanSavedCollection(r.db.QueryRow(`
+		SELECT `+savedCollectionSelectColumns+`
+		FROM saved_collections c
+		WHERE c.id = $1 AND c.user_id = $2`, id, userID))
+}
+
+// CreateCollection inserts a collection. It returns ErrCollectionExists when
+// the user already has one with the same name.
+func (r *SavedItemRepository) CreateCollection(collection *models.SavedCollection) error {
+	if !r.IsConnected() {
+		return sql.ErrConnDone
+	}
+
+	err := r.db.QueryRow(`
+		INSERT INTO saved_collections (user_id, name, description)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at, updated_at`,
+		collection.UserID, collection.Name, collection.Description,
+	).Scan(&collection.ID, &collection.CreatedAt, &collection.UpdatedAt)
+	return collectionWriteError(err)
+}
+
+// UpdateCollection renames one of the user's collections and replaces its
+// description.
+func (r *SavedItemRepository) UpdateCollection(collection *models.SavedCollection) error {
+	if !r.IsConnected() {
+		return sql.ErrConnDone
+	}
+	if !isUUID(collection.ID) {
+		return sql.ErrNoRows
+	}
+
+	err := r.db.QueryRow(`
+		UPDATE saved_collections c SET name = $3, description = $4, updated_at = NOW()
+		WHERE c.id = $1 AND c.user_id = $2
+		RETURNING (SELECT COUNT(*) FROM saved_items s WHERE s.collection_id = c.id),
+			c.created_at, c.updated_at`,
+		collection.ID, collection.UserID, collection.Name, collection.Description,
+	).Scan(&collection.ItemCount, &collection.CreatedAt, &collection.UpdatedAt)
+	return collectionWriteError(err)
+}
+
+// DeleteCollection removes one of the user's collections. Its items stay
+// saved outside any collection.
+func (r *SavedItemRepository) DeleteCollection(id, userID string) error {
+	if !r.IsConnected() {
+		return sql.ErrConnDone
+	}
+	if !isUUID(id) {
+		return sql.ErrNoRows
+	}
+
+	result, err := r.db.Exec(`DELETE FROM saved_collections WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+	return expectAffected(result)
+}
+
+// notifyPriceDrop alerts the users who saved an active product when its
+// price falls below its original price, once per new low. Raising the price
+// back to the original re-arms the alert.
+func notifyPriceDrop(tx *sql.Tx, product *models.Product) error {
+	if product.OriginalPrice == nil || product.Price >= *product.OriginalPrice {
+		_, err := tx.Exec(`
+			UPDATE saved_items SET alerted_price = NULL
+			WHERE saveable_type = 'product' AND saveable_id = $1 AND alerted_price IS NOT NULL`,
+			product.ID)
+		return err
+	}
+	if product.Status != "active" {
+		return nil
+	}
+
+	_, err := tx.Exec(`
+		WITH alerted AS (
+			UPDATE saved_items SET alerted_price = $2
+			WHERE saveable_type = 'product' AND saveable_id = $1 AND user_id <> $3
+				AND (alerted_price IS NULL OR alerted_price > $2)
+			RETURNING user_id
+		)
+		INSERT INTO notifications (user_id, type, title, message, data)
+		SELECT user_id, 'price_drop', 'Price drop on a saved item', $4,
+			json_build_object('productId', $1::text, 'price', $2::numeric, 'originalPrice', $5::numeric)
+		FROM alerted`,
+		product.ID, product.Price, product.UserID, product.Title, *product.OriginalPrice)
+	return err
+}
+
+// saveableVisibleCondition is the SQL predicate deciding whether the item of
+// the given type and ID exists and the viewer may see it: posts by their
+// visibility, products once active and courses once published, except to
+// their own creator.
+func saveableVisibleCondition(saveableType, saveableID, viewer string) string {
+	return `CASE ` + saveableType + `
+		WHEN 'post' THEN EXISTS (SELECT 1 FROM posts p WHERE p.id = ` + saveableID + ` AND ` + postVisibleCondition(viewer) + `)
+		WHEN 'product' THEN EXISTS (SELECT 1 FROM products p WHERE p.id = ` + saveableID + `
+			AND (p.status = 'active' OR p.user_id = ` + viewer + `))
+		WHEN 'course' THEN EXISTS (SELECT 1 FROM courses co WHERE co.id = ` + saveableID + `
+			AND (co.status = 'published' OR co.instructor_id = ` + viewer + `))
+		ELSE FALSE END`
+}
+
+func collectionWriteError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code.Name() == "unique_violation" {
+		return ErrCollectionExists
+	}
+	return err
+}
+
+func scanSavedItem(row rowScanner) (*models.SavedItem, error) {
+	item := &models.SavedItem{}
+	err := row.Scan(
+		&item.ID, &item.UserID, &item.ItemType, &item.ItemID, &item.CollectionID, &item.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+func scanSavedCollection(row rowScanner) (*models.SavedCollection, error) {
+	collection := &models.SavedCollection{}
+	err := row.Scan(
+		&collection.ID, &collection.UserID, &collection.Name, &collection.Description,
+		&collection.ItemCount, &collection.CreatedAt, &collection.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return collection, nil
+}
@@ -0,0 +1,259 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+	"viport-backend/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// tagLink is the join table linking one content type to its tags.
+type tagLink struct {
+	table  string
+	column string
+}
+
+// Link tables for each taggable content type.
+var taggableTables = map[string]tagLink{
+	"post":    {"post_tags", "post_id"},
+	"product": {"product_tags", "product_id"},
+}
+
+// IsTaggableType reports whether tags can be attached to the given type.
+func IsTaggableType(taggableType string) bool {
+	_, ok := taggableTables[taggableType]
+	return ok
+}
+
+const tagSelectColumns = `t.id, t.name, t.slug, t.usage_count, t.created_at`
+
+type TagRepository struct {
+	db *sql.DB
+}
+
+func NewTagRepository(db *sql.DB) *TagRepository {
+	return &TagRepository{db: db}
+}
+
+func (r *TagRepository) IsConnected() bool {
+	return r.db != nil
+}
+
+// SetTags replaces the tags of an item with tags, creating any tag whose
+// slug does not exist yet, and returns the stored tags. Tags are matched on
+// Slug; Name is only used for new tags. Usage counts of every tag added or
+// removed are recomputed in the same transaction.
+func (r *TagRepository) SetTags(taggableType, itemID string, tags []models.Tag) ([]models.Tag, error) {
+	if !r.IsConnected() {
+		return nil, sql.ErrConnDone
+	}
+	link, ok := taggableTables[taggableType]
+	if !ok {
+		return nil, fmt.Errorf("unsupported taggable type %q", taggableType)
+	}
+	if !isUUID(itemID) {
+		return nil, sql.ErrNoRows
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	stored, err := setTags(tx, link, itemID, tags)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return stored, nil
+}
+
+// setTags does the work of SetTags inside tx, so items can be created with
+// their tags atomically.
+func setTags(tx *sql.Tx, link tagLink, itemID string, tags []models.Tag) ([]models.Tag, error) {
+	stored := make([]models.Tag, 0, len(tags))
+	ids := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		err := tx.QueryRow(`
+			INSERT INTO tags (name, slug) VALUES ($1, $2)
+			ON CONFLICT (slug) DO UPDATE SET slug = EXCLUDED.slug
+			RETURNING id, name, slug, usage_count, created_at`,
+			tag.Name, tag.Slug,
+		).Scan(&tag.ID, &tag.Name, &tag.Slug, &tag.UsageCount, &tag.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		stored = append(stored, tag)
+		ids = append(ids, tag.ID)
+	}
+
+	removed, err := queryIDs(tx, `
+		DELETE FROM `+link.table+`
+		WHERE `+link.column+` = $1 AND NOT (tag_id = ANY($2::uuid[]))
+		RETURNING tag_id`, itemID, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+
+	added, err := queryIDs(tx, `
+		INSERT INTO `+link.table+` (`+link.column+`, tag_id)
+		SELECT $1, unnest($2::uuid[])
+		ON CONFLICT DO NOTHING
+		RETURNING tag_id`, itemID, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := recountTagUsage(tx, append(removed, added...)); err != nil {
+		return nil, err
+	}
+
+	// Re-read the counts so the returned tags reflect this change
+	if len(added) > 0 {
+		counts := make(map[string]int, len(stored))
+		rows, err := tx.Query(`SELECT id, usage_count FROM tags WHERE id = ANY($1::uuid[])`, pq.Array(ids))
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var id string
+			var count int
+			if err := rows.Scan(&id, &count); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			counts[id] = count
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		for i := range stored {
+			stored[i].UsageCount = counts[stored[i].ID]
+		}
+	}
+
+	return stored, nil
+}
+
+func (r *TagRepository) GetBySlug(slug string) (*models.Tag, error) {
+	if !r.IsConnected() {
+		return nil, sql.ErrConnDone
+	}
+
+	tag := &models.Tag{}
+	err := r.db.QueryRow(`SELECT `+tagSelectColumns+` FROM tags t WHERE t.slug = $1`, slug).Scan(
+		&tag.ID, &tag.Name, &tag.Slug, &tag.UsageCount, &tag.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return tag, nil
+}
+
+// Search returns the most used tags whose slug or name starts with prefix.
+// An empty prefix matches every tag.
+func (r *TagRepository) Search(prefix string, limit int) ([]models.Tag, error) {
+	if !r.IsConnected() {
+		return nil, sql.ErrConnDone
+	}
+
+	pattern := escapeLike(prefix) + "%"
+	return r.list(`
+		SELECT `+tagSelectColumns+`
+		FROM tags t
+		WHERE t.usage_count > 0 AND (t.slug LIKE $1 OR t.name ILIKE $1)
+		ORDER BY t.usage_count DESC, t.name
+		LIMIT $2`, pattern, limit)
+}
+
+// Trending returns the tags used most on public posts and active products
+// created since the given time.
+func (r *TagRepository) Trending(since time.Time, limit int) ([]models.Tag, error) {
+	if !r.IsConnected() {
+		return nil, sql.ErrConnDone
+	}
+
+	return r.list(`
+		SELECT `+tagSelectColumns+`
+		FROM tags t
+		JOIN (
+			SELECT pt.tag_id FROM post_tags pt
+			JOIN posts p ON p.id = pt.post_id
+			WHERE p.created_at >= $1 AND p.visibility = 'public'
+			UNION ALL
+			SELECT pt.tag_id FROM product_tags pt
+			JOIN products p ON p.id = pt.product_id
+			WHERE p.created_at >= $1 AND p.status = 'active'
+		) recent ON recent.tag_id = t.id
+		GROUP BY t.id
+		ORDER BY COUNT(*) DESC, t.usage_count DESC, t.name
+		LIMIT $2`, since, limit)
+}
+
+func (r *TagRepository) list(query string, args ...interface{}) ([]models.Tag, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []models.Tag{}
+	for rows.Next() {
+		var tag models.Tag
+		if err := rows.Scan(&tag.ID, &tag.Name, &tag.Slug, &tag.UsageCount, &tag.CreatedAt); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+
+	return tags, rows.Err()
+}
+
+// detachTags removes every tag link of an item inside tx and returns the IDs
+// of the tags it was linked to, so their usage can be recounted once the
+// item is gone.
+func detachTags(tx *sql.Tx, taggableType, itemID string) ([]string, error) {
+	link := taggableTables[taggableType]
+	return queryIDs(tx, `DELETE FROM `+link.table+` WHERE `+link.column+` = $1 RETURNING tag_id`, itemID)
+}
+
+// recountTagUsage recomputes usage_count for the given tags from the link
+// tables.
+func recountTagUsage(tx *sql.Tx, tagIDs []string) error {
+	if len(tagIDs) == 0 {
+		return nil
+	}
+
+	_, err := tx.Exec(`
+		UPDATE tags t SET usage_count =
+			(SELECT COUNT(*) FROM post_tags WHERE tag_id = t.id) +
+			(SELECT COUNT(*) FROM product_tags WHERE tag_id = t.id)
+		WHERE t.id = ANY($1::uuid[])`, pq.Array(tagIDs))
+	return err
+}
+
+func queryIDs(tx *sql.Tx, query string, args ...interface{}) ([]string, error) {
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
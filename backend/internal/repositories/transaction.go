@@ -0,0 +1,376 @@
+package repositories
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+	"viport-backend/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrDuplicatePurchase = errors.New("item already purchased")
+	ErrStatusConflict    = errors.New("transaction is not in the expected status")
+	ErrDuplicateEvent    = errors.New("payment event already received")
+	ErrInvalidTransition = errors.New("payment status transition not allowed")
+)
+
+const transactionSelectColumns = `
+	t.id, t.buyer_id, t.seller_id, t.item_type, t.item_id, t.amount, t.fee_amount,
+	t.net_amount, t.currency, t.payment_method, t.payment_status, t.transaction_id,
+	t.refunded_amount, t.fee_rule_id, t.created_at`
+
+type TransactionRepository struct {
+	db *sql.DB
+}
+
+func NewTransactionRepository(db *sql.DB) *TransactionRepository {
+	return &TransactionRepository{db: db}
+}
+
+func (r *TransactionRepository) IsConnected() bool {
+	return r.db != nil
+}
+
+// Create records a new transaction. It returns ErrDuplicatePurchase when the
+// buyer already has a pending or completed purchase of the same item.
+func (r *TransactionRepository) Create(transaction *models.Transaction) error {
+	if !r.IsConnected() {
+		return sql.ErrConnDone
+	}
+
+	transaction.ID = uuid.New().String()
+	transaction.CreatedAt = time.Now()
+
+	_, err := r.db.Exec(`
+		INSERT INTO transactions (
+			id, buyer_id, seller_id, item_type, item_id, amount, fee_amount, net_amount,
+			currency, payment_method, payment_status, transaction_id, fee_rule_id, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)`,
+		transaction.ID, transaction.BuyerID, transaction.SellerID, transaction.ItemType,
+		transaction.ItemID, transaction.Amount, transaction.FeeAmount, transaction.NetAmount,
+		transaction.Currency, transaction.PaymentMethod, transaction.PaymentStatus,
+		transaction.TransactionID, transaction.FeeRuleID, transaction.CreatedAt,
+	)
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code.Name() == "unique_violation" {
+		return ErrDuplicatePurchase
+	}
+	return err
+}
+
+func (r *TransactionRepository) GetByID(id string) (*models.Transaction, error) {
+	if !r.IsConnected() {
+		return nil, sql.ErrConnDone
+	}
+	if !isUUID(id) {
+		return nil, sql.ErrNoRows
+	}
+
+	return scanTransaction(r.db.QueryRow(`SELECT `+transactionSelectColumns+` FROM transactions t WHERE t.id = $1`, id))
+}
+
+// UpdateStatus moves a transaction from one payment status to another,
+// records the provider's reference when given and applies the effects of
+// the new status. It returns ErrStatusConflict when the transaction is no
+// longer in status from, so concurrent updates cannot both apply.
+func (r *TransactionRepository) UpdateStatus(id, from, to string, reference *string) error {
+	if !r.IsConnected() {
+		return sql.ErrConnDone
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	transaction, err := scanTransaction(tx.QueryRow(`
+		UPDATE transactions t
+		SET payment_status = $3, transaction_id = COALESCE($4, transaction_id)
+		WHERE t.id = $1 AND t.payment_status = $2
+		RETURNING `+transactionSelectColumns, id, from, to, reference))
+	if err == sql.ErrNoRows {
+		return ErrStatusConflict
+	} else if err != nil {
+		return err
+	}
+
+	if err := applyStatusEffects(tx, transaction); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ApplyEvent records a payment webhook event and moves its transaction to
+// status to when allowed(current, to) permits it, applying the effects of
+// the new status in the same database transaction. Events are recorded once:
+// a redelivered event returns ErrDuplicateEvent. A disallowed transition is
+// recorded as ignored and returns ErrInvalidTransition; an event for a
+// transaction already in status to changes nothing. Unknown transactions
+// return sql.ErrNoRows and are not recorded.
+func (r *TransactionRepository) ApplyEvent(event models.PaymentEvent, to string, allowed func(from, to string) bool) (*models.Transaction, error) {
+	if !r.IsConnected() {
+		return nil, sql.ErrConnDone
+	}
+	if !isUUID(event.Data.TransactionID) {
+		return nil, sql.ErrNoRows
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var seen bool
+	if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM payment_events WHERE id = $1)`, event.ID).Scan(&seen); err != nil {
+		return nil, err
+	}
+	if seen {
+		return nil, ErrDuplicateEvent
+	}
+
+	transaction, err := scanTransaction(tx.QueryRow(`
+		SELECT `+transactionSelectColumns+` FROM transactions t
+		WHERE t.id = $1 FOR UPDATE`, event.Data.TransactionID))
+	if err != nil {
+		return nil, err
+	}
+
+	outcome := "applied"
+	var result error
+	if transaction.PaymentStatus == to || !allowed(transaction.PaymentStatus, to) {
+		outcome = "ignored"
+		result = ErrInvalidTransition
+	} else {
+		_, err := tx.Exec(`UPDATE transactions SET payment_status = $2 WHERE id = $1`, transaction.ID, to)
+		if err != nil {
+			return nil, err
+		}
+		transaction.PaymentStatus = to
+		if err := applyStatusEffects(tx, transaction); err != nil {
+			return nil, err
+		}
+	}
+
+	// A concurrent delivery of the same event loses on the primary key
+	_, err = tx.Exec(`
+		INSERT INTO payment_events (id, type, transaction_id, outcome)
+		VALUES ($1, $2, $3, $4)`, event.ID, event.Type, transaction.ID, outcome)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code.Name() == "unique_violation" {
+			return nil, ErrDuplicateEvent
+		}
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return transaction, result
+}
+
+// applyStatusEffects grants or revokes what the buyer paid for once a
+// transaction reaches a new status, and notifies the people involved.
+// Product access is derived from completed transactions, so products only
+// need the notifications; courses also get their enrollment. Sales and
+// refunds are posted to the ledger.
+func applyStatusEffects(tx *sql.Tx, transaction *models.Transaction) error {
+	switch transaction.PaymentStatus {
+	case "completed":
+		if transaction.ItemType == "course" {
+			if _, err := enroll(tx, transaction.BuyerID, transaction.ItemID); err != nil {
+				return err
+			}
+		}
+		if err := postSale(tx, transaction); err != nil {
+			return err
+		}
+		if err := notify(tx, transaction.BuyerID, "purchase_completed", "Purchase complete", transaction); err != nil {
+			return err
+		}
+		return notify(tx, transaction.SellerID, "sale", "You made a sale", transaction)
+
+	case "refunded":
+		// Whatever was not refunded through a refund record yet is returned now
+		_, err := tx.Exec(`
+			UPDATE transactions SET refunded_amount = amount, fee_amount = 0, net_amount = 0
+			WHERE id = $1`, transaction.ID)
+		if err != nil {
+			return err
+		}
+		transaction.RefundedAmount = transaction.Amount
+		transaction.FeeAmount = 0
+		transaction.NetAmount = 0
+		if err := postRemainingRefund(tx, transaction); err != nil {
+			return err
+		}
+
+		if transaction.ItemType == "course" {
+			if err := unenroll(tx, transaction.BuyerID, transaction.ItemID); err != nil {
+				return err
+			}
+		}
+		return notify(tx, transaction.BuyerID, "purchase_refunded", "Purchase refunded", transaction)
+
+	case "failed":
+		return notify(tx, transaction.BuyerID, "payment_failed", "Payment failed", transaction)
+	}
+	return nil
+}
+
+func notify(tx *sql.Tx, userID, notificationType, title string, transaction *models.Transaction) error {
+	data, err := json.Marshal(map[string]interface{}{
+		"transactionId": transaction.ID,
+		"itemType":      transaction.ItemType,
+		"itemId":        transaction.ItemID,
+		"amount":        transaction.Amount,
+		"currency":      transaction.Currency,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`INSERT INTO notifications (user_id, type, title, data) VALUES ($1, $2, $3, $4)`,
+		userID, notificationType, title, data)
+	return err
+}
+
+// HasPurchased reports whether the buyer owns the item through a completed
+// transaction.
+func (r *TransactionRepository) HasPurchased(buyerID, itemType, itemID string) (bool, error) {
+	if !r.IsConnected() {
+		return false, sql.ErrConnDone
+	}
+	if !isUUID(buyerID) || !isUUID(itemID) {
+		return false, nil
+	}
+
+	var purchased bool
+	err := r.db.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM transactions
+			WHERE buyer_id = $1 AND item_type = $2 AND item_id = $3 AND payment_status = 'completed'
+		)`, buyerID, itemType, itemID).Scan(&purchased)
+	return purchased, err
+}
+
+// PurchasedIDs returns the subset of itemIDs the buyer owns through a
+// completed transaction.
+func (r *TransactionRepository) PurchasedIDs(buyerID, itemType string, itemIDs []string) (map[string]bool, error) {
+	purchased := make(map[string]bool)
+	if !r.IsConnected() {
+		return purchased, sql.ErrConnDone
+	}
+	if !isUUID(buyerID) || len(itemIDs) == 0 {
+		return purchased, nil
+	}
+
+	rows, err := r.db.Query(`
+		SELECT item_id FROM transactions
+		WHERE buyer_id = $1 AND item_type = $2 AND item_id = ANY($3::uuid[])
+			AND payment_status = 'completed'`,
+		buyerID, itemType, pq.Array(itemIDs))
+	if err != nil {
+		return purchased, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return purchased, err
+		}
+		purchased[id] = true
+	}
+
+	return purchased, rows.Err()
+}
+
+// ListPurchases returns a page of the buyer's completed purchases, newest
+// first, with the total count. An empty itemType lists every kind of item.
+func (r *TransactionRepository) ListPurchases(buyerID, itemType string, limit, offset int) ([]*models.Transaction, int, error) {
+	if !r.IsConnected() {
+		return nil, 0, sql.ErrConnDone
+	}
+	transactions := []*models.Transaction{}
+	if !isUUID(buyerID) {
+		return transactions, 0, nil
+	}
+
+	where := ` WHERE t.buyer_id = $1 AND t.payment_status = 'completed' AND ($2 = '' OR t.item_type = $2)`
+
+	var total int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM transactions t`+where, buyerID, itemType).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.db.Query(`
+		SELECT `+transactionSelectColumns+`
+		FROM transactions t`+where+`
+		ORDER BY t.created_at DESC, t.id DESC
+		LIMIT $3 OFFSET $4`, buyerID, itemType, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		transaction, err := scanTransaction(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		transactions = append(transactions, transaction)
+	}
+	return transactions, total, rows.Err()
+}
+
+// SellerStats fills the earnings and sales figures of a seller. Earnings are
+// the net amounts of completed sales after any refunds.
+func (r *TransactionRepository) SellerStats(sellerID string, monthStart time.Time) (*models.UserStatsResponse, error) {
+	stats := &models.UserStatsResponse{
+		PopularProducts: []any{},
+		RecentActivity:  []any{},
+	}
+	if !r.IsConnected() {
+		return nil, sql.ErrConnDone
+	}
+	if !isUUID(sellerID) {
+		return stats, nil
+	}
+
+	err := r.db.QueryRow(`
+		SELECT
+			COALESCE(SUM(net_amount), 0),
+			COALESCE(SUM(net_amount) FILTER (WHERE created_at >= $2), 0),
+			COUNT(*) FILTER (WHERE payment_status = 'completed')
+		FROM transactions
+		WHERE seller_id = $1 AND payment_status IN ('completed', 'refunded')`,
+		sellerID, monthStart).Scan(&stats.TotalEarnings, &stats.MonthlyEarnings, &stats.TotalSales)
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+func scanTransaction(row rowScanner) (*models.Transaction, error) {
+	transaction := &models.Transaction{}
+	err := row.Scan(
+		&transaction.ID, &transaction.BuyerID, &transaction.SellerID, &transaction.ItemType,
+		&transaction.ItemID, &transaction.Amount, &transaction.FeeAmount, &transaction.NetAmount,
+		&transaction.Currency, &transaction.PaymentMethod, &transaction.PaymentStatus,
+		&transaction.TransactionID, &transaction.RefundedAmount, &transaction.FeeRuleID,
+		&transaction.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return transaction, nil
+}
@@ -2,8 +2,10 @@ package repositories
 
 import (
 	"database/sql"
+	"fmt"
 	"time"
 	"viport-backend/internal/models"
+	"viport-backend/pkg/pagination"
 
 	"github.com/google/uuid"
 )
@@ -110,6 +112,9 @@ func (r *UserRepository) GetByID(id string) (*models.User, error) {
 	if !r.IsConnected() {
 		return nil, sql.ErrConnDone
 	}
+	if !isUUID(id) {
+		return nil, sql.ErrNoRows
+	}
 	
 	user := &models.User{}
 	query := `
@@ -134,6 +139,21 @@ func (r *UserRepository) GetByID(id string) (*models.User, error) {
 	return user, nil
 }
 
+// GetOwnerID confirms the user exists and returns its ID, which is its own
+// owner for authorization purposes.
+func (r *UserRepository) GetOwnerID(id string) (string, error) {
+	if !r.IsConnected() {
+		return "", sql.ErrConnDone
+	}
+	if !isUUID(id) {
+		return "", sql.ErrNoRows
+	}
+
+	var ownerID string
+	err := r.db.QueryRow(`SELECT id FROM users WHERE id = $1`, id).Scan(&ownerID)
+	return ownerID, err
+}
+
 func (r *UserRepository) Update(user *models.User) error {
 	if !r.IsConnected() {
 		return sql.ErrConnDone
@@ -158,25 +178,48 @@ func (r *UserRepository) Update(user *models.User) error {
 	return err
 }
 
-func (r *UserRepository) GetAll() ([]*models.User, error) {
+// List returns a page of users, newest first, along with the total number of
+// users. It fetches one row beyond limit so callers can tell whether another
+// page follows; with a cursor the offset is ignored.
+func (r *UserRepository) List(limit, offset int, cursor *pagination.Cursor) ([]*models.User, int, error) {
 	if !r.IsConnected() {
-		return nil, sql.ErrConnDone
+		return nil, 0, sql.ErrConnDone
 	}
-	
+
+	var total int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM users`).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	var args []interface{}
+	addArg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	keyset, orderBy := keysetClause("created_at", "timestamptz", "id", true, cursor, addArg)
+	where := ""
+	if keyset != "" {
+		where = "WHERE " + keyset
+		offset = 0
+	}
+
 	query := `
 		SELECT id, username, email, first_name, last_name,
 			   display_name, bio, avatar_url, cover_image_url, location, website_url,
 			   is_verified, is_creator, verification_level, account_type,
 			   created_at, updated_at, last_active_at
-		FROM users ORDER BY created_at DESC`
+		FROM users ` + where + `
+		ORDER BY ` + orderBy + `
+		LIMIT ` + addArg(limit+1) + ` OFFSET ` + addArg(offset)
 
-	rows, err := r.db.Query(query)
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	defer rows.Close()
 
-	var users []*models.User
+	users := []*models.User{}
 	for rows.Next() {
 		user := &models.User{}
 		err := rows.Scan(
@@ -187,10 +230,33 @@ func (r *UserRepository) GetAll() ([]*models.User, error) {
 			&user.CreatedAt, &user.UpdatedAt, &user.LastActiveAt,
 		)
 		if err != nil {
-			return nil, err
+			return nil, 0, err
 		}
 		users = append(users, user)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	reverseRows(users, cursor)
+	return users, total, nil
+}
+
+// LoadCounts fills in the follower, following, post and product counts shown
+// on profiles.
+func (r *UserRepository) LoadCounts(user *models.User) error {
+	if !r.IsConnected() {
+		return sql.ErrConnDone
+	}
 
-	return users, nil
-}
\ No newline at end of file
+	query := `
+		SELECT
+			(SELECT COUNT(*) FROM follows WHERE following_id = $1),
+			(SELECT COUNT(*) FROM follows WHERE follower_id = $1),
+			(SELECT COUNT(*) FROM posts WHERE user_id = $1),
+			(SELECT COUNT(*) FROM products WHERE user_id = $1)`
+
+	return r.db.QueryRow(query, user.ID).Scan(
+		&user.FollowerCount, &user.FollowingCount, &user.PostCount, &user.ProductCount,
+	)
+}
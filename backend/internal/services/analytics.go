@@ -0,0 +1,49 @@
+package services
+
+import (
+	"database/sql"
+	"viport-backend/internal/models"
+	"viport-backend/internal/repositories"
+)
+
+// AnalyticsService reports how a course performs to its instructor.
+type AnalyticsService struct {
+	analyticsRepo *repositories.AnalyticsRepository
+	reviewRepo    *repositories.ReviewRepository
+}
+
+func NewAnalyticsService(db *sql.DB) *AnalyticsService {
+	return &AnalyticsService{
+		analyticsRepo: repositories.NewAnalyticsRepository(db),
+		reviewRepo:    repositories.NewReviewRepository(db),
+	}
+}
+
+// Course gathers the analytics of a course over the range.
+func (s *AnalyticsService) Course(courseID string, window models.AnalyticsRange) (*models.CourseAnalytics, error) {
+	analytics := &models.CourseAnalytics{CourseID: courseID, Range: window}
+
+	var err error
+	if analytics.Overview, err = s.analyticsRepo.CourseOverview(courseID, window); err != nil {
+		return nil, err
+	}
+	if analytics.Enrollments, err = s.analyticsRepo.EnrollmentTrend(courseID, window); err != nil {
+		return nil, err
+	}
+	if analytics.Lessons, err = s.analyticsRepo.LessonFunnel(courseID); err != nil {
+		return nil, err
+	}
+	if analytics.Revenue, err = s.analyticsRepo.CourseRevenue(courseID, window); err != nil {
+		return nil, err
+	}
+	if analytics.RevenueTrend, err = s.analyticsRepo.RevenueTrend(courseID, window); err != nil {
+		return nil, err
+	}
+	if analytics.Ratings, err = s.reviewRepo.Summary("course", courseID); err != nil {
+		return nil, err
+	}
+	if analytics.RatingTrend, err = s.analyticsRepo.RatingTrend(courseID, window); err != nil {
+		return nil, err
+	}
+	return analytics, nil
+}
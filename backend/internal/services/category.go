@@ -0,0 +1,181 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"viport-backend/internal/models"
+	"viport-backend/internal/repositories"
+)
+
+var ErrInvalidSlug = errors.New("slug must contain letters or digits")
+
+// maxCategorySlugLength matches the width of the categories.slug column.
+const maxCategorySlugLength = 100
+
+// CategoryService assembles the flat categories table into the nested tree
+// served to the storefront and applies admin changes to it.
+type CategoryService struct {
+	categoryRepo *repositories.CategoryRepository
+}
+
+func NewCategoryService(db *sql.DB) *CategoryService {
+	return &CategoryService{
+		categoryRepo: repositories.NewCategoryRepository(db),
+	}
+}
+
+// Tree returns the top-level categories with their descendants nested in
+// Children. ItemCount of each category includes the items of its
+// descendants. Without includeInactive, inactive categories are left out
+// together with everything below them.
+func (s *CategoryService) Tree(includeInactive bool) ([]models.Category, error) {
+	categories, err := s.categoryRepo.List(includeInactive)
+	if err != nil {
+		return nil, err
+	}
+
+	roots, _ := buildCategoryTree(categories, "")
+	if roots == nil {
+		roots = []models.Category{}
+	}
+	return roots, nil
+}
+
+// GetBySlug returns the category with its parent and its subtree.
+func (s *CategoryService) GetBySlug(slug string, includeInactive bool) (*models.Category, error) {
+	category, err := s.categoryRepo.GetBySlug(strings.ToLower(slug))
+	if err != nil {
+		return nil, err
+	}
+	if !category.IsActive && !includeInactive {
+		return nil, sql.ErrNoRows
+	}
+
+	categories, err := s.categoryRepo.List(includeInactive)
+	if err != nil {
+		return nil, err
+	}
+
+	// A category below an inactive ancestor is not part of the public tree
+	byID := make(map[string]*models.Category, len(categories))
+	for _, c := range categories {
+		byID[c.ID] = c
+	}
+	for parentID := category.ParentID; parentID != nil; {
+		parent, ok := byID[*parentID]
+		if !ok {
+			return nil, sql.ErrNoRows
+		}
+		parentID = parent.ParentID
+	}
+
+	children, itemCount := buildCategoryTree(categories, category.ID)
+	category.Children = children
+	category.ItemCount += itemCount
+
+	if category.ParentID != nil {
+		parent := *byID[*category.ParentID]
+		category.Parent = &parent
+	}
+
+	return category, nil
+}
+
+func (s *CategoryService) Create(req models.CreateCategoryRequest) (*models.Category, error) {
+	slug := req.Name
+	if req.Slug != nil {
+		slug = *req.Slug
+	}
+
+	category := &models.Category{
+		Name:        strings.TrimSpace(req.Name),
+		Slug:        slugify(slug, maxCategorySlugLength),
+		Description: req.Description,
+		Icon:        req.Icon,
+		Color:       req.Color,
+		ParentID:    req.ParentID,
+		IsActive:    true,
+	}
+	if category.Slug == "" {
+		return nil, ErrInvalidSlug
+	}
+
+	if err := s.categoryRepo.Create(category); err != nil {
+		return nil, err
+	}
+	return category, nil
+}
+
+func (s *CategoryService) Update(id string, req models.UpdateCategoryRequest) (*models.Category, error) {
+	category, err := s.categoryRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		category.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Slug != nil {
+		category.Slug = slugify(*req.Slug, maxCategorySlugLength)
+		if category.Slug == "" {
+			return nil, ErrInvalidSlug
+		}
+	}
+	if req.Description != nil {
+		category.Description = req.Description
+	}
+	if req.Icon != nil {
+		category.Icon = req.Icon
+	}
+	if req.Color != nil {
+		category.Color = req.Color
+	}
+	if req.ParentID != nil {
+		category.ParentID = req.ParentID
+	}
+
+	if err := s.categoryRepo.Update(category); err != nil {
+		return nil, err
+	}
+	return category, nil
+}
+
+func (s *CategoryService) Reorder(positions []models.CategoryPosition) error {
+	return s.categoryRepo.Reorder(positions)
+}
+
+func (s *CategoryService) SetActive(id string, active bool) error {
+	return s.categoryRepo.SetActive(id, active)
+}
+
+// buildCategoryTree nests the categories below parentID ("" for the top
+// level), keeping the input order among siblings, and returns them with the
+// total item count of the subtree.
+func buildCategoryTree(categories []*models.Category, parentID string) ([]models.Category, int) {
+	children := make(map[string][]*models.Category)
+	for _, category := range categories {
+		key := ""
+		if category.ParentID != nil {
+			key = *category.ParentID
+		}
+		children[key] = append(children[key], category)
+	}
+
+	var build func(parentID string) ([]models.Category, int)
+	build = func(parentID string) ([]models.Category, int) {
+		var nodes []models.Category
+		total := 0
+		for _, category := range children[parentID] {
+			node := *category
+			subtree, count := build(node.ID)
+			node.Children = subtree
+			node.ItemCount += count
+			total += node.ItemCount
+			nodes = append(nodes, node)
+		}
+		return nodes, total
+	}
+
+	return build(parentID)
+}
@@ -0,0 +1,234 @@
+package services
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+	"viport-backend/internal/models"
+	"viport-backend/internal/repositories"
+	"viport-backend/pkg/pdf"
+	"viport-backend/pkg/storage"
+)
+
+var ErrCourseNotCompleted = errors.New("course not completed yet")
+
+// codeAlphabet is Crockford's base32, which leaves out letters easily
+// misread as digits.
+const codeAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// CertificateService issues course completion certificates as PDFs and
+// verifies them by their code.
+type CertificateService struct {
+	certificateRepo *repositories.CertificateRepository
+	enrollmentRepo  *repositories.EnrollmentRepository
+	courseRepo      *repositories.CourseRepository
+	userRepo        *repositories.UserRepository
+	storage         storage.Storage
+}
+
+func NewCertificateService(db *sql.DB, store storage.Storage) *CertificateService {
+	return &CertificateService{
+		certificateRepo: repositories.NewCertificateRepository(db),
+		enrollmentRepo:  repositories.NewEnrollmentRepository(db),
+		courseRepo:      repositories.NewCourseRepository(db),
+		userRepo:        repositories.NewUserRepository(db),
+		storage:         store,
+	}
+}
+
+// Issue returns the learner's certificate for a course, generating and
+// storing it on first request. It returns ErrNotEnrolled for learners who
+// are not enrolled and ErrCourseNotCompleted before every lesson is done.
+func (s *CertificateService) Issue(userID, courseID string) (*models.Certificate, error) {
+	certificate, err := s.certificateRepo.GetByUserCourse(userID, courseID)
+	if err != sql.ErrNoRows {
+		return certificate, err
+	}
+
+	enrollment, err := s.enrollmentRepo.Get(userID, courseID)
+	if err != nil {
+		return nil, err
+	}
+	if enrollment.CompletionDate == nil {
+		return nil, ErrCourseNotCompleted
+	}
+
+	course, err := s.courseRepo.GetByID(courseID)
+	if err != nil {
+		return nil, err
+	}
+	learner, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	code, err := newVerificationCode()
+	if err != nil {
+		return nil, err
+	}
+
+	certificate = &models.Certificate{
+		UserID:           userID,
+		CourseID:         courseID,
+		VerificationCode: code,
+		LearnerName:      personName(learner),
+		CourseTitle:      course.Title,
+		InstructorName:   personName(course.Instructor),
+		CompletedAt:      *enrollment.CompletionDate,
+	}
+
+	document, err := renderCertificate(certificate)
+	if err != nil {
+		return nil, err
+	}
+	if certificate.URL, err = s.storage.Put("certificates/"+code+".pdf", document, "application/pdf"); err != nil {
+		return nil, err
+	}
+
+	if err := s.certificateRepo.Create(certificate); err != nil {
+		// A concurrent request issued it first; its file is the one linked
+		if err == repositories.ErrCertificateExists {
+			return s.certificateRepo.GetByUserCourse(userID, courseID)
+		}
+		return nil, err
+	}
+	return certificate, nil
+}
+
+// IssueOnCompletion issues the certificate of a completed enrollment that
+// has none yet and links it from the enrollment.
+func (s *CertificateService) IssueOnCompletion(enrollment *models.Enrollment) error {
+	if enrollment.CompletionDate == nil || enrollment.CertificateURL != nil {
+		return nil
+	}
+
+	certificate, err := s.Issue(enrollment.UserID, enrollment.CourseID)
+	if err != nil {
+		return err
+	}
+	enrollment.CertificateURL = &certificate.URL
+	return nil
+}
+
+// Verify returns the certificate with the verification code, which is
+// matched regardless of case and dashes, or sql.ErrNoRows.
+func (s *CertificateService) Verify(code string) (*models.Certificate, error) {
+	normalized := strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	if len(normalized) != 16 || strings.Trim(normalized, codeAlphabet) != "" {
+		return nil, sql.ErrNoRows
+	}
+	return s.certificateRepo.GetByCode(formatVerificationCode(normalized))
+}
+
+// newVerificationCode returns a random code such as "7K2M-Q9XD-4HPA-WR3T",
+// 80 bits that cannot be guessed.
+func newVerificationCode() (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+
+	code := make([]byte, len(random))
+	for i, b := range random {
+		code[i] = codeAlphabet[b%32]
+	}
+	return formatVerificationCode(string(code)), nil
+}
+
+func formatVerificationCode(code string) string {
+	return code[0:4] + "-" + code[4:8] + "-" + code[8:12] + "-" + code[12:16]
+}
+
+// personName is how a user is named on a certificate: their display name,
+// else their first and last name, else their username. Names the PDF fonts
+// cannot draw, such as ones in non-Latin scripts, are passed over so the
+// certificate never shows a name as question marks.
+func personName(user *models.User) string {
+	if user == nil {
+		return ""
+	}
+
+	var names []string
+	if user.DisplayName != nil && strings.TrimSpace(*user.DisplayName) != "" {
+		names = append(names, strings.TrimSpace(*user.DisplayName))
+	}
+	var parts []string
+	for _, part := range []*string{user.FirstName, user.LastName} {
+		if part != nil && strings.TrimSpace(*part) != "" {
+			parts = append(parts, strings.TrimSpace(*part))
+		}
+	}
+	if len(parts) > 0 {
+		names = append(names, strings.Join(parts, " "))
+	}
+	names = append(names, user.Username)
+
+	for _, name := range names {
+		if pdf.CanEncode(name) {
+			return name
+		}
+	}
+	return names[0]
+}
+
+// renderCertificate lays the certificate out on a landscape A4 page.
+func renderCertificate(certificate *models.Certificate) ([]byte, error) {
+	document := pdf.NewDocument("Certificate of Completion - "+certificate.CourseTitle, time.Now())
+	page := document.AddPage(pdf.A4Width, pdf.A4Height)
+	center := page.Width() / 2
+
+	// Double border
+	page.SetStrokeColor(0.16, 0.24, 0.45)
+	page.SetLineWidth(4)
+	page.Rect(24, 24, page.Width()-48, page.Height()-48, false)
+	page.SetLineWidth(1)
+	page.Rect(34, 34, page.Width()-68, page.Height()-68, false)
+
+	page.SetFillColor(0.16, 0.24, 0.45)
+	page.CenteredText(center, 470, pdf.HelveticaBold, 34, "CERTIFICATE OF COMPLETION")
+
+	page.SetFillColor(0.3, 0.3, 0.3)
+	page.CenteredText(center, 410, pdf.Helvetica, 14, "This certifies that")
+
+	page.SetFillColor(0, 0, 0)
+	page.CenteredText(center, 365, pdf.HelveticaBold, fitText(pdf.HelveticaBold, 30, certificate.LearnerName, 680), certificate.LearnerName)
+
+	page.SetStrokeColor(0.6, 0.6, 0.6)
+	page.Line(center-220, 352, center+220, 352)
+
+	page.SetFillColor(0.3, 0.3, 0.3)
+	page.CenteredText(center, 320, pdf.Helvetica, 14, "has successfully completed the course")
+
+	page.SetFillColor(0.16, 0.24, 0.45)
+	page.CenteredText(center, 280, pdf.HelveticaBold, fitText(pdf.HelveticaBold, 24, certificate.CourseTitle, 700), certificate.CourseTitle)
+
+	page.SetFillColor(0.3, 0.3, 0.3)
+	page.CenteredText(center, 245, pdf.HelveticaOblique, 13, "taught by "+certificate.InstructorName)
+
+	// Date and verification details along the bottom
+	page.SetFillColor(0, 0, 0)
+	page.CenteredText(200, 150, pdf.Helvetica, 13, certificate.CompletedAt.UTC().Format("January 2, 2006"))
+	page.CenteredText(page.Width()-200, 150, pdf.Helvetica, 13, certificate.VerificationCode)
+	page.SetStrokeColor(0.6, 0.6, 0.6)
+	page.Line(110, 140, 290, 140)
+	page.Line(page.Width()-290, 140, page.Width()-110, 140)
+	page.SetFillColor(0.4, 0.4, 0.4)
+	page.CenteredText(200, 124, pdf.Helvetica, 10, "Date of completion")
+	page.CenteredText(page.Width()-200, 124, pdf.Helvetica, 10, "Verification code")
+
+	page.CenteredText(center, 70, pdf.Helvetica, 9,
+		"Verify this certificate at /api/certificates/"+certificate.VerificationCode+"/verify")
+
+	return document.Bytes()
+}
+
+// fitText returns the largest font size up to size at which text fits in
+// width.
+func fitText(font pdf.Font, size float64, text string, width float64) float64 {
+	if textWidth := pdf.TextWidth(font, size, text); textWidth > width {
+		return size * width / textWidth
+	}
+	return size
+}
@@ -0,0 +1,33 @@
+package services
+
+import (
+	"testing"
+	"viport-backend/internal/models"
+)
+
+func TestPersonName(t *testing.T) {
+	str := func(s string) *string { return &s }
+
+	tests := []struct {
+		name string
+		user *models.User
+		want string
+	}{
+		{"display name", &models.User{Username: "ada", DisplayName: str(" Ada Lovelace "), FirstName: str("Augusta")}, "Ada Lovelace"},
+		{"first and last name", &models.User{Username: "ada", DisplayName: str(" "), FirstName: str("Ada"), LastName: str("Lovelace")}, "Ada Lovelace"},
+		{"username", &models.User{Username: "ada"}, "ada"},
+		{"accented display name", &models.User{Username: "jose", DisplayName: str("José Müller")}, "José Müller"},
+		{"non-Latin display name", &models.User{Username: "bruce", DisplayName: str("李小龙"), FirstName: str("Bruce"), LastName: str("Lee")}, "Bruce Lee"},
+		{"non-Latin names", &models.User{Username: "ivan", DisplayName: str("Иван"), FirstName: str("Иван")}, "ivan"},
+		{"nothing drawable", &models.User{Username: "李", DisplayName: str("李小龙")}, "李小龙"},
+		{"no user", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := personName(tt.user); got != tt.want {
+				t.Errorf("personName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+	"viport-backend/internal/models"
+	"viport-backend/internal/repositories"
+)
+
+var (
+	ErrSelfPurchase     = errors.New("sellers cannot buy their own items")
+	ErrAlreadyPurchased = errors.New("item already purchased")
+	ErrPaymentProvider  = errors.New("payment provider error")
+)
+
+// defaultCurrency is the currency every listed price is in.
+const defaultCurrency = "USD"
+
+// CheckoutService sells items to buyers: it prices the purchase from the
+// stored item, records a pending transaction, charges the buyer through the
+// PaymentProvider and settles the transaction with the outcome.
+type CheckoutService struct {
+	transactionRepo *repositories.TransactionRepository
+	productRepo     *repositories.ProductRepository
+	courseRepo      *repositories.CourseRepository
+	provider        PaymentProvider
+	fees            *FeeService
+}
+
+func NewCheckoutService(db *sql.DB, provider PaymentProvider) *CheckoutService {
+	return &CheckoutService{
+		transactionRepo: repositories.NewTransactionRepository(db),
+		productRepo:     repositories.NewProductRepository(db),
+		courseRepo:      repositories.NewCourseRepository(db),
+		provider:        provider,
+		fees:            NewFeeService(db),
+	}
+}
+
+// purchasable is the part of a sellable item checkout needs.
+type purchasable struct {
+	Type        string
+	ID          string
+	SellerID    string
+	Title       string
+	Price       float64
+	CategoryID  *string
+	LicenseType string
+}
+
+// PurchaseProduct buys an active product for the buyer. The returned
+// transaction is completed, pending (the provider settles asynchronously) or
+// failed (the payment was declined, see FailureReason). It returns
+// sql.ErrNoRows for products that do not exist or are not on sale,
+// ErrSelfPurchase for the seller's own products and ErrAlreadyPurchased when
+// the buyer already owns the product or has a payment for it in flight.
+func (s *CheckoutService) PurchaseProduct(buyerID, productID, paymentMethod string) (*models.Transaction, error) {
+	product, err := s.productRepo.GetByID(productID)
+	if err != nil {
+		return nil, err
+	}
+	if product.Status != "active" {
+		return nil, sql.ErrNoRows
+	}
+
+	price := product.Price
+	if product.IsFree {
+		price = 0
+	}
+
+	return s.purchase(buyerID, purchasable{
+		Type:        "product",
+		ID:          product.ID,
+		SellerID:    product.UserID,
+		Title:       product.Title,
+		Price:       price,
+		CategoryID:  product.CategoryID,
+		LicenseType: product.LicenseType,
+	}, paymentMethod)
+}
+
+// PurchaseCourse buys a published course for the buyer, who is enrolled
+// once the transaction completes. It reports outcomes and errors like
+// PurchaseProduct.
+func (s *CheckoutService) PurchaseCourse(buyerID, courseID, paymentMethod string) (*models.Transaction, error) {
+	course, err := s.courseRepo.GetByID(courseID)
+	if err != nil {
+		return nil, err
+	}
+	if course.Status != "published" {
+		return nil, sql.ErrNoRows
+	}
+
+	price := course.Price
+	if course.IsFree {
+		price = 0
+	}
+
+	return s.purchase(buyerID, purchasable{
+		Type:       "course",
+		ID:         course.ID,
+		SellerID:   course.InstructorID,
+		Title:      course.Title,
+		Price:      price,
+		CategoryID: course.CategoryID,
+	}, paymentMethod)
+}
+
+func (s *CheckoutService) purchase(buyerID string, item purchasable, paymentMethod string) (*models.Transaction, error) {
+	if item.SellerID == buyerID {
+		return nil, ErrSelfPurchase
+	}
+
+	amount := toMinorUnits(item.Price)
+	fee, rule, err := s.fees.Quote(repositories.FeeCriteria{
+		CategoryID:  item.CategoryID,
+		LicenseType: item.LicenseType,
+		SellerID:    item.SellerID,
+	}, amount, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	transaction := &models.Transaction{
+		BuyerID:       buyerID,
+		SellerID:      item.SellerID,
+		ItemType:      item.Type,
+		ItemID:        item.ID,
+		Amount:        fromMinorUnits(amount),
+		FeeAmount:     fromMinorUnits(fee),
+		NetAmount:     fromMinorUnits(amount - fee),
+		Currency:      defaultCurrency,
+		PaymentMethod: &paymentMethod,
+		PaymentStatus: PaymentPending,
+	}
+	if rule != nil {
+		transaction.FeeRuleID = &rule.ID
+	}
+
+	if amount == 0 {
+		method := "free"
+		transaction.PaymentMethod = &method
+	}
+
+	if err := s.transactionRepo.Create(transaction); err != nil {
+		if err == repositories.ErrDuplicatePurchase {
+			return nil, ErrAlreadyPurchased
+		}
+		return nil, err
+	}
+
+	// Free items need no payment and are owned immediately. Completing them
+	// like paid ones enrolls course buyers and sends the notifications.
+	if amount == 0 {
+		if err := s.transactionRepo.UpdateStatus(transaction.ID, PaymentPending, PaymentCompleted, nil); err != nil {
+			if updateErr := s.transactionRepo.UpdateStatus(transaction.ID, PaymentPending, PaymentFailed, nil); updateErr != nil {
+				return nil, fmt.Errorf("%w (marking transaction failed: %v)", err, updateErr)
+			}
+			return nil, err
+		}
+		transaction.PaymentStatus = PaymentCompleted
+		return transaction, nil
+	}
+
+	result, err := s.provider.Charge(PaymentRequest{
+		TransactionID: transaction.ID,
+		BuyerID:       buyerID,
+		Amount:        amount,
+		Currency:      transaction.Currency,
+		PaymentMethod: paymentMethod,
+		Description:   item.Title,
+	})
+	if err != nil {
+		// Release the item so the buyer can try again
+		if updateErr := s.transactionRepo.UpdateStatus(transaction.ID, PaymentPending, PaymentFailed, nil); updateErr != nil {
+			return nil, fmt.Errorf("%w: %v (marking transaction failed: %v)", ErrPaymentProvider, err, updateErr)
+		}
+		return nil, fmt.Errorf("%w: %v", ErrPaymentProvider, err)
+	}
+
+	reference := s.provider.Name() + ":" + result.Reference
+	transaction.TransactionID = &reference
+	if err := s.transactionRepo.UpdateStatus(transaction.ID, PaymentPending, result.Status, &reference); err != nil {
+		return nil, err
+	}
+	transaction.PaymentStatus = result.Status
+	transaction.FailureReason = result.FailureReason
+
+	return transaction, nil
+}
+
+// toMinorUnits converts a decimal price to cents.
+func toMinorUnits(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+func fromMinorUnits(amount int64) float64 {
+	return float64(amount) / 100
+}
@@ -0,0 +1,177 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+	"viport-backend/internal/models"
+	"viport-backend/internal/repositories"
+	"viport-backend/pkg/downloads"
+	"viport-backend/pkg/storage"
+)
+
+var ErrNotPurchased = errors.New("product has not been purchased")
+
+// DefaultDownloadLimit is how many downloads a buyer gets of a product
+// that does not set its own limit.
+const DefaultDownloadLimit = 10
+
+// downloadLinkTTL is how long a signed download link stays valid.
+const downloadLinkTTL = 15 * time.Minute
+
+// downloadPath is the route signed download tokens are served under.
+const downloadPath = "/api/downloads/"
+
+// DownloadService hands out short-lived signed links to the files of
+// products and opens them for the product's buyers and owner, counting
+// each buyer download against the product's download limit. The stored
+// file URLs never leave the server.
+type DownloadService struct {
+	productRepo     *repositories.ProductRepository
+	transactionRepo *repositories.TransactionRepository
+	downloadRepo    *repositories.DownloadRepository
+	signer          *downloads.Signer
+	files           *storage.Fetcher
+}
+
+func NewDownloadService(db *sql.DB, signer *downloads.Signer, files *storage.Fetcher) *DownloadService {
+	return &DownloadService{
+		productRepo:     repositories.NewProductRepository(db),
+		transactionRepo: repositories.NewTransactionRepository(db),
+		downloadRepo:    repositories.NewDownloadRepository(db),
+		signer:          signer,
+		files:           files,
+	}
+}
+
+// DownloadFile is a product file opened for download. The caller must
+// close Body.
+type DownloadFile struct {
+	Name string
+	*storage.File
+}
+
+// Links signs a download link for every file of the product. Only the
+// product's owner and users with a completed purchase get links; others get
+// ErrNotPurchased.
+func (s *DownloadService) Links(userID, productID string, now time.Time) (*models.ProductDownloads, error) {
+	product, err := s.productRepo.GetByID(productID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkAccess(userID, product); err != nil {
+		return nil, err
+	}
+
+	files, err := s.productRepo.GetFiles(product.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := now.Add(downloadLinkTTL)
+	result := &models.ProductDownloads{
+		ProductID: product.ID,
+		Files:     make([]models.DownloadLink, 0, len(files)),
+		ExpiresAt: expiresAt,
+	}
+	for i, file := range files {
+		token := s.signer.Sign(downloads.Grant{
+			ProductID: product.ID,
+			FileIndex: i,
+			UserID:    userID,
+			ExpiresAt: expiresAt.Unix(),
+		})
+		result.Files = append(result.Files, models.DownloadLink{
+			Index:       i,
+			Name:        file.Name,
+			Size:        file.Size,
+			Format:      file.Format,
+			Description: file.Description,
+			URL:         downloadPath + token,
+		})
+	}
+
+	if product.UserID != userID {
+		result.DownloadLimit = downloadLimit(product)
+		result.DownloadsUsed, err = s.downloadRepo.CountByBuyer(product.ID, userID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// Resolve verifies a download token and opens the file it grants for
+// streaming. Access is checked again, so refunded buyers lose their links,
+// and buyer downloads are counted once the file is open;
+// ErrDownloadLimitReached is returned once the limit is used up. Invalid
+// tokens return downloads.ErrInvalidLink or downloads.ErrLinkExpired, and
+// files that cannot be opened storage.ErrFileNotFound or
+// storage.ErrFileUnavailable.
+func (s *DownloadService) Resolve(token string, now time.Time) (*DownloadFile, error) {
+	grant, err := s.signer.Verify(token, now)
+	if err != nil {
+		return nil, err
+	}
+
+	product, err := s.productRepo.GetByID(grant.ProductID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkAccess(grant.UserID, product); err != nil {
+		return nil, err
+	}
+
+	files, err := s.productRepo.GetFiles(product.ID)
+	if err != nil {
+		return nil, err
+	}
+	if grant.FileIndex < 0 || grant.FileIndex >= len(files) {
+		return nil, sql.ErrNoRows
+	}
+	if product.UserID != grant.UserID {
+		// Refuse before fetching when the limit is already used up
+		used, err := s.downloadRepo.CountByBuyer(product.ID, grant.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if used >= downloadLimit(product) {
+			return nil, repositories.ErrDownloadLimitReached
+		}
+	}
+
+	file, err := s.files.Open(files[grant.FileIndex].URL)
+	if err != nil {
+		return nil, err
+	}
+
+	if product.UserID != grant.UserID {
+		err := s.downloadRepo.Record(product.ID, grant.UserID, grant.FileIndex, downloadLimit(product))
+		if err != nil {
+			file.Body.Close()
+			return nil, err
+		}
+	}
+	return &DownloadFile{Name: files[grant.FileIndex].Name, File: file}, nil
+}
+
+func (s *DownloadService) checkAccess(userID string, product *models.Product) error {
+	if product.UserID == userID {
+		return nil
+	}
+	purchased, err := s.transactionRepo.HasPurchased(userID, "product", product.ID)
+	if err != nil {
+		return err
+	}
+	if !purchased {
+		return ErrNotPurchased
+	}
+	return nil
+}
+
+func downloadLimit(product *models.Product) int {
+	if product.DownloadLimit != nil {
+		return *product.DownloadLimit
+	}
+	return DefaultDownloadLimit
+}
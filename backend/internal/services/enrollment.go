@@ -0,0 +1,202 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"viport-backend/internal/models"
+	"viport-backend/internal/repositories"
+)
+
+var (
+	ErrPurchaseRequired = errors.New("paid courses are enrolled in by purchasing them")
+	ErrOwnCourse        = errors.New("instructors cannot enroll in their own courses")
+)
+
+// EnrollmentService enrolls students in courses, gates lesson content on
+// enrollment and tracks their progress through the lessons.
+type EnrollmentService struct {
+	enrollmentRepo *repositories.EnrollmentRepository
+	courseRepo     *repositories.CourseRepository
+}
+
+func NewEnrollmentService(db *sql.DB) *EnrollmentService {
+	return &EnrollmentService{
+		enrollmentRepo: repositories.NewEnrollmentRepository(db),
+		courseRepo:     repositories.NewCourseRepository(db),
+	}
+}
+
+// Enroll enrolls the user in a free published course. Paid courses return
+// ErrPurchaseRequired; buying one enrolls the buyer once payment completes.
+func (s *EnrollmentService) Enroll(userID, courseID string) (*models.Enrollment, error) {
+	course, err := s.courseRepo.GetByID(courseID)
+	if err != nil {
+		return nil, err
+	}
+	if course.Status != "published" {
+		return nil, sql.ErrNoRows
+	}
+	if course.InstructorID == userID {
+		return nil, ErrOwnCourse
+	}
+	if !course.IsFree && course.Price > 0 {
+		return nil, ErrPurchaseRequired
+	}
+
+	return s.enrollmentRepo.Enroll(userID, courseID)
+}
+
+// Outline marks which lessons the viewer may open, and which they completed,
+// and strips the content, video and resources of the others. Instructors
+// open every lesson of their course, enrolled students every lesson and
+// everyone else only previews. The viewer's enrollment is returned when
+// they have one.
+func (s *EnrollmentService) Outline(viewerID string, course *models.Course, lessons []models.Lesson) ([]models.Lesson, *models.Enrollment, error) {
+	enrollment, err := s.enrollment(viewerID, course.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	completed := map[string]bool{}
+	if enrollment != nil {
+		if completed, err = s.enrollmentRepo.CompletedLessonIDs(viewerID, course.ID); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	full := enrollment != nil || course.InstructorID == viewerID
+	for i := range lessons {
+		lesson := &lessons[i]
+		lesson.IsCompleted = completed[lesson.ID]
+		lesson.IsAccessible = full || lesson.IsPreview
+		if !lesson.IsAccessible {
+			lesson.Content = nil
+			lesson.VideoURL = nil
+			lesson.Resources = nil
+		}
+	}
+	return lessons, enrollment, nil
+}
+
+// RecordAccess remembers the lesson as where an enrolled viewer left off.
+func (s *EnrollmentService) RecordAccess(viewerID, courseID, lessonID string) error {
+	if viewerID == "" || !s.enrollmentRepo.IsConnected() {
+		return nil
+	}
+	return s.enrollmentRepo.RecordAccess(viewerID, courseID, lessonID)
+}
+
+// CompleteLesson marks a lesson completed, or not, for an enrolled student
+// and returns their enrollment with the recomputed progress.
+func (s *EnrollmentService) CompleteLesson(userID, courseID, lessonID string, completed bool) (*models.Enrollment, error) {
+	return s.enrollmentRepo.SetLessonCompleted(userID, courseID, lessonID, completed)
+}
+
+// Resume returns the lesson an enrolled student should open next: the one
+// they last opened unless they completed it, then the first lesson after it
+// they have not completed, wrapping around to the start of the course.
+func (s *EnrollmentService) Resume(userID, courseID string) (*models.CourseResume, error) {
+	enrollment, err := s.enrollmentRepo.Get(userID, courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	lessons, err := s.courseRepo.ListLessons(courseID)
+	if err != nil {
+		return nil, err
+	}
+	completed, err := s.enrollmentRepo.CompletedLessonIDs(userID, courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	resume := &models.CourseResume{Enrollment: enrollment}
+	if len(lessons) == 0 {
+		return resume, nil
+	}
+
+	start := 0
+	if enrollment.LastAccessedLessonID != nil {
+		for i, lesson := range lessons {
+			if lesson.ID == *enrollment.LastAccessedLessonID {
+				start = i
+				break
+			}
+		}
+	}
+
+	// With every lesson completed, go back to the last one opened
+	next := start
+	for i := range lessons {
+		if candidate := (start + i) % len(lessons); !completed[lessons[candidate].ID] {
+			next = candidate
+			break
+		}
+	}
+
+	lesson := lessons[next]
+	lesson.IsCompleted = completed[lesson.ID]
+	lesson.IsAccessible = true
+	resume.Lesson = &lesson
+	return resume, nil
+}
+
+// MyCourses returns a page of the user's enrollments with their courses,
+// most recently studied first, and the total count.
+func (s *EnrollmentService) MyCourses(userID string, limit, offset int) ([]*models.Enrollment, int, error) {
+	enrollments, total, err := s.enrollmentRepo.ListByUser(userID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ids := make([]string, len(enrollments))
+	for i, enrollment := range enrollments {
+		ids[i] = enrollment.CourseID
+	}
+	courses, err := s.courseRepo.GetByIDs(ids)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for _, enrollment := range enrollments {
+		if enrollment.Course = courses[enrollment.CourseID]; enrollment.Course != nil {
+			enrollment.Course.IsEnrolled = true
+			enrollment.Course.Progress = enrollment.ProgressPercentage
+		}
+	}
+	return enrollments, total, nil
+}
+
+// MarkCourses sets IsEnrolled and Progress on each course the viewer is
+// enrolled in.
+func (s *EnrollmentService) MarkCourses(viewerID string, courses []*models.Course) error {
+	if viewerID == "" || len(courses) == 0 || !s.enrollmentRepo.IsConnected() {
+		return nil
+	}
+
+	ids := make([]string, len(courses))
+	for i, course := range courses {
+		ids[i] = course.ID
+	}
+
+	progress, err := s.enrollmentRepo.ProgressByCourse(viewerID, ids)
+	if err != nil {
+		return err
+	}
+	for _, course := range courses {
+		course.Progress, course.IsEnrolled = progress[course.ID]
+	}
+	return nil
+}
+
+// enrollment returns the viewer's enrollment in the course, or nil.
+func (s *EnrollmentService) enrollment(viewerID, courseID string) (*models.Enrollment, error) {
+	if viewerID == "" || !s.enrollmentRepo.IsConnected() {
+		return nil, nil
+	}
+	enrollment, err := s.enrollmentRepo.Get(viewerID, courseID)
+	if err == repositories.ErrNotEnrolled {
+		return nil, nil
+	}
+	return enrollment, err
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"math"
+	"time"
+	"viport-backend/internal/models"
+	"viport-backend/internal/repositories"
+)
+
+var ErrInvalidFeeWindow = errors.New("fee rule must end after it starts")
+
+// FeeService prices platform fees from the admin-managed fee rules.
+type FeeService struct {
+	feeRuleRepo *repositories.FeeRuleRepository
+}
+
+func NewFeeService(db *sql.DB) *FeeService {
+	return &FeeService{
+		feeRuleRepo: repositories.NewFeeRuleRepository(db),
+	}
+}
+
+// Quote returns the fee in minor units on a sale of amount minor units and
+// the rule that priced it. Sales no rule matches carry no fee and a nil
+// rule.
+func (s *FeeService) Quote(criteria repositories.FeeCriteria, amount int64, at time.Time) (int64, *models.FeeRule, error) {
+	if amount <= 0 {
+		return 0, nil, nil
+	}
+
+	rule, err := s.feeRuleRepo.Match(criteria, at)
+	if err == sql.ErrNoRows {
+		return 0, nil, nil
+	} else if err != nil {
+		return 0, nil, err
+	}
+	return computeFee(rule, amount), rule, nil
+}
+
+// computeFee applies a rule to amount minor units, rounding the percentage
+// down in the seller's favour and capping the fee at the amount.
+func computeFee(rule *models.FeeRule, amount int64) int64 {
+	basisPoints := int64(math.Round(rule.Percentage * 100))
+	fee := amount*basisPoints/10000 + toMinorUnits(rule.FixedAmount)
+	if fee > amount {
+		return amount
+	}
+	return fee
+}
+
+func (s *FeeService) List() ([]*models.FeeRule, error) {
+	return s.feeRuleRepo.List()
+}
+
+func (s *FeeService) Create(req models.FeeRuleRequest) (*models.FeeRule, error) {
+	rule := &models.FeeRule{IsActive: true}
+	if err := applyFeeRuleRequest(rule, req); err != nil {
+		return nil, err
+	}
+	if err := s.feeRuleRepo.Create(rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// Update replaces the fields of a fee rule. IsActive is kept when the
+// request leaves it out.
+func (s *FeeService) Update(id string, req models.FeeRuleRequest) (*models.FeeRule, error) {
+	rule, err := s.feeRuleRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyFeeRuleRequest(rule, req); err != nil {
+		return nil, err
+	}
+	if err := s.feeRuleRepo.Update(rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+func applyFeeRuleRequest(rule *models.FeeRule, req models.FeeRuleRequest) error {
+	if req.StartsAt != nil && req.EndsAt != nil && !req.EndsAt.After(*req.StartsAt) {
+		return ErrInvalidFeeWindow
+	}
+
+	rule.Name = req.Name
+	rule.Description = req.Description
+	rule.CategoryID = req.CategoryID
+	rule.LicenseType = req.LicenseType
+	rule.VerificationLevel = req.VerificationLevel
+	rule.Percentage = req.Percentage
+	rule.FixedAmount = req.FixedAmount
+	rule.Priority = req.Priority
+	rule.StartsAt = req.StartsAt
+	rule.EndsAt = req.EndsAt
+	if req.IsActive != nil {
+		rule.IsActive = *req.IsActive
+	}
+	return nil
+}
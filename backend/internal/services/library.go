@@ -0,0 +1,119 @@
+package services
+
+import (
+	"database/sql"
+	"viport-backend/internal/models"
+	"viport-backend/internal/repositories"
+)
+
+// LibraryService lists what buyers own and resolves the viewer's
+// IsPurchased flags on read paths.
+type LibraryService struct {
+	transactionRepo *repositories.TransactionRepository
+	productRepo     *repositories.ProductRepository
+	courseRepo      *repositories.CourseRepository
+}
+
+func NewLibraryService(db *sql.DB) *LibraryService {
+	return &LibraryService{
+		transactionRepo: repositories.NewTransactionRepository(db),
+		productRepo:     repositories.NewProductRepository(db),
+		courseRepo:      repositories.NewCourseRepository(db),
+	}
+}
+
+// Library returns a page of the buyer's completed purchases with the items
+// bought, newest first, and the total count. itemType narrows it to
+// products or courses.
+func (s *LibraryService) Library(buyerID, itemType string, limit, offset int) ([]*models.LibraryItem, int, error) {
+	transactions, total, err := s.transactionRepo.ListPurchases(buyerID, itemType, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var productIDs, courseIDs []string
+	for _, transaction := range transactions {
+		switch transaction.ItemType {
+		case "product":
+			productIDs = append(productIDs, transaction.ItemID)
+		case "course":
+			courseIDs = append(courseIDs, transaction.ItemID)
+		}
+	}
+
+	products, err := s.productRepo.GetByIDs(productIDs)
+	if err != nil {
+		return nil, 0, err
+	}
+	courses, err := s.courseRepo.GetByIDs(courseIDs)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	items := make([]*models.LibraryItem, 0, len(transactions))
+	for _, transaction := range transactions {
+		item := &models.LibraryItem{
+			TransactionID: transaction.ID,
+			ItemType:      transaction.ItemType,
+			ItemID:        transaction.ItemID,
+			Amount:        transaction.Amount,
+			Currency:      transaction.Currency,
+			PurchasedAt:   transaction.CreatedAt,
+		}
+		switch transaction.ItemType {
+		case "product":
+			if item.Product = products[transaction.ItemID]; item.Product != nil {
+				item.Product.IsPurchased = true
+				item.DownloadURL = "/api/products/" + transaction.ItemID + "/download"
+			}
+		case "course":
+			if item.Course = courses[transaction.ItemID]; item.Course != nil {
+				item.Course.IsPurchased = true
+			}
+		}
+		items = append(items, item)
+	}
+	return items, total, nil
+}
+
+// MarkProducts sets IsPurchased on each product the viewer has bought.
+func (s *LibraryService) MarkProducts(viewerID string, products []*models.Product) error {
+	ids := make([]string, len(products))
+	for i, product := range products {
+		ids[i] = product.ID
+	}
+
+	purchased, err := s.purchasedIDs(viewerID, "product", ids)
+	if err != nil {
+		return err
+	}
+	for _, product := range products {
+		product.IsPurchased = purchased[product.ID]
+	}
+	return nil
+}
+
+// MarkCourses sets IsPurchased on each course the viewer has bought.
+func (s *LibraryService) MarkCourses(viewerID string, courses []*models.Course) error {
+	ids := make([]string, len(courses))
+	for i, course := range courses {
+		ids[i] = course.ID
+	}
+
+	purchased, err := s.purchasedIDs(viewerID, "course", ids)
+	if err != nil {
+		return err
+	}
+	for _, course := range courses {
+		course.IsPurchased = purchased[course.ID]
+	}
+	return nil
+}
+
+// purchasedIDs skips the lookup entirely for anonymous viewers.
+func (s *LibraryService) purchasedIDs(viewerID, itemType string, ids []string) (map[string]bool, error) {
+	if viewerID == "" || len(ids) == 0 || !s.transactionRepo.IsConnected() {
+		return map[string]bool{}, nil
+	}
+	return s.transactionRepo.PurchasedIDs(viewerID, itemType, ids)
+}
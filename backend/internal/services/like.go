@@ -0,0 +1,125 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"viport-backend/internal/models"
+	"viport-backend/internal/repositories"
+)
+
+var ErrUnsupportedLikeableType = errors.New("unsupported likeable type")
+
+// LikeService records likes against posts, products, courses and comments and
+// resolves the viewer's IsLiked flags on read paths.
+type LikeService struct {
+	likeRepo *repositories.LikeRepository
+}
+
+func NewLikeService(db *sql.DB) *LikeService {
+	return &LikeService{
+		likeRepo: repositories.NewLikeRepository(db),
+	}
+}
+
+// Like marks the item as liked by the user and returns its new like count.
+func (s *LikeService) Like(userID, likeableType, likeableID string) (int, error) {
+	if !repositories.IsLikeableType(likeableType) {
+		return 0, ErrUnsupportedLikeableType
+	}
+	return s.likeRepo.Like(userID, likeableType, likeableID)
+}
+
+// Unlike removes the user's like from the item and returns its new like count.
+func (s *LikeService) Unlike(userID, likeableType, likeableID string) (int, error) {
+	if !repositories.IsLikeableType(likeableType) {
+		return 0, ErrUnsupportedLikeableType
+	}
+	return s.likeRepo.Unlike(userID, likeableType, likeableID)
+}
+
+// MarkPosts sets IsLiked on each post the viewer has liked.
+func (s *LikeService) MarkPosts(viewerID string, posts []*models.Post) error {
+	ids := make([]string, len(posts))
+	for i, post := range posts {
+		ids[i] = post.ID
+	}
+
+	liked, err := s.likedIDs(viewerID, "post", ids)
+	if err != nil {
+		return err
+	}
+	for _, post := range posts {
+		post.IsLiked = liked[post.ID]
+	}
+	return nil
+}
+
+// MarkProducts sets IsLiked on each product the viewer has liked.
+func (s *LikeService) MarkProducts(viewerID string, products []*models.Product) error {
+	ids := make([]string, len(products))
+	for i, product := range products {
+		ids[i] = product.ID
+	}
+
+	liked, err := s.likedIDs(viewerID, "product", ids)
+	if err != nil {
+		return err
+	}
+	for _, product := range products {
+		product.IsLiked = liked[product.ID]
+	}
+	return nil
+}
+
+// MarkCourses sets IsLiked on each course the viewer has liked.
+func (s *LikeService) MarkCourses(viewerID string, courses []*models.Course) error {
+	ids := make([]string, len(courses))
+	for i, course := range courses {
+		ids[i] = course.ID
+	}
+
+	liked, err := s.likedIDs(viewerID, "course", ids)
+	if err != nil {
+		return err
+	}
+	for _, course := range courses {
+		course.IsLiked = liked[course.ID]
+	}
+	return nil
+}
+
+// MarkComments sets IsLiked on each comment, including nested replies, the
+// viewer has liked.
+func (s *LikeService) MarkComments(viewerID string, comments []*models.Comment) error {
+	var ids []string
+	walkComments(comments, func(comment *models.Comment) {
+		ids = append(ids, comment.ID)
+	})
+
+	liked, err := s.likedIDs(viewerID, "comment", ids)
+	if err != nil {
+		return err
+	}
+	walkComments(comments, func(comment *models.Comment) {
+		comment.IsLiked = liked[comment.ID]
+	})
+	return nil
+}
+
+// likedIDs skips the lookup entirely for anonymous viewers.
+func (s *LikeService) likedIDs(viewerID, likeableType string, ids []string) (map[string]bool, error) {
+	if viewerID == "" || len(ids) == 0 || !s.likeRepo.IsConnected() {
+		return map[string]bool{}, nil
+	}
+	return s.likeRepo.LikedIDs(viewerID, likeableType, ids)
+}
+
+// walkComments visits every comment and, depth first, each of its replies.
+func walkComments(comments []*models.Comment, visit func(*models.Comment)) {
+	for _, comment := range comments {
+		visit(comment)
+		for i := range comment.Replies {
+			walkComments([]*models.Comment{&comment.Replies[i]}, visit)
+		}
+	}
+}
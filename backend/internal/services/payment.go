@@ -0,0 +1,73 @@
+package services
+
+// Payment statuses reported by a PaymentProvider. They match the values of
+// transactions.payment_status.
+const (
+	PaymentPending   = "pending"
+	PaymentCompleted = "completed"
+	PaymentFailed    = "failed"
+	PaymentRefunded  = "refunded"
+)
+
+// paymentTransitions lists the statuses a transaction may move to from each
+// status. Failed and refunded are final.
+var paymentTransitions = map[string][]string{
+	PaymentPending:   {PaymentCompleted, PaymentFailed},
+	PaymentCompleted: {PaymentRefunded},
+}
+
+// CanTransition reports whether a transaction may move from one payment
+// status to another.
+func CanTransition(from, to string) bool {
+	for _, next := range paymentTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// PaymentProvider charges buyers through a payment gateway. Implementations
+// must be safe for concurrent use.
+type PaymentProvider interface {
+	// Name identifies the provider in logs and stored references.
+	Name() string
+
+	// Charge asks the gateway to collect a payment. A provider that settles
+	// asynchronously returns PaymentPending and reports the outcome later.
+	// An error means the gateway could not be reached or refused the request
+	// outright; declined payments are reported as PaymentFailed instead.
+	Charge(req PaymentRequest) (*PaymentResult, error)
+
+	// Refund returns all or part of a settled payment to the buyer. Like
+	// Charge it may answer PaymentPending and report the outcome later.
+	Refund(req RefundRequest) (*PaymentResult, error)
+}
+
+// PaymentRequest describes a single charge. Amounts are in minor units of
+// Currency, e.g. cents.
+type PaymentRequest struct {
+	TransactionID string
+	BuyerID       string
+	Amount        int64
+	Currency      string
+	PaymentMethod string
+	Description   string
+}
+
+// RefundRequest describes a refund of a payment made through Charge.
+// Amounts are in minor units of Currency.
+type RefundRequest struct {
+	RefundID         string
+	PaymentReference string // PaymentResult.Reference of the charge
+	Amount           int64
+	Currency         string
+	Reason           string
+}
+
+// PaymentResult is the gateway's answer to a charge or refund.
+type PaymentResult struct {
+	Reference     string // the gateway's ID for the payment
+	Status        string // PaymentPending, PaymentCompleted or PaymentFailed
+	FailureReason string // set when Status is PaymentFailed
+}
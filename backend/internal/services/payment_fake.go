@@ -0,0 +1,104 @@
+package services
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// Payment methods understood by FakePaymentProvider. Any other method is
+// charged successfully.
+const (
+	FakeMethodDecline = "fake_decline" // the charge is declined
+	FakeMethodPending = "fake_pending" // the charge stays pending until settled
+	FakeMethodError   = "fake_error"   // the gateway is unreachable
+)
+
+var (
+	ErrFakeGatewayUnavailable = errors.New("fake payment gateway unavailable")
+	ErrFakeUnknownPayment     = errors.New("fake payment not found or not settled")
+	ErrFakeRefundTooLarge     = errors.New("fake refund exceeds the charged amount")
+)
+
+// FakePaymentProvider is an in-process PaymentProvider for development and
+// tests. It never moves money; the outcome of a charge is chosen by its
+// payment method, see FakeMethodDecline and friends.
+type FakePaymentProvider struct {
+	mu       sync.Mutex
+	payments map[string]FakePayment
+}
+
+// FakePayment is a charge recorded by FakePaymentProvider.
+type FakePayment struct {
+	Request  PaymentRequest
+	Result   PaymentResult
+	Refunded int64
+}
+
+func NewFakePaymentProvider() *FakePaymentProvider {
+	return &FakePaymentProvider{payments: make(map[string]FakePayment)}
+}
+
+func (p *FakePaymentProvider) Name() string {
+	return "fake"
+}
+
+func (p *FakePaymentProvider) Charge(req PaymentRequest) (*PaymentResult, error) {
+	if req.PaymentMethod == FakeMethodError {
+		return nil, ErrFakeGatewayUnavailable
+	}
+
+	result := PaymentResult{
+		Reference: "fake_" + uuid.New().String(),
+		Status:    PaymentCompleted,
+	}
+	switch req.PaymentMethod {
+	case FakeMethodDecline:
+		result.Status = PaymentFailed
+		result.FailureReason = "card_declined"
+	case FakeMethodPending:
+		result.Status = PaymentPending
+	}
+
+	p.mu.Lock()
+	p.payments[result.Reference] = FakePayment{Request: req, Result: result}
+	p.mu.Unlock()
+
+	return &result, nil
+}
+
+// Refund refunds a completed fake charge. Refunds of charges made with
+// FakeMethodPending stay pending as well.
+func (p *FakePaymentProvider) Refund(req RefundRequest) (*PaymentResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	payment, ok := p.payments[req.PaymentReference]
+	if !ok || payment.Result.Status == PaymentFailed {
+		return nil, ErrFakeUnknownPayment
+	}
+	if payment.Refunded+req.Amount > payment.Request.Amount {
+		return nil, ErrFakeRefundTooLarge
+	}
+
+	payment.Refunded += req.Amount
+	p.payments[req.PaymentReference] = payment
+
+	result := PaymentResult{
+		Reference: "fake_re_" + uuid.New().String(),
+		Status:    PaymentCompleted,
+	}
+	if payment.Request.PaymentMethod == FakeMethodPending {
+		result.Status = PaymentPending
+	}
+	return &result, nil
+}
+
+// Payment returns the charge recorded under reference.
+func (p *FakePaymentProvider) Payment(reference string) (FakePayment, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	payment, ok := p.payments[reference]
+	return payment, ok
+}
@@ -0,0 +1,86 @@
+package services
+
+import "testing"
+
+func TestFakePaymentProviderCharge(t *testing.T) {
+	tests := []struct {
+		method       string
+		wantStatus   string
+		wantReason   string
+		wantErr      error
+		wantRecorded bool
+	}{
+		{method: "card", wantStatus: PaymentCompleted, wantRecorded: true},
+		{method: FakeMethodDecline, wantStatus: PaymentFailed, wantReason: "card_declined", wantRecorded: true},
+		{method: FakeMethodPending, wantStatus: PaymentPending, wantRecorded: true},
+		{method: FakeMethodError, wantErr: ErrFakeGatewayUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			provider := NewFakePaymentProvider()
+			result, err := provider.Charge(PaymentRequest{TransactionID: "tx", Amount: 1000, Currency: "USD", PaymentMethod: tt.method})
+			if err != tt.wantErr {
+				t.Fatalf("Charge() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if result.Status != tt.wantStatus || result.FailureReason != tt.wantReason {
+				t.Errorf("Charge() = %q (%q), want %q (%q)", result.Status, result.FailureReason, tt.wantStatus, tt.wantReason)
+			}
+			if _, ok := provider.Payment(result.Reference); ok != tt.wantRecorded {
+				t.Errorf("Payment(%q) recorded = %v, want %v", result.Reference, ok, tt.wantRecorded)
+			}
+		})
+	}
+}
+
+func TestFakePaymentProviderRefund(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		refunds    []int64
+		wantStatus string
+		wantErr    error
+	}{
+		{name: "full", method: "card", refunds: []int64{1000}, wantStatus: PaymentCompleted},
+		{name: "partial", method: "card", refunds: []int64{400}, wantStatus: PaymentCompleted},
+		{name: "partials up to the charge", method: "card", refunds: []int64{400, 600}, wantStatus: PaymentCompleted},
+		{name: "too large", method: "card", refunds: []int64{1001}, wantErr: ErrFakeRefundTooLarge},
+		{name: "partials over the charge", method: "card", refunds: []int64{600, 401}, wantErr: ErrFakeRefundTooLarge},
+		{name: "pending charge", method: FakeMethodPending, refunds: []int64{1000}, wantStatus: PaymentPending},
+		{name: "declined charge", method: FakeMethodDecline, refunds: []int64{1000}, wantErr: ErrFakeUnknownPayment},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := NewFakePaymentProvider()
+			charge, err := provider.Charge(PaymentRequest{TransactionID: "tx", Amount: 1000, Currency: "USD", PaymentMethod: tt.method})
+			if err != nil {
+				t.Fatalf("Charge() error = %v", err)
+			}
+
+			var result *PaymentResult
+			for _, amount := range tt.refunds {
+				result, err = provider.Refund(RefundRequest{PaymentReference: charge.Reference, Amount: amount, Currency: "USD"})
+				if err != nil {
+					break
+				}
+			}
+			if err != tt.wantErr {
+				t.Fatalf("Refund() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && result.Status != tt.wantStatus {
+				t.Errorf("Refund() status = %q, want %q", result.Status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestFakePaymentProviderRefundUnknownPayment(t *testing.T) {
+	provider := NewFakePaymentProvider()
+	if _, err := provider.Refund(RefundRequest{PaymentReference: "fake_missing", Amount: 100}); err != ErrFakeUnknownPayment {
+		t.Errorf("Refund() error = %v, want %v", err, ErrFakeUnknownPayment)
+	}
+}
@@ -0,0 +1,29 @@
+package services
+
+import "testing"
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{PaymentPending, PaymentCompleted, true},
+		{PaymentPending, PaymentFailed, true},
+		{PaymentPending, PaymentRefunded, false},
+		{PaymentPending, PaymentPending, false},
+		{PaymentCompleted, PaymentRefunded, true},
+		{PaymentCompleted, PaymentFailed, false},
+		{PaymentCompleted, PaymentPending, false},
+		{PaymentFailed, PaymentCompleted, false},
+		{PaymentFailed, PaymentPending, false},
+		{PaymentRefunded, PaymentCompleted, false},
+		{"", PaymentCompleted, false},
+		{"unknown", PaymentCompleted, false},
+	}
+
+	for _, tt := range tests {
+		if got := CanTransition(tt.from, tt.to); got != tt.want {
+			t.Errorf("CanTransition(%q, %q) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+	"viport-backend/internal/models"
+	"viport-backend/internal/repositories"
+)
+
+var (
+	ErrWebhookDisabled  = errors.New("payment webhook secret not configured")
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+)
+
+// webhookTolerance is how far a signature timestamp may be from now before
+// the delivery is rejected as a replay.
+const webhookTolerance = 5 * time.Minute
+
+// Webhook event types and the payment status each one moves a transaction to.
+var paymentEventStatuses = map[string]string{
+	"payment.completed": PaymentCompleted,
+	"payment.failed":    PaymentFailed,
+	"payment.refunded":  PaymentRefunded,
+}
+
+// Outcomes of handling a webhook event.
+const (
+	EventApplied   = "applied"   // the transaction moved to a new status
+	EventDuplicate = "duplicate" // the event was handled before
+	EventIgnored   = "ignored"   // nothing to do: unknown type or transaction, or a disallowed transition
+)
+
+// PaymentWebhookService authenticates payment provider webhooks and applies
+// the events they carry to transactions.
+type PaymentWebhookService struct {
+	transactionRepo *repositories.TransactionRepository
+	refundRepo      *repositories.RefundRepository
+	eventRepo       *repositories.PaymentEventRepository
+	secret          []byte
+	now             func() time.Time
+}
+
+func NewPaymentWebhookService(db *sql.DB, secret string) *PaymentWebhookService {
+	return &PaymentWebhookService{
+		transactionRepo: repositories.NewTransactionRepository(db),
+		refundRepo:      repositories.NewRefundRepository(db),
+		eventRepo:       repositories.NewPaymentEventRepository(db),
+		secret:          []byte(secret),
+		now:             time.Now,
+	}
+}
+
+// VerifySignature checks a signature header of the form "t=<unix>,v1=<hex>"
+// where v1 is the HMAC-SHA256 of "<t>.<body>" under the webhook secret.
+// Several v1 entries are accepted so the secret can be rotated.
+func (s *PaymentWebhookService) VerifySignature(header string, body []byte) error {
+	if len(s.secret) == 0 {
+		return ErrWebhookDisabled
+	}
+
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return ErrInvalidSignature
+	}
+	age := s.now().Sub(time.Unix(unix, 0))
+	if age > webhookTolerance || age < -webhookTolerance {
+		return ErrInvalidSignature
+	}
+
+	expected := webhookMAC(s.secret, timestamp, body)
+	for _, signature := range signatures {
+		mac, err := hex.DecodeString(signature)
+		if err == nil && hmac.Equal(mac, expected) {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
+
+// HandleEvent applies a verified event and reports its outcome. Errors are
+// only returned for failures worth a redelivery.
+func (s *PaymentWebhookService) HandleEvent(event models.PaymentEvent) (string, *models.Transaction, error) {
+	if strings.HasPrefix(event.Type, "refund.") || strings.HasPrefix(event.Type, "dispute.") {
+		return s.handleRefundEvent(event)
+	}
+
+	status, ok := paymentEventStatuses[event.Type]
+	if !ok {
+		return EventIgnored, nil, nil
+	}
+
+	transaction, err := s.transactionRepo.ApplyEvent(event, status, CanTransition)
+	switch err {
+	case nil:
+		return EventApplied, transaction, nil
+	case repositories.ErrDuplicateEvent:
+		return EventDuplicate, nil, nil
+	case repositories.ErrInvalidTransition, sql.ErrNoRows:
+		return EventIgnored, transaction, nil
+	default:
+		return "", nil, err
+	}
+}
+
+// handleRefundEvent settles refunds that were left processing and tracks
+// card disputes:
+//
+//	refund.completed, refund.failed  data.refundId settled or failed
+//	dispute.opened                   the buyer's bank disputes data.transactionId
+//	dispute.won, dispute.lost        the dispute data.reference was decided
+//
+// Each step only applies from the expected refund status, so a redelivery
+// that slips past the duplicate check changes nothing.
+func (s *PaymentWebhookService) handleRefundEvent(event models.PaymentEvent) (string, *models.Transaction, error) {
+	seen, err := s.eventRepo.Seen(event.ID)
+	if err != nil {
+		return "", nil, err
+	}
+	if seen {
+		return EventDuplicate, nil, nil
+	}
+
+	refund, err := s.applyRefundEvent(event)
+	outcome := EventApplied
+	switch err {
+	case nil:
+	case sql.ErrNoRows, repositories.ErrStatusConflict, repositories.ErrNotRefundable,
+		repositories.ErrRefundExceedsBalance, repositories.ErrRefundOpen:
+		outcome = EventIgnored
+	default:
+		return "", nil, err
+	}
+
+	var transactionID *string
+	var transaction *models.Transaction
+	if refund != nil {
+		transactionID = &refund.TransactionID
+		transaction = refund.Transaction
+	}
+	if err := s.eventRepo.Record(event, transactionID, outcome); err != nil {
+		return "", nil, err
+	}
+	return outcome, transaction, nil
+}
+
+func (s *PaymentWebhookService) applyRefundEvent(event models.PaymentEvent) (*models.Refund, error) {
+	switch event.Type {
+	case "refund.completed":
+		// Only refunds approved here are settled; the provider cannot
+		// complete one the seller or an admin never approved
+		return s.refundRepo.Complete(event.Data.RefundID, RefundProcessing, nil, nil, nil)
+
+	case "refund.failed":
+		refund, err := s.refundRepo.GetByID(event.Data.RefundID)
+		if err != nil {
+			return nil, err
+		}
+		return refund, s.refundRepo.UpdateStatus(refund.ID, RefundProcessing, RefundFailed, nil, nil, nil)
+
+	case "dispute.opened":
+		if event.Data.Reference == "" {
+			return nil, sql.ErrNoRows
+		}
+		if err := s.refundRepo.RejectOpen(event.Data.TransactionID, "Superseded by a card dispute"); err != nil {
+			return nil, err
+		}
+
+		reason := event.Data.Reason
+		if reason == "" {
+			reason = "Card dispute"
+		}
+		refund := &models.Refund{
+			TransactionID:     event.Data.TransactionID,
+			Kind:              "dispute",
+			Reason:            reason,
+			Status:            RefundProcessing,
+			ProviderReference: &event.Data.Reference,
+		}
+		if event.Data.Amount != nil {
+			refund.Amount = *event.Data.Amount
+		}
+		if err := s.refundRepo.Create(refund); err != nil {
+			return nil, err
+		}
+		return refund, nil
+
+	case "dispute.won", "dispute.lost":
+		refund, err := s.refundRepo.GetByProviderReference(event.Data.Reference)
+		if err != nil {
+			return nil, err
+		}
+		if event.Type == "dispute.lost" {
+			return s.refundRepo.Complete(refund.ID, RefundProcessing, nil, nil, nil)
+		}
+		note := "Dispute decided in the seller's favour"
+		return refund, s.refundRepo.UpdateStatus(refund.ID, RefundProcessing, RefundRejected, nil, &note, nil)
+	}
+
+	return nil, sql.ErrNoRows
+}
+
+// SignPaymentWebhook returns the signature header for body as sent at the
+// given time. Providers and tests use it to produce deliveries that
+// VerifySignature accepts.
+func SignPaymentWebhook(secret string, body []byte, at time.Time) string {
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	return "t=" + timestamp + ",v1=" + hex.EncodeToString(webhookMAC([]byte(secret), timestamp, body))
+}
+
+func webhookMAC(secret []byte, timestamp string, body []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"encoding/hex"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestVerifySignature(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	body := []byte(`{"id":"evt_1","type":"payment.completed"}`)
+
+	sign := func(secret string, at time.Time, body []byte) (string, string) {
+		timestamp := strconv.FormatInt(at.Unix(), 10)
+		return timestamp, hex.EncodeToString(webhookMAC([]byte(secret), timestamp, body))
+	}
+	ts, valid := sign("secret", now, body)
+	_, rotated := sign("old-secret", now, body)
+	oldTS, old := sign("secret", now.Add(-webhookTolerance-time.Second), body)
+	edgeTS, edge := sign("secret", now.Add(-webhookTolerance), body)
+	futureTS, future := sign("secret", now.Add(webhookTolerance+time.Second), body)
+
+	tests := []struct {
+		name    string
+		secret  string
+		header  string
+		body    []byte
+		wantErr error
+	}{
+		{"valid", "secret", "t=" + ts + ",v1=" + valid, body, nil},
+		{"spaces around entries", "secret", "t=" + ts + ", v1=" + valid, body, nil},
+		{"rotated secret listed first", "secret", "t=" + ts + ",v1=" + rotated + ",v1=" + valid, body, nil},
+		{"only the old secret", "secret", "t=" + ts + ",v1=" + rotated, body, ErrInvalidSignature},
+		{"at the tolerance edge", "secret", "t=" + edgeTS + ",v1=" + edge, body, nil},
+		{"too old", "secret", "t=" + oldTS + ",v1=" + old, body, ErrInvalidSignature},
+		{"too far in the future", "secret", "t=" + futureTS + ",v1=" + future, body, ErrInvalidSignature},
+		{"timestamp swapped", "secret", "t=" + edgeTS + ",v1=" + valid, body, ErrInvalidSignature},
+		{"body changed", "secret", "t=" + ts + ",v1=" + valid, []byte(`{"id":"evt_2"}`), ErrInvalidSignature},
+		{"not hex", "secret", "t=" + ts + ",v1=zz", body, ErrInvalidSignature},
+		{"no signature", "secret", "t=" + ts, body, ErrInvalidSignature},
+		{"no timestamp", "secret", "v1=" + valid, body, ErrInvalidSignature},
+		{"empty header", "secret", "", body, ErrInvalidSignature},
+		{"no secret configured", "", "t=" + ts + ",v1=" + valid, body, ErrWebhookDisabled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &PaymentWebhookService{secret: []byte(tt.secret), now: func() time.Time { return now }}
+			if err := service.VerifySignature(tt.header, tt.body); err != tt.wantErr {
+				t.Errorf("VerifySignature() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
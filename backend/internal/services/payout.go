@@ -0,0 +1,327 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"viport-backend/internal/models"
+	"viport-backend/internal/repositories"
+)
+
+var (
+	ErrPayoutNotHeld    = errors.New("payout is not on hold")
+	ErrPayoutNotPending = errors.New("payout is not pending")
+	ErrPayoutProvider   = errors.New("payout provider error")
+
+	// ErrPayoutUnknown is returned by PayoutProvider.Status for payouts the
+	// provider never received.
+	ErrPayoutUnknown = errors.New("payout unknown to provider")
+)
+
+// Payout statuses, stored in payouts.status. Held payouts are pending
+// payouts an admin stopped; paid and failed are final.
+const (
+	PayoutPending    = "pending"
+	PayoutHeld       = "held"
+	PayoutProcessing = "processing"
+	PayoutPaid       = "paid"
+	PayoutFailed     = "failed"
+)
+
+// DefaultPayoutMinimum is the payout threshold, in minor units, of creators
+// who have not set their own.
+const DefaultPayoutMinimum = 2500
+
+// payoutSendLimit caps how many pending payouts one run sends.
+const payoutSendLimit = 500
+
+// payoutSettleAfter is how long a payout may be processing before a run
+// asks the provider for its outcome. It also covers payouts claimed by a
+// run that stopped before recording the provider's answer.
+const payoutSettleAfter = 15 * time.Minute
+
+// PayoutProvider sends money to creators. Implementations must be safe for
+// concurrent use.
+type PayoutProvider interface {
+	// Name identifies the provider in logs and stored references.
+	Name() string
+
+	// Send asks the provider to pay a creator. It returns PayoutPaid,
+	// PayoutFailed for payouts the provider refused, or PayoutProcessing
+	// when the outcome is reported later. An error means the provider
+	// could not be reached and the payout may be retried.
+	Send(req PayoutRequest) (*PayoutResult, error)
+
+	// Status looks up the outcome of a payout sent earlier, by the
+	// reference Send returned or, when none was recorded, by payoutID. It
+	// returns ErrPayoutUnknown if the provider never received the payout.
+	Status(payoutID, reference string) (*PayoutResult, error)
+}
+
+// PayoutRequest describes one payout. Amounts are in minor units of
+// Currency.
+type PayoutRequest struct {
+	PayoutID    string
+	UserID      string
+	Amount      int64
+	Currency    string
+	MethodKind  string
+	Destination string
+}
+
+type PayoutResult struct {
+	Reference     string
+	Status        string
+	FailureReason string
+}
+
+// PayoutService lets creators manage where and when they are paid and
+// turns their ledger balances into payouts sent through the PayoutProvider.
+type PayoutService struct {
+	payoutRepo *repositories.PayoutRepository
+	provider   PayoutProvider
+}
+
+func NewPayoutService(db *sql.DB, provider PayoutProvider) *PayoutService {
+	return &PayoutService{
+		payoutRepo: repositories.NewPayoutRepository(db),
+		provider:   provider,
+	}
+}
+
+// AddMethod registers a payout destination for the creator.
+func (s *PayoutService) AddMethod(userID string, req models.CreatePayoutMethodRequest) (*models.PayoutMethod, error) {
+	destination := strings.TrimSpace(req.Destination)
+	hint := destination
+	if len(hint) > 4 {
+		hint = "****" + hint[len(hint)-4:]
+	}
+
+	method := &models.PayoutMethod{
+		UserID:          userID,
+		Kind:            req.Kind,
+		Label:           req.Label,
+		Destination:     destination,
+		DestinationHint: hint,
+		IsDefault:       req.IsDefault,
+	}
+	if err := s.payoutRepo.CreateMethod(method); err != nil {
+		return nil, err
+	}
+	return method, nil
+}
+
+func (s *PayoutService) ListMethods(userID string) ([]*models.PayoutMethod, error) {
+	return s.payoutRepo.ListMethods(userID)
+}
+
+func (s *PayoutService) SetDefaultMethod(userID, methodID string) error {
+	return s.payoutRepo.SetDefaultMethod(userID, methodID)
+}
+
+func (s *PayoutService) RemoveMethod(userID, methodID string) error {
+	return s.payoutRepo.RemoveMethod(userID, methodID)
+}
+
+func (s *PayoutService) Settings(userID string) (*models.PayoutSettings, error) {
+	return s.payoutRepo.GetSettings(userID, DefaultPayoutMinimum)
+}
+
+// SetMinimum changes the balance the creator must reach before a payout is
+// made.
+func (s *PayoutService) SetMinimum(userID string, minimum int64) (*models.PayoutSettings, error) {
+	if err := s.payoutRepo.SetMinimum(userID, minimum); err != nil {
+		return nil, err
+	}
+	return s.Settings(userID)
+}
+
+// List returns a page of the creator's payouts; an empty userID lists
+// everyone's for admins.
+func (s *PayoutService) List(userID, status string, limit, offset int) ([]*models.Payout, int, error) {
+	return s.payoutRepo.List(userID, status, limit, offset)
+}
+
+// Get returns a payout of the creator, or any payout for admins. Other
+// users get sql.ErrNoRows.
+func (s *PayoutService) Get(userID string, isAdmin bool, payoutID string) (*models.Payout, error) {
+	payout, err := s.payoutRepo.GetByID(payoutID)
+	if err != nil {
+		return nil, err
+	}
+	if !isAdmin && payout.UserID != userID {
+		return nil, sql.ErrNoRows
+	}
+	return payout, nil
+}
+
+// Hold stops a pending payout from being sent until it is released.
+func (s *PayoutService) Hold(payoutID string, reason *string) (*models.Payout, error) {
+	if reason == nil {
+		held := "Held by an administrator"
+		reason = &held
+	}
+	err := s.payoutRepo.UpdateStatus(payoutID, PayoutPending, PayoutHeld, nil, nil, reason)
+	if err == repositories.ErrStatusConflict {
+		return nil, ErrPayoutNotPending
+	} else if err != nil {
+		return nil, err
+	}
+	return s.payoutRepo.GetByID(payoutID)
+}
+
+// Release returns a held payout to the queue for the next run.
+func (s *PayoutService) Release(payoutID string) (*models.Payout, error) {
+	err := s.payoutRepo.UpdateStatus(payoutID, PayoutHeld, PayoutPending, nil, nil, nil)
+	if err == repositories.ErrStatusConflict {
+		return nil, ErrPayoutNotHeld
+	} else if err != nil {
+		return nil, err
+	}
+	return s.payoutRepo.GetByID(payoutID)
+}
+
+// HoldCreator stops or resumes all future payouts to a creator. Payouts
+// already created are held individually.
+func (s *PayoutService) HoldCreator(userID string, onHold bool, reason *string) (*models.PayoutSettings, error) {
+	if !onHold {
+		reason = nil
+	}
+	if err := s.payoutRepo.SetHold(userID, onHold, reason, DefaultPayoutMinimum); err != nil {
+		return nil, err
+	}
+	return s.Settings(userID)
+}
+
+// RunBatch creates payouts for every eligible balance and sends all
+// pending payouts, including ones left over or released since the last
+// run. Payouts the provider could not be reached for stay pending.
+// Payouts processing for longer than payoutSettleAfter are settled with
+// the provider first; the batch counts them with the ones it sent.
+func (s *PayoutService) RunBatch() (*models.PayoutBatch, error) {
+	batch, err := s.payoutRepo.CreateBatch(DefaultPayoutMinimum)
+	if err != nil {
+		return nil, err
+	}
+
+	stale, err := s.payoutRepo.ListStaleProcessing(payoutSettleAfter, payoutSendLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	var sendErr error
+	count := func(status string, err error) {
+		if err != nil {
+			sendErr = err
+			return
+		}
+		switch status {
+		case PayoutPaid:
+			batch.PaidCount++
+		case PayoutFailed:
+			batch.FailedCount++
+		}
+	}
+
+	for _, payout := range stale {
+		count(s.settle(payout))
+	}
+
+	// Listed after settling so payouts the provider never received are
+	// sent again in this run
+	payouts, err := s.payoutRepo.ListPending(payoutSendLimit)
+	if err != nil {
+		return nil, err
+	}
+	for _, payout := range payouts {
+		count(s.send(payout))
+	}
+
+	if err := s.payoutRepo.CompleteBatch(batch); err != nil {
+		return nil, err
+	}
+	return batch, sendErr
+}
+
+// send claims a pending payout, hands it to the provider and records the
+// outcome. It returns the payout's new status.
+func (s *PayoutService) send(payout *models.Payout) (string, error) {
+	err := s.payoutRepo.UpdateStatus(payout.ID, PayoutPending, PayoutProcessing, nil, nil, nil)
+	if err == repositories.ErrStatusConflict {
+		// Held or claimed by a concurrent run since it was listed
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+
+	result, err := s.provider.Send(PayoutRequest{
+		PayoutID:    payout.ID,
+		UserID:      payout.UserID,
+		Amount:      payout.Amount,
+		Currency:    payout.Currency,
+		MethodKind:  payout.Method.Kind,
+		Destination: payout.Method.Destination,
+	})
+	if err != nil {
+		// Put the payout back so the next run retries it
+		if updateErr := s.payoutRepo.UpdateStatus(payout.ID, PayoutProcessing, PayoutPending, nil, nil, nil); updateErr != nil {
+			return "", fmt.Errorf("%w: %v (returning payout to pending: %v)", ErrPayoutProvider, err, updateErr)
+		}
+		return PayoutPending, fmt.Errorf("%w: %v", ErrPayoutProvider, err)
+	}
+
+	return s.record(payout, result)
+}
+
+// settle asks the provider what became of a payout left processing and
+// records the outcome. Payouts the provider never received go back to
+// pending to be sent again.
+func (s *PayoutService) settle(payout *models.Payout) (string, error) {
+	reference := ""
+	if payout.ProviderReference != nil {
+		reference = strings.TrimPrefix(*payout.ProviderReference, s.provider.Name()+":")
+	}
+
+	result, err := s.provider.Status(payout.ID, reference)
+	if err == ErrPayoutUnknown {
+		err = s.payoutRepo.UpdateStatus(payout.ID, PayoutProcessing, PayoutPending, nil, nil, nil)
+		if err == repositories.ErrStatusConflict {
+			// Settled by a concurrent run
+			return "", nil
+		} else if err != nil {
+			return "", err
+		}
+		return PayoutPending, nil
+	} else if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrPayoutProvider, err)
+	}
+
+	status, err := s.record(payout, result)
+	if err == repositories.ErrStatusConflict {
+		return "", nil
+	}
+	return status, err
+}
+
+// record stores the provider's answer for a processing payout. Payouts
+// still processing are stamped so the next settlement check waits
+// payoutSettleAfter again.
+func (s *PayoutService) record(payout *models.Payout, result *PayoutResult) (string, error) {
+	reference := s.provider.Name() + ":" + result.Reference
+	var err error
+	switch result.Status {
+	case PayoutPaid:
+		_, err = s.payoutRepo.MarkPaid(payout.ID, &reference)
+	case PayoutFailed:
+		err = s.payoutRepo.MarkFailed(payout.ID, &reference, result.FailureReason)
+	default:
+		// Settled later by the provider; keep the reference to match it
+		err = s.payoutRepo.UpdateStatus(payout.ID, PayoutProcessing, PayoutProcessing, &reference, nil, nil)
+	}
+	if err != nil {
+		return "", err
+	}
+	return result.Status, nil
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// Destination prefixes understood by FakePayoutProvider. Payouts to any
+// other destination are paid immediately.
+const (
+	FakeDestinationFail    = "fake_fail"    // the payout is rejected
+	FakeDestinationPending = "fake_pending" // the payout is paid once its status is checked
+	FakeDestinationError   = "fake_error"   // the provider is unreachable
+)
+
+var ErrFakePayoutUnavailable = errors.New("fake payout provider unavailable")
+
+// FakePayoutProvider is an in-process PayoutProvider for development and
+// tests. It never moves money; the outcome of a payout is chosen by the
+// prefix of its destination, see FakeDestinationFail and friends.
+type FakePayoutProvider struct {
+	mu      sync.Mutex
+	payouts map[string]FakePayout
+}
+
+// FakePayout is a payout recorded by FakePayoutProvider.
+type FakePayout struct {
+	Request PayoutRequest
+	Result  PayoutResult
+}
+
+func NewFakePayoutProvider() *FakePayoutProvider {
+	return &FakePayoutProvider{payouts: make(map[string]FakePayout)}
+}
+
+func (p *FakePayoutProvider) Name() string {
+	return "fake"
+}
+
+func (p *FakePayoutProvider) Send(req PayoutRequest) (*PayoutResult, error) {
+	if strings.HasPrefix(req.Destination, FakeDestinationError) {
+		return nil, ErrFakePayoutUnavailable
+	}
+
+	result := PayoutResult{
+		Reference: "fake_po_" + uuid.New().String(),
+		Status:    PayoutPaid,
+	}
+	switch {
+	case strings.HasPrefix(req.Destination, FakeDestinationFail):
+		result.Status = PayoutFailed
+		result.FailureReason = "account_closed"
+	case strings.HasPrefix(req.Destination, FakeDestinationPending):
+		result.Status = PayoutProcessing
+	}
+
+	p.mu.Lock()
+	p.payouts[result.Reference] = FakePayout{Request: req, Result: result}
+	p.mu.Unlock()
+
+	return &result, nil
+}
+
+// Status reports a recorded payout. Payouts sent to FakeDestinationPending
+// are processing until their first status check, which pays them.
+func (p *FakePayoutProvider) Status(payoutID, reference string) (*PayoutResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	payout, ok := p.payouts[reference]
+	if !ok {
+		for _, recorded := range p.payouts {
+			if recorded.Request.PayoutID == payoutID {
+				payout, ok = recorded, true
+				break
+			}
+		}
+	}
+	if !ok {
+		return nil, ErrPayoutUnknown
+	}
+
+	if payout.Result.Status == PayoutProcessing {
+		payout.Result.Status = PayoutPaid
+		p.payouts[payout.Result.Reference] = payout
+	}
+	result := payout.Result
+	return &result, nil
+}
+
+// Payout returns the payout recorded under reference.
+func (p *FakePayoutProvider) Payout(reference string) (FakePayout, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	payout, ok := p.payouts[reference]
+	return payout, ok
+}
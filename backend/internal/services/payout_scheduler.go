@@ -0,0 +1,68 @@
+package services
+
+import (
+	"strconv"
+	"sync"
+	"time"
+	"viport-backend/pkg/logger"
+)
+
+// PayoutScheduler runs PayoutService.RunBatch in the background at a fixed
+// interval.
+type PayoutScheduler struct {
+	payouts  *PayoutService
+	interval time.Duration
+	logger   logger.Logger
+
+	stop chan struct{}
+	done sync.WaitGroup
+}
+
+func NewPayoutScheduler(payouts *PayoutService, interval time.Duration, logger logger.Logger) *PayoutScheduler {
+	return &PayoutScheduler{
+		payouts:  payouts,
+		interval: interval,
+		logger:   logger,
+		stop:     make(chan struct{}),
+	}
+}
+
+// Start begins running batches every interval, the first one after a full
+// interval has passed.
+func (s *PayoutScheduler) Start() {
+	s.done.Add(1)
+	go func() {
+		defer s.done.Done()
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				s.run()
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends the schedule, waiting for a running batch to finish.
+func (s *PayoutScheduler) Stop() {
+	close(s.stop)
+	s.done.Wait()
+}
+
+func (s *PayoutScheduler) run() {
+	batch, err := s.payouts.RunBatch()
+	if batch == nil {
+		s.logger.Error("Payout batch failed: " + err.Error())
+		return
+	}
+	if err != nil {
+		s.logger.Error("Payout batch " + batch.ID + " could not send every payout: " + err.Error())
+	}
+	s.logger.Info("Payout batch " + batch.ID + ": " + strconv.Itoa(batch.PayoutCount) + " created, " +
+		strconv.Itoa(batch.PaidCount) + " paid, " + strconv.Itoa(batch.FailedCount) + " failed")
+}
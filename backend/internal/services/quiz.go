@@ -0,0 +1,319 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"viport-backend/internal/models"
+	"viport-backend/internal/repositories"
+)
+
+var (
+	ErrInvalidQuiz  = errors.New("invalid quiz")
+	ErrLessonLocked = errors.New("enroll in the course to open this lesson")
+)
+
+// QuizService manages the quizzes attached to lessons and scores students'
+// attempts at them.
+type QuizService struct {
+	quizRepo       *repositories.QuizRepository
+	courseRepo     *repositories.CourseRepository
+	enrollmentRepo *repositories.EnrollmentRepository
+}
+
+func NewQuizService(db *sql.DB) *QuizService {
+	return &QuizService{
+		quizRepo:       repositories.NewQuizRepository(db),
+		courseRepo:     repositories.NewCourseRepository(db),
+		enrollmentRepo: repositories.NewEnrollmentRepository(db),
+	}
+}
+
+// Save creates or replaces the quiz of a lesson. Questions without an ID
+// are numbered, and questions without points are worth one. It returns an
+// error wrapping ErrInvalidQuiz when a question cannot be scored.
+func (s *QuizService) Save(courseID, lessonID string, req *models.SaveQuizRequest) (*models.Quiz, error) {
+	questions := make([]models.QuizQuestion, len(req.Questions))
+	seen := make(map[string]bool, len(req.Questions))
+	for i, question := range req.Questions {
+		if question.ID == "" {
+			question.ID = "q" + strconv.Itoa(i+1)
+		}
+		if seen[question.ID] {
+			return nil, fmt.Errorf("%w: question ID %q is used twice", ErrInvalidQuiz, question.ID)
+		}
+		seen[question.ID] = true
+
+		if question.Points == 0 {
+			question.Points = 1
+		}
+		if err := checkQuestion(&question); err != nil {
+			return nil, fmt.Errorf("%w: question %s: %v", ErrInvalidQuiz, question.ID, err)
+		}
+		questions[i] = question
+	}
+
+	quiz := &models.Quiz{
+		LessonID:           lessonID,
+		CourseID:           courseID,
+		Title:              req.Title,
+		Description:        req.Description,
+		PassMark:           req.PassMark,
+		MaxAttempts:        req.MaxAttempts,
+		RequiredToComplete: req.RequiredToComplete,
+		Questions:          questions,
+	}
+	if err := s.quizRepo.Save(quiz); err != nil {
+		return nil, err
+	}
+	return quiz, nil
+}
+
+// checkQuestion reports why a question could not be scored.
+func checkQuestion(question *models.QuizQuestion) error {
+	switch question.Type {
+	case "multiple_choice", "multi_select":
+		if len(question.Options) < 2 {
+			return errors.New("needs at least two options")
+		}
+		options := make(map[string]bool, len(question.Options))
+		for _, option := range question.Options {
+			if options[option.ID] {
+				return fmt.Errorf("option ID %q is used twice", option.ID)
+			}
+			options[option.ID] = true
+		}
+
+		correct := make(map[string]bool, len(question.CorrectOptionIDs))
+		for _, id := range question.CorrectOptionIDs {
+			if !options[id] {
+				return fmt.Errorf("correct option %q is not an option", id)
+			}
+			correct[id] = true
+		}
+		if question.Type == "multiple_choice" && len(correct) != 1 {
+			return errors.New("multiple choice questions have exactly one correct option")
+		}
+		if len(correct) == 0 {
+			return errors.New("needs at least one correct option")
+		}
+		question.AcceptedAnswers, question.MatchMode = nil, ""
+
+	case "short_answer":
+		if len(question.AcceptedAnswers) == 0 {
+			return errors.New("needs at least one accepted answer")
+		}
+		if question.MatchMode == "" {
+			question.MatchMode = "exact"
+		}
+		if question.MatchMode == "regex" {
+			for _, pattern := range question.AcceptedAnswers {
+				if _, err := compileAnswer(pattern, question.CaseSensitive); err != nil {
+					return fmt.Errorf("accepted answer %q is not a valid regular expression", pattern)
+				}
+			}
+		}
+		question.Options, question.CorrectOptionIDs = nil, nil
+	}
+	return nil
+}
+
+func (s *QuizService) Delete(courseID, lessonID string) error {
+	return s.quizRepo.Delete(courseID, lessonID)
+}
+
+// Get returns the quiz of a lesson the viewer may open. Instructors get the
+// answers; students get the questions alone with a summary of their
+// attempts. It returns ErrLessonLocked when the viewer may not open the
+// lesson.
+func (s *QuizService) Get(viewerID, courseID, lessonID string) (*models.Quiz, error) {
+	course, lesson, enrolled, err := s.openLesson(viewerID, courseID, lessonID)
+	if err != nil {
+		return nil, err
+	}
+
+	quiz, err := s.quizRepo.GetByLesson(course.ID, lesson.ID)
+	if err != nil {
+		return nil, err
+	}
+	if course.InstructorID == viewerID {
+		return quiz, nil
+	}
+
+	for i := range quiz.Questions {
+		quiz.Questions[i].CorrectOptionIDs = nil
+		quiz.Questions[i].AcceptedAnswers = nil
+		quiz.Questions[i].MatchMode = ""
+		quiz.Questions[i].CaseSensitive = false
+	}
+
+	if enrolled {
+		quiz.AttemptsUsed, quiz.BestPercentage, quiz.IsPassed, err = s.quizRepo.AttemptSummary(quiz.ID, viewerID)
+		if err != nil {
+			return nil, err
+		}
+		quiz.AttemptsRemaining = attemptsRemaining(quiz)
+	}
+	return quiz, nil
+}
+
+// Submit scores an enrolled student's answers and records the attempt.
+// Passing completes the lesson; the attempt carries the updated enrollment.
+// It returns ErrAttemptsExhausted once the student used every attempt.
+func (s *QuizService) Submit(userID, courseID, lessonID string, answers []models.QuizAnswer) (*models.QuizAttempt, error) {
+	if _, _, enrolled, err := s.openLesson(userID, courseID, lessonID); err != nil {
+		return nil, err
+	} else if !enrolled {
+		return nil, repositories.ErrNotEnrolled
+	}
+
+	quiz, err := s.quizRepo.GetByLesson(courseID, lessonID)
+	if err != nil {
+		return nil, err
+	}
+
+	attempt := scoreQuiz(quiz, answers)
+	attempt.UserID = userID
+	if attempt.Enrollment, err = s.quizRepo.CreateAttempt(quiz, attempt); err != nil {
+		return nil, err
+	}
+	return attempt, nil
+}
+
+// Attempts returns the student's attempts at the quiz of a lesson, newest
+// first.
+func (s *QuizService) Attempts(userID, courseID, lessonID string) ([]*models.QuizAttempt, error) {
+	if _, _, _, err := s.openLesson(userID, courseID, lessonID); err != nil {
+		return nil, err
+	}
+
+	quiz, err := s.quizRepo.GetByLesson(courseID, lessonID)
+	if err != nil {
+		return nil, err
+	}
+	return s.quizRepo.ListAttempts(quiz.ID, userID)
+}
+
+// openLesson loads a lesson the viewer may open, reporting whether they are
+// enrolled in its course. Instructors and enrolled students open every
+// lesson, others only previews.
+func (s *QuizService) openLesson(viewerID, courseID, lessonID string) (*models.Course, *models.Lesson, bool, error) {
+	course, err := s.courseRepo.GetVisibleByID(courseID, viewerID)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	lesson, err := s.courseRepo.GetLesson(course.ID, lessonID)
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	enrolled := false
+	if viewerID != "" {
+		_, err := s.enrollmentRepo.Get(viewerID, course.ID)
+		if err != nil && err != repositories.ErrNotEnrolled {
+			return nil, nil, false, err
+		}
+		enrolled = err == nil
+	}
+
+	if !enrolled && !lesson.IsPreview && course.InstructorID != viewerID {
+		return nil, nil, false, ErrLessonLocked
+	}
+	return course, lesson, enrolled, nil
+}
+
+// scoreQuiz marks each question right or wrong, with no partial credit, and
+// totals the points.
+func scoreQuiz(quiz *models.Quiz, answers []models.QuizAnswer) *models.QuizAttempt {
+	byQuestion := make(map[string]models.QuizAnswer, len(answers))
+	for _, answer := range answers {
+		byQuestion[answer.QuestionID] = answer
+	}
+
+	attempt := &models.QuizAttempt{
+		Answers: []models.QuizAnswer{},
+		Results: make([]models.QuizResult, len(quiz.Questions)),
+	}
+	for i, question := range quiz.Questions {
+		answer, answered := byQuestion[question.ID]
+		if answered {
+			attempt.Answers = append(attempt.Answers, answer)
+		}
+
+		result := models.QuizResult{QuestionID: question.ID, MaxPoints: question.Points}
+		if answered && isCorrect(question, answer) {
+			result.Correct = true
+			result.Points = question.Points
+		}
+		attempt.Results[i] = result
+		attempt.Score += result.Points
+		attempt.MaxScore += result.MaxPoints
+	}
+
+	if attempt.MaxScore > 0 {
+		attempt.Percentage = attempt.Score * 100 / attempt.MaxScore
+	}
+	attempt.Passed = attempt.Percentage >= quiz.PassMark
+	return attempt
+}
+
+func isCorrect(question models.QuizQuestion, answer models.QuizAnswer) bool {
+	switch question.Type {
+	case "multiple_choice", "multi_select":
+		chosen := make(map[string]bool, len(answer.OptionIDs))
+		for _, id := range answer.OptionIDs {
+			chosen[id] = true
+		}
+		if len(chosen) != len(question.CorrectOptionIDs) {
+			return false
+		}
+		for _, id := range question.CorrectOptionIDs {
+			if !chosen[id] {
+				return false
+			}
+		}
+		return true
+
+	case "short_answer":
+		if answer.Text == nil {
+			return false
+		}
+		text := strings.TrimSpace(*answer.Text)
+		for _, accepted := range question.AcceptedAnswers {
+			if question.MatchMode == "regex" {
+				if pattern, err := compileAnswer(accepted, question.CaseSensitive); err == nil && pattern.MatchString(text) {
+					return true
+				}
+			} else if question.CaseSensitive && text == strings.TrimSpace(accepted) {
+				return true
+			} else if !question.CaseSensitive && strings.EqualFold(text, strings.TrimSpace(accepted)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// compileAnswer compiles an accepted answer pattern to match the whole
+// answer.
+func compileAnswer(pattern string, caseSensitive bool) (*regexp.Regexp, error) {
+	flags := ""
+	if !caseSensitive {
+		flags = "(?i)"
+	}
+	return regexp.Compile(flags + `^(?:` + pattern + `)$`)
+}
+
+func attemptsRemaining(quiz *models.Quiz) *int {
+	if quiz.MaxAttempts == nil {
+		return nil
+	}
+	remaining := *quiz.MaxAttempts - quiz.AttemptsUsed
+	if remaining < 0 {
+		remaining = 0
+	}
+	return &remaining
+}
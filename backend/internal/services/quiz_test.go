@@ -0,0 +1,158 @@
+package services
+
+import (
+	"testing"
+	"viport-backend/internal/models"
+)
+
+func options(ids ...string) []models.QuizOption {
+	opts := make([]models.QuizOption, len(ids))
+	for i, id := range ids {
+		opts[i] = models.QuizOption{ID: id, Text: "Option " + id}
+	}
+	return opts
+}
+
+func TestCheckQuestion(t *testing.T) {
+	tests := []struct {
+		name     string
+		question models.QuizQuestion
+		wantErr  bool
+	}{
+		{"multiple choice", models.QuizQuestion{Type: "multiple_choice", Options: options("a", "b"), CorrectOptionIDs: []string{"a"}}, false},
+		{"one option", models.QuizQuestion{Type: "multiple_choice", Options: options("a"), CorrectOptionIDs: []string{"a"}}, true},
+		{"duplicate option", models.QuizQuestion{Type: "multiple_choice", Options: options("a", "a"), CorrectOptionIDs: []string{"a"}}, true},
+		{"correct option missing", models.QuizQuestion{Type: "multiple_choice", Options: options("a", "b"), CorrectOptionIDs: []string{"c"}}, true},
+		{"two correct options", models.QuizQuestion{Type: "multiple_choice", Options: options("a", "b"), CorrectOptionIDs: []string{"a", "b"}}, true},
+		{"no correct option", models.QuizQuestion{Type: "multiple_choice", Options: options("a", "b")}, true},
+		{"multi select", models.QuizQuestion{Type: "multi_select", Options: options("a", "b", "c"), CorrectOptionIDs: []string{"a", "c"}}, false},
+		{"multi select without correct option", models.QuizQuestion{Type: "multi_select", Options: options("a", "b")}, true},
+		{"short answer", models.QuizQuestion{Type: "short_answer", AcceptedAnswers: []string{"Paris"}}, false},
+		{"short answer without answers", models.QuizQuestion{Type: "short_answer"}, true},
+		{"regex answer", models.QuizQuestion{Type: "short_answer", AcceptedAnswers: []string{"colou?r"}, MatchMode: "regex"}, false},
+		{"invalid regex answer", models.QuizQuestion{Type: "short_answer", AcceptedAnswers: []string{"colou(r"}, MatchMode: "regex"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			question := tt.question
+			if err := checkQuestion(&question); (err != nil) != tt.wantErr {
+				t.Errorf("checkQuestion() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckQuestionClearsOtherTypeFields(t *testing.T) {
+	choice := models.QuizQuestion{
+		Type: "multiple_choice", Options: options("a", "b"), CorrectOptionIDs: []string{"a"},
+		AcceptedAnswers: []string{"a"}, MatchMode: "regex",
+	}
+	if err := checkQuestion(&choice); err != nil {
+		t.Fatalf("checkQuestion() error = %v", err)
+	}
+	if choice.AcceptedAnswers != nil || choice.MatchMode != "" {
+		t.Errorf("choice question kept short answer fields: %v %q", choice.AcceptedAnswers, choice.MatchMode)
+	}
+
+	short := models.QuizQuestion{
+		Type: "short_answer", AcceptedAnswers: []string{"Paris"},
+		Options: options("a", "b"), CorrectOptionIDs: []string{"a"},
+	}
+	if err := checkQuestion(&short); err != nil {
+		t.Fatalf("checkQuestion() error = %v", err)
+	}
+	if short.Options != nil || short.CorrectOptionIDs != nil {
+		t.Errorf("short answer question kept choice fields: %v %v", short.Options, short.CorrectOptionIDs)
+	}
+	if short.MatchMode != "exact" {
+		t.Errorf("short answer match mode = %q, want %q", short.MatchMode, "exact")
+	}
+}
+
+func TestScoreQuiz(t *testing.T) {
+	text := func(s string) *string { return &s }
+	quiz := &models.Quiz{
+		PassMark: 60,
+		Questions: []models.QuizQuestion{
+			{ID: "q1", Type: "multiple_choice", Points: 2, Options: options("a", "b"), CorrectOptionIDs: []string{"a"}},
+			{ID: "q2", Type: "multi_select", Points: 3, Options: options("a", "b", "c"), CorrectOptionIDs: []string{"a", "c"}},
+			{ID: "q3", Type: "short_answer", Points: 1, AcceptedAnswers: []string{"Paris"}, MatchMode: "exact"},
+			{ID: "q4", Type: "short_answer", Points: 4, AcceptedAnswers: []string{"colou?r"}, MatchMode: "regex", CaseSensitive: true},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		answers     []models.QuizAnswer
+		wantScore   int
+		wantPercent int
+		wantPassed  bool
+		wantCorrect []bool
+	}{
+		{
+			name: "all correct",
+			answers: []models.QuizAnswer{
+				{QuestionID: "q1", OptionIDs: []string{"a"}},
+				{QuestionID: "q2", OptionIDs: []string{"c", "a"}},
+				{QuestionID: "q3", Text: text("  paris ")},
+				{QuestionID: "q4", Text: text("color")},
+			},
+			wantScore: 10, wantPercent: 100, wantPassed: true,
+			wantCorrect: []bool{true, true, true, true},
+		},
+		{
+			name:        "no answers",
+			wantScore:   0,
+			wantPercent: 0,
+			wantCorrect: []bool{false, false, false, false},
+		},
+		{
+			name: "no partial credit",
+			answers: []models.QuizAnswer{
+				{QuestionID: "q1", OptionIDs: []string{"a", "b"}},
+				{QuestionID: "q2", OptionIDs: []string{"a"}},
+				{QuestionID: "q3", Text: text("Paris")},
+				{QuestionID: "q4", Text: text("Colour")},
+			},
+			wantScore: 1, wantPercent: 10,
+			wantCorrect: []bool{false, false, true, false},
+		},
+		{
+			name: "regex matches the whole answer",
+			answers: []models.QuizAnswer{
+				{QuestionID: "q1", OptionIDs: []string{"a"}},
+				{QuestionID: "q4", Text: text("colours")},
+				{QuestionID: "unknown", OptionIDs: []string{"a"}},
+			},
+			wantScore: 2, wantPercent: 20,
+			wantCorrect: []bool{true, false, false, false},
+		},
+		{
+			name: "exactly the pass mark",
+			answers: []models.QuizAnswer{
+				{QuestionID: "q4", Text: text("colour")},
+				{QuestionID: "q1", OptionIDs: []string{"a", "a"}},
+			},
+			wantScore: 6, wantPercent: 60, wantPassed: true,
+			wantCorrect: []bool{true, false, false, true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempt := scoreQuiz(quiz, tt.answers)
+			if attempt.Score != tt.wantScore || attempt.MaxScore != 10 {
+				t.Errorf("score = %d/%d, want %d/10", attempt.Score, attempt.MaxScore, tt.wantScore)
+			}
+			if attempt.Percentage != tt.wantPercent || attempt.Passed != tt.wantPassed {
+				t.Errorf("percentage = %d passed = %v, want %d %v", attempt.Percentage, attempt.Passed, tt.wantPercent, tt.wantPassed)
+			}
+			for i, want := range tt.wantCorrect {
+				if attempt.Results[i].Correct != want {
+					t.Errorf("question %s correct = %v, want %v", quiz.Questions[i].ID, attempt.Results[i].Correct, want)
+				}
+			}
+		})
+	}
+}
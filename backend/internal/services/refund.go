@@ -0,0 +1,181 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"viport-backend/internal/models"
+	"viport-backend/internal/repositories"
+)
+
+var (
+	ErrNotRefundReviewer = errors.New("only the seller or an admin can review this refund")
+	ErrRefundNotOpen     = errors.New("refund is not awaiting review")
+)
+
+// Refund statuses, stored in refunds.status.
+const (
+	RefundRequested  = "requested"
+	RefundProcessing = "processing"
+	RefundCompleted  = "completed"
+	RefundRejected   = "rejected"
+	RefundFailed     = "failed"
+)
+
+// RefundService runs the refund workflow: buyers request refunds, the seller
+// or an admin approves or rejects them, and approved refunds are paid back
+// through the PaymentProvider and booked against the original transaction.
+type RefundService struct {
+	refundRepo      *repositories.RefundRepository
+	transactionRepo *repositories.TransactionRepository
+	provider        PaymentProvider
+}
+
+func NewRefundService(db *sql.DB, provider PaymentProvider) *RefundService {
+	return &RefundService{
+		refundRepo:      repositories.NewRefundRepository(db),
+		transactionRepo: repositories.NewTransactionRepository(db),
+		provider:        provider,
+	}
+}
+
+// Request opens a refund request on one of the buyer's completed purchases.
+// Transactions of other users are reported as sql.ErrNoRows.
+func (s *RefundService) Request(buyerID, transactionID string, req models.CreateRefundRequest) (*models.Refund, error) {
+	transaction, err := s.transactionRepo.GetByID(transactionID)
+	if err != nil {
+		return nil, err
+	}
+	if transaction.BuyerID != buyerID {
+		return nil, sql.ErrNoRows
+	}
+
+	refund := &models.Refund{
+		TransactionID: transaction.ID,
+		Kind:          "refund",
+		RequestedBy:   &buyerID,
+		Reason:        strings.TrimSpace(req.Reason),
+		Status:        RefundRequested,
+	}
+	if req.Amount != nil {
+		refund.Amount = *req.Amount
+	}
+
+	if err := s.refundRepo.Create(refund); err != nil {
+		return nil, err
+	}
+	refund.Transaction = transaction
+	return refund, nil
+}
+
+// Get returns a refund visible to the user: the buyer, the seller or an
+// admin. Others get sql.ErrNoRows.
+func (s *RefundService) Get(userID string, isAdmin bool, refundID string) (*models.Refund, error) {
+	refund, err := s.refundRepo.GetByID(refundID)
+	if err != nil {
+		return nil, err
+	}
+	if !isAdmin && refund.Transaction.BuyerID != userID && refund.Transaction.SellerID != userID {
+		return nil, sql.ErrNoRows
+	}
+	return refund, nil
+}
+
+func (s *RefundService) List(userID, role string, limit, offset int) ([]*models.Refund, int, error) {
+	return s.refundRepo.ListForUser(userID, role, limit, offset)
+}
+
+// Approve pays a requested refund back to the buyer. The refund is completed
+// right away when the provider settles synchronously, otherwise it stays
+// processing until the provider's refund webhook arrives.
+func (s *RefundService) Approve(reviewerID string, isAdmin bool, refundID string, note *string) (*models.Refund, error) {
+	refund, err := s.reviewable(reviewerID, isAdmin, refundID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Claim the refund first so concurrent approvals cannot both pay out
+	if err := s.refundRepo.UpdateStatus(refund.ID, RefundRequested, RefundProcessing, &reviewerID, note, nil); err != nil {
+		if err == repositories.ErrStatusConflict {
+			return nil, ErrRefundNotOpen
+		}
+		return nil, err
+	}
+
+	transaction := refund.Transaction
+	var paymentReference string
+	if transaction.TransactionID != nil {
+		paymentReference = strings.TrimPrefix(*transaction.TransactionID, s.provider.Name()+":")
+	}
+
+	result, err := s.provider.Refund(RefundRequest{
+		RefundID:         refund.ID,
+		PaymentReference: paymentReference,
+		Amount:           toMinorUnits(refund.Amount),
+		Currency:         transaction.Currency,
+		Reason:           refund.Reason,
+	})
+	if err != nil {
+		// Hand the refund back for another attempt
+		if revertErr := s.refundRepo.UpdateStatus(refund.ID, RefundProcessing, RefundRequested, nil, nil, nil); revertErr != nil {
+			return nil, fmt.Errorf("%w: %v (reverting refund: %v)", ErrPaymentProvider, err, revertErr)
+		}
+		return nil, fmt.Errorf("%w: %v", ErrPaymentProvider, err)
+	}
+
+	reference := s.provider.Name() + ":" + result.Reference
+	switch result.Status {
+	case PaymentCompleted:
+		return s.refundRepo.Complete(refund.ID, RefundProcessing, nil, nil, &reference)
+	case PaymentFailed:
+		if err := s.refundRepo.UpdateStatus(refund.ID, RefundProcessing, RefundFailed, nil, nil, &reference); err != nil {
+			return nil, err
+		}
+		refund.Status = RefundFailed
+	default:
+		if err := s.refundRepo.UpdateStatus(refund.ID, RefundProcessing, RefundProcessing, nil, nil, &reference); err != nil {
+			return nil, err
+		}
+		refund.Status = RefundProcessing
+	}
+
+	refund.ReviewedBy = &reviewerID
+	refund.ReviewNote = note
+	return refund, nil
+}
+
+// Reject declines a requested refund.
+func (s *RefundService) Reject(reviewerID string, isAdmin bool, refundID string, note *string) (*models.Refund, error) {
+	refund, err := s.reviewable(reviewerID, isAdmin, refundID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.refundRepo.UpdateStatus(refund.ID, RefundRequested, RefundRejected, &reviewerID, note, nil); err != nil {
+		if err == repositories.ErrStatusConflict {
+			return nil, ErrRefundNotOpen
+		}
+		return nil, err
+	}
+
+	refund.Status = RefundRejected
+	refund.ReviewedBy = &reviewerID
+	refund.ReviewNote = note
+	return refund, nil
+}
+
+// reviewable loads a requested refund the reviewer may decide on.
+func (s *RefundService) reviewable(reviewerID string, isAdmin bool, refundID string) (*models.Refund, error) {
+	refund, err := s.Get(reviewerID, isAdmin, refundID)
+	if err != nil {
+		return nil, err
+	}
+	if !isAdmin && refund.Transaction.SellerID != reviewerID {
+		return nil, ErrNotRefundReviewer
+	}
+	if refund.Status != RefundRequested {
+		return nil, ErrRefundNotOpen
+	}
+	return refund, nil
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"viport-backend/internal/models"
+	"viport-backend/internal/repositories"
+)
+
+var ErrUnsupportedReviewableType = errors.New("unsupported reviewable type")
+
+// ReviewService manages product and course reviews, their helpful votes and
+// the rating aggregates they drive.
+type ReviewService struct {
+	reviewRepo *repositories.ReviewRepository
+}
+
+func NewReviewService(db *sql.DB) *ReviewService {
+	return &ReviewService{
+		reviewRepo: repositories.NewReviewRepository(db),
+	}
+}
+
+// Create posts the user's review of an item. Each user reviews an item once.
+func (s *ReviewService) Create(userID, reviewableType, reviewableID string, req *models.CreateReviewRequest) (*models.Review, error) {
+	if !repositories.IsReviewableType(reviewableType) {
+		return nil, ErrUnsupportedReviewableType
+	}
+
+	review := &models.Review{
+		UserID:         userID,
+		ReviewableType: reviewableType,
+		ReviewableID:   reviewableID,
+		Rating:         req.Rating,
+		Title:          req.Title,
+		Content:        req.Content,
+	}
+	if err := s.reviewRepo.Create(review); err != nil {
+		return nil, err
+	}
+	return s.reviewRepo.GetByID(review.ID)
+}
+
+// Update replaces the rating, title and content of a review.
+func (s *ReviewService) Update(id string, req *models.UpdateReviewRequest) (*models.Review, error) {
+	review, err := s.reviewRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	review.Rating = req.Rating
+	review.Title = req.Title
+	review.Content = req.Content
+	if err := s.reviewRepo.Update(review); err != nil {
+		return nil, err
+	}
+	return review, nil
+}
+
+func (s *ReviewService) Delete(id string) error {
+	return s.reviewRepo.Delete(id)
+}
+
+// List returns a page of reviews on an item the viewer may see, with the
+// viewer's helpful votes marked.
+func (s *ReviewService) List(filter models.ReviewFilter, viewerID string) ([]*models.Review, int, error) {
+	if !repositories.IsReviewableType(filter.ReviewableType) {
+		return nil, 0, ErrUnsupportedReviewableType
+	}
+	if err := s.reviewRepo.CanView(filter.ReviewableType, filter.ReviewableID, viewerID); err != nil {
+		return nil, 0, err
+	}
+
+	reviews, total, err := s.reviewRepo.List(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if viewerID != "" && len(reviews) > 0 {
+		ids := make([]string, len(reviews))
+		for i, review := range reviews {
+			ids[i] = review.ID
+		}
+		helpful, err := s.reviewRepo.HelpfulIDs(viewerID, ids)
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, review := range reviews {
+			review.IsHelpful = helpful[review.ID]
+		}
+	}
+
+	return reviews, total, nil
+}
+
+// Summary returns the rating aggregate and histogram of an item the viewer
+// may see.
+func (s *ReviewService) Summary(reviewableType, reviewableID, viewerID string) (*models.RatingSummary, error) {
+	if !repositories.IsReviewableType(reviewableType) {
+		return nil, ErrUnsupportedReviewableType
+	}
+	if err := s.reviewRepo.CanView(reviewableType, reviewableID, viewerID); err != nil {
+		return nil, err
+	}
+	return s.reviewRepo.Summary(reviewableType, reviewableID)
+}
+
+// Vote marks the review helpful to the user and returns its helpful count.
+func (s *ReviewService) Vote(reviewID, userID string) (int, error) {
+	return s.reviewRepo.Vote(reviewID, userID)
+}
+
+// Unvote withdraws the user's helpful vote and returns the helpful count.
+func (s *ReviewService) Unvote(reviewID, userID string) (int, error) {
+	return s.reviewRepo.Unvote(reviewID, userID)
+}
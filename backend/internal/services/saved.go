@@ -0,0 +1,196 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"viport-backend/internal/models"
+	"viport-backend/internal/repositories"
+)
+
+var ErrUnsupportedSaveableType = errors.New("unsupported saveable type")
+
+// SavedService keeps users' saved posts, products and courses and their
+// collections, and resolves the viewer's saved flags on read paths.
+type SavedService struct {
+	savedRepo   *repositories.SavedItemRepository
+	postRepo    *repositories.PostRepository
+	productRepo *repositories.ProductRepository
+	courseRepo  *repositories.CourseRepository
+}
+
+func NewSavedService(db *sql.DB) *SavedService {
+	return &SavedService{
+		savedRepo:   repositories.NewSavedItemRepository(db),
+		postRepo:    repositories.NewPostRepository(db),
+		productRepo: repositories.NewProductRepository(db),
+		courseRepo:  repositories.NewCourseRepository(db),
+	}
+}
+
+// Save adds the item to the user's saved items, in the given collection
+// when collectionID is set.
+func (s *SavedService) Save(userID, saveableType, saveableID string, collectionID *string) (*models.SavedItem, error) {
+	if !repositories.IsSaveableType(saveableType) {
+		return nil, ErrUnsupportedSaveableType
+	}
+	return s.savedRepo.Save(userID, saveableType, saveableID, collectionID)
+}
+
+// Unsave removes the item from the user's saved items.
+func (s *SavedService) Unsave(userID, saveableType, saveableID string) error {
+	if !repositories.IsSaveableType(saveableType) {
+		return ErrUnsupportedSaveableType
+	}
+	return s.savedRepo.Unsave(userID, saveableType, saveableID)
+}
+
+// List returns a page of the user's saved items with the items themselves,
+// newest first, and the total count. It returns
+// repositories.ErrCollectionNotFound when collectionID is not the user's.
+func (s *SavedService) List(userID, saveableType string, collectionID *string, limit, offset int) ([]*models.SavedItem, int, error) {
+	if saveableType != "" && !repositories.IsSaveableType(saveableType) {
+		return nil, 0, ErrUnsupportedSaveableType
+	}
+	if collectionID != nil {
+		if _, err := s.savedRepo.GetCollection(*collectionID, userID); err != nil {
+			if err == sql.ErrNoRows {
+				return nil, 0, repositories.ErrCollectionNotFound
+			}
+			return nil, 0, err
+		}
+	}
+
+	items, total, err := s.savedRepo.List(userID, saveableType, collectionID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ids := map[string][]string{}
+	for _, item := range items {
+		ids[item.ItemType] = append(ids[item.ItemType], item.ItemID)
+	}
+
+	posts, err := s.postRepo.GetByIDs(ids["post"])
+	if err != nil {
+		return nil, 0, err
+	}
+	products, err := s.productRepo.GetByIDs(ids["product"])
+	if err != nil {
+		return nil, 0, err
+	}
+	courses, err := s.courseRepo.GetByIDs(ids["course"])
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for _, item := range items {
+		switch item.ItemType {
+		case "post":
+			if item.Post = posts[item.ItemID]; item.Post != nil {
+				item.Post.IsSaved = true
+			}
+		case "product":
+			if item.Product = products[item.ItemID]; item.Product != nil {
+				item.Product.IsInWishlist = true
+			}
+		case "course":
+			if item.Course = courses[item.ItemID]; item.Course != nil {
+				item.Course.IsInWishlist = true
+			}
+		}
+	}
+	return items, total, nil
+}
+
+// MarkPosts sets IsSaved on each post the viewer has saved.
+func (s *SavedService) MarkPosts(viewerID string, posts []*models.Post) error {
+	ids := make([]string, len(posts))
+	for i, post := range posts {
+		ids[i] = post.ID
+	}
+
+	saved, err := s.savedIDs(viewerID, "post", ids)
+	if err != nil {
+		return err
+	}
+	for _, post := range posts {
+		post.IsSaved = saved[post.ID]
+	}
+	return nil
+}
+
+// MarkProducts sets IsInWishlist on each product the viewer has saved.
+func (s *SavedService) MarkProducts(viewerID string, products []*models.Product) error {
+	ids := make([]string, len(products))
+	for i, product := range products {
+		ids[i] = product.ID
+	}
+
+	saved, err := s.savedIDs(viewerID, "product", ids)
+	if err != nil {
+		return err
+	}
+	for _, product := range products {
+		product.IsInWishlist = saved[product.ID]
+	}
+	return nil
+}
+
+// MarkCourses sets IsInWishlist on each course the viewer has saved.
+func (s *SavedService) MarkCourses(viewerID string, courses []*models.Course) error {
+	ids := make([]string, len(courses))
+	for i, course := range courses {
+		ids[i] = course.ID
+	}
+
+	saved, err := s.savedIDs(viewerID, "course", ids)
+	if err != nil {
+		return err
+	}
+	for _, course := range courses {
+		course.IsInWishlist = saved[course.ID]
+	}
+	return nil
+}
+
+// savedIDs skips the lookup entirely for anonymous viewers.
+func (s *SavedService) savedIDs(viewerID, saveableType string, ids []string) (map[string]bool, error) {
+	if viewerID == "" || len(ids) == 0 || !s.savedRepo.IsConnected() {
+		return map[string]bool{}, nil
+	}
+	return s.savedRepo.SavedIDs(viewerID, saveableType, ids)
+}
+
+func (s *SavedService) ListCollections(userID string) ([]*models.SavedCollection, error) {
+	return s.savedRepo.ListCollections(userID)
+}
+
+func (s *SavedService) CreateCollection(userID string, req *models.SavedCollectionRequest) (*models.SavedCollection, error) {
+	collection := &models.SavedCollection{
+		UserID:      userID,
+		Name:        req.Name,
+		Description: req.Description,
+	}
+	if err := s.savedRepo.CreateCollection(collection); err != nil {
+		return nil, err
+	}
+	return collection, nil
+}
+
+func (s *SavedService) UpdateCollection(id, userID string, req *models.SavedCollectionRequest) (*models.SavedCollection, error) {
+	collection := &models.SavedCollection{
+		ID:          id,
+		UserID:      userID,
+		Name:        req.Name,
+		Description: req.Description,
+	}
+	if err := s.savedRepo.UpdateCollection(collection); err != nil {
+		return nil, err
+	}
+	return collection, nil
+}
+
+// DeleteCollection removes the collection; its items stay saved.
+func (s *SavedService) DeleteCollection(id, userID string) error {
+	return s.savedRepo.DeleteCollection(id, userID)
+}
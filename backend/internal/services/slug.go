@@ -0,0 +1,31 @@
+package services
+
+import (
+	"strings"
+	"unicode"
+)
+
+// slugify lowercases s and joins its runs of letters and digits with single
+// hyphens, keeping at most maxLength characters without a trailing hyphen.
+func slugify(s string, maxLength int) string {
+	var b strings.Builder
+	pendingHyphen := false
+	length := 0
+	for _, r := range strings.ToLower(s) {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			pendingHyphen = b.Len() > 0
+			continue
+		}
+		if (pendingHyphen && length >= maxLength-1) || length >= maxLength {
+			break
+		}
+		if pendingHyphen {
+			b.WriteByte('-')
+			length++
+			pendingHyphen = false
+		}
+		b.WriteRune(r)
+		length++
+	}
+	return b.String()
+}
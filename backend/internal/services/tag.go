@@ -0,0 +1,90 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+	"viport-backend/internal/models"
+	"viport-backend/internal/repositories"
+)
+
+var ErrUnsupportedTaggableType = errors.New("unsupported taggable type")
+
+// maxTagLength matches the width of the tags.name and tags.slug columns.
+const maxTagLength = 50
+
+// TagService normalizes free-form tag names, links them to posts and
+// products and answers the tag discovery queries.
+type TagService struct {
+	tagRepo *repositories.TagRepository
+}
+
+func NewTagService(db *sql.DB) *TagService {
+	return &TagService{
+		tagRepo: repositories.NewTagRepository(db),
+	}
+}
+
+// SetTags replaces the tags of the item with names and returns the stored
+// tags. Names are normalized as by TagsFromNames.
+func (s *TagService) SetTags(taggableType, itemID string, names []string) ([]models.Tag, error) {
+	if !repositories.IsTaggableType(taggableType) {
+		return nil, ErrUnsupportedTaggableType
+	}
+	return s.tagRepo.SetTags(taggableType, itemID, TagsFromNames(names))
+}
+
+// TagsFromNames normalizes tag names as entered by users into the tags to
+// store, for items created or updated along with their tags. The result is
+// never nil, so an empty list still clears an item's tags.
+func TagsFromNames(names []string) []models.Tag {
+	tags := []models.Tag{}
+	seen := make(map[string]bool)
+	for _, name := range names {
+		name = normalizeTagName(name)
+		slug := NormalizeTagSlug(name)
+		if slug == "" || seen[slug] {
+			continue
+		}
+		seen[slug] = true
+		tags = append(tags, models.Tag{Name: name, Slug: slug})
+	}
+	return tags
+}
+
+// GetBySlug looks a tag up by slug, normalizing the slug first so that
+// "/tags/Web Design" finds "web-design".
+func (s *TagService) GetBySlug(slug string) (*models.Tag, error) {
+	slug = NormalizeTagSlug(slug)
+	if slug == "" {
+		return nil, sql.ErrNoRows
+	}
+	return s.tagRepo.GetBySlug(slug)
+}
+
+// Autocomplete suggests the most used tags starting with query.
+func (s *TagService) Autocomplete(query string, limit int) ([]models.Tag, error) {
+	return s.tagRepo.Search(NormalizeTagSlug(query), limit)
+}
+
+// Trending returns the tags used most on content published in the last days.
+func (s *TagService) Trending(days, limit int) ([]models.Tag, error) {
+	return s.tagRepo.Trending(time.Now().AddDate(0, 0, -days), limit)
+}
+
+// NormalizeTagSlug lowercases name and joins its runs of letters and digits
+// with single hyphens, e.g. "#Web  Design!" becomes "web-design".
+func NormalizeTagSlug(name string) string {
+	return slugify(name, maxTagLength)
+}
+
+// normalizeTagName trims a leading "#", collapses whitespace and caps the
+// length of a tag name as entered by the user.
+func normalizeTagName(name string) string {
+	name = strings.Join(strings.Fields(strings.TrimLeft(strings.TrimSpace(name), "#")), " ")
+	if runes := []rune(name); len(runes) > maxTagLength {
+		name = strings.TrimSpace(string(runes[:maxTagLength]))
+	}
+	return name
+}
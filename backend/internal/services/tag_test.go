@@ -0,0 +1,52 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeTagSlug(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Design", "design"},
+		{"#Web  Design!", "web-design"},
+		{"  go   lang  ", "go-lang"},
+		{"C++ / C#", "c-c"},
+		{"web-design", "web-design"},
+		{"Crème Brûlée", "crème-brûlée"},
+		{"日本語", "日本語"},
+		{"3D Models", "3d-models"},
+		{"!!!", ""},
+		{"", ""},
+		{strings.Repeat("a", 60), strings.Repeat("a", 50)},
+		{strings.Repeat("a", 49) + " b", strings.Repeat("a", 49)},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeTagSlug(tt.name); got != tt.want {
+			t.Errorf("NormalizeTagSlug(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTagsFromNames(t *testing.T) {
+	tags := TagsFromNames([]string{"#Web Design", "web-design", "  Go  ", "!!!"})
+	want := []string{"web-design", "go"}
+	if len(tags) != len(want) {
+		t.Fatalf("TagsFromNames() returned %d tags, want %d", len(tags), len(want))
+	}
+	for i, slug := range want {
+		if tags[i].Slug != slug {
+			t.Errorf("tag %d slug = %q, want %q", i, tags[i].Slug, slug)
+		}
+	}
+	if tags[0].Name != "Web Design" {
+		t.Errorf("tag 0 name = %q, want %q", tags[0].Name, "Web Design")
+	}
+
+	if tags := TagsFromNames(nil); tags == nil || len(tags) != 0 {
+		t.Errorf("TagsFromNames(nil) = %#v, want an empty slice", tags)
+	}
+}
@@ -0,0 +1,11 @@
+-- Products and courses can be liked like posts, so they keep the same
+-- denormalized like_count
+ALTER TABLE products ADD COLUMN like_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE courses ADD COLUMN like_count INTEGER NOT NULL DEFAULT 0;
+
+UPDATE products p SET like_count = (
+    SELECT COUNT(*) FROM likes l WHERE l.likeable_type = 'product' AND l.likeable_id = p.id
+);
+UPDATE courses co SET like_count = (
+    SELECT COUNT(*) FROM likes l WHERE l.likeable_type = 'course' AND l.likeable_id = co.id
+);
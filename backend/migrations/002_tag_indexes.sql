@@ -0,0 +1,4 @@
+-- Lookups of content by tag
+CREATE INDEX idx_post_tags_tag_id ON post_tags(tag_id);
+CREATE INDEX idx_product_tags_tag_id ON product_tags(tag_id);
+CREATE INDEX idx_tags_usage_count ON tags(usage_count DESC);
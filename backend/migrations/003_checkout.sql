@@ -0,0 +1,7 @@
+-- A buyer holds at most one open or completed purchase of an item; failed
+-- and refunded attempts may be retried
+CREATE UNIQUE INDEX uniq_transactions_open_purchase
+    ON transactions(buyer_id, item_type, item_id)
+    WHERE payment_status IN ('pending', 'completed');
+
+CREATE INDEX idx_transactions_item ON transactions(item_type, item_id);
@@ -0,0 +1,11 @@
+-- Webhook events received from the payment provider, kept so redelivered
+-- events are recognised and skipped
+CREATE TABLE payment_events (
+    id VARCHAR(255) PRIMARY KEY, -- provider's event ID
+    type VARCHAR(50) NOT NULL,
+    transaction_id UUID REFERENCES transactions(id),
+    outcome VARCHAR(20) NOT NULL, -- applied, ignored
+    received_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_payment_events_transaction_id ON payment_events(transaction_id);
@@ -0,0 +1,27 @@
+-- Amount already returned to the buyer. fee_amount and net_amount are
+-- reduced by each refund's share so they always reflect what the platform
+-- and the seller keep.
+ALTER TABLE transactions ADD COLUMN refunded_amount DECIMAL(10,2) NOT NULL DEFAULT 0;
+
+-- Refund requests and card disputes against completed transactions
+CREATE TABLE refunds (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    transaction_id UUID NOT NULL REFERENCES transactions(id),
+    kind VARCHAR(20) NOT NULL DEFAULT 'refund', -- refund, dispute
+    requested_by UUID REFERENCES users(id), -- NULL for disputes opened by the card issuer
+    reason TEXT NOT NULL,
+    amount DECIMAL(10,2) NOT NULL CHECK (amount > 0),
+    status VARCHAR(20) NOT NULL DEFAULT 'requested', -- requested, processing, completed, rejected, failed
+    reviewed_by UUID REFERENCES users(id),
+    review_note TEXT,
+    provider_reference VARCHAR(255) UNIQUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    resolved_at TIMESTAMP WITH TIME ZONE
+);
+
+-- At most one refund or dispute open per transaction at a time
+CREATE UNIQUE INDEX uniq_refunds_open
+    ON refunds(transaction_id)
+    WHERE status IN ('requested', 'processing');
+
+CREATE INDEX idx_refunds_transaction_id ON refunds(transaction_id);
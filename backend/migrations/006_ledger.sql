@@ -0,0 +1,90 @@
+-- Double-entry ledger for money moving through the marketplace. Every
+-- journal is a set of entries in integer minor units (cents) that sum to
+-- zero: debits are positive, credits negative. Accounts are
+--   cash             money held by the platform through the payment provider
+--   platform_fees    the platform's share of each sale
+--   creator_payable  what the platform owes a creator (user_id is the creator)
+CREATE TABLE ledger_journals (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    kind VARCHAR(20) NOT NULL, -- opening, sale, refund, payout
+    transaction_id UUID REFERENCES transactions(id),
+    refund_id UUID REFERENCES refunds(id),
+    currency VARCHAR(3) NOT NULL DEFAULT 'USD',
+    description TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE ledger_entries (
+    id BIGSERIAL PRIMARY KEY,
+    journal_id UUID NOT NULL REFERENCES ledger_journals(id),
+    account VARCHAR(30) NOT NULL,
+    user_id UUID REFERENCES users(id),
+    amount BIGINT NOT NULL CHECK (amount <> 0),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CHECK ((account = 'creator_payable') = (user_id IS NOT NULL))
+);
+
+-- A transaction is recognised once, and each refund is reversed once
+CREATE UNIQUE INDEX uniq_ledger_journals_sale
+    ON ledger_journals(transaction_id)
+    WHERE kind IN ('opening', 'sale');
+CREATE UNIQUE INDEX uniq_ledger_journals_refund
+    ON ledger_journals(refund_id)
+    WHERE refund_id IS NOT NULL;
+
+CREATE INDEX idx_ledger_journals_transaction_id ON ledger_journals(transaction_id);
+CREATE INDEX idx_ledger_entries_journal_id ON ledger_entries(journal_id);
+CREATE INDEX idx_ledger_entries_account_user ON ledger_entries(account, user_id);
+
+-- Journals must balance when the writing transaction commits
+CREATE FUNCTION ledger_check_journal_balance() RETURNS trigger AS $$
+BEGIN
+    IF (SELECT SUM(amount) FROM ledger_entries WHERE journal_id = NEW.journal_id) <> 0 THEN
+        RAISE EXCEPTION 'ledger journal % does not balance', NEW.journal_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER ledger_entries_balanced
+    AFTER INSERT ON ledger_entries
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW EXECUTE FUNCTION ledger_check_journal_balance();
+
+-- The ledger is append-only; corrections are new journals
+CREATE FUNCTION ledger_reject_change() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'ledger rows cannot be changed';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER ledger_journals_append_only
+    BEFORE UPDATE OR DELETE ON ledger_journals
+    FOR EACH ROW EXECUTE FUNCTION ledger_reject_change();
+CREATE TRIGGER ledger_entries_append_only
+    BEFORE UPDATE OR DELETE ON ledger_entries
+    FOR EACH ROW EXECUTE FUNCTION ledger_reject_change();
+
+-- Carry the balances of transactions settled before the ledger existed.
+-- fee_amount and net_amount already exclude earlier refunds, so the
+-- opening journal holds what the platform still keeps of each sale.
+CREATE TEMPORARY TABLE ledger_opening AS
+SELECT gen_random_uuid() AS journal_id, id, seller_id, currency,
+       ROUND(fee_amount * 100)::BIGINT AS fee,
+       ROUND(net_amount * 100)::BIGINT AS net
+FROM transactions
+WHERE payment_status IN ('completed', 'refunded')
+  AND ROUND(fee_amount * 100) + ROUND(net_amount * 100) <> 0;
+
+INSERT INTO ledger_journals (id, kind, transaction_id, currency, description)
+SELECT journal_id, 'opening', id, COALESCE(currency, 'USD'), 'Balance carried over at ledger start'
+FROM ledger_opening;
+
+INSERT INTO ledger_entries (journal_id, account, user_id, amount)
+SELECT journal_id, 'cash', NULL, fee + net FROM ledger_opening
+UNION ALL
+SELECT journal_id, 'platform_fees', NULL, -fee FROM ledger_opening WHERE fee <> 0
+UNION ALL
+SELECT journal_id, 'creator_payable', seller_id, -net FROM ledger_opening WHERE net <> 0;
+
+DROP TABLE ledger_opening;
@@ -0,0 +1,32 @@
+-- Platform fee rules. A sale's fee is percentage of the price plus
+-- fixed_amount, capped at the price, from the matching active rule with the
+-- highest priority; ties go to the most specific rule. NULL criteria match
+-- anything, category_id also matches products in its subcategories, and
+-- starts_at/ends_at limit promotional rules to a window.
+CREATE TABLE fee_rules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    category_id UUID REFERENCES categories(id),
+    license_type VARCHAR(50), -- standard, extended, commercial
+    verification_level VARCHAR(20), -- seller's level: none, email, phone, identity, creator
+    percentage DECIMAL(5,2) NOT NULL DEFAULT 0 CHECK (percentage >= 0 AND percentage <= 100),
+    fixed_amount DECIMAL(10,2) NOT NULL DEFAULT 0 CHECK (fixed_amount >= 0),
+    priority INTEGER NOT NULL DEFAULT 0,
+    starts_at TIMESTAMP WITH TIME ZONE,
+    ends_at TIMESTAMP WITH TIME ZONE,
+    is_active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CHECK (ends_at IS NULL OR starts_at IS NULL OR ends_at > starts_at)
+);
+
+CREATE INDEX idx_fee_rules_active ON fee_rules(is_active);
+
+-- The fee previously hard-coded in checkout
+INSERT INTO fee_rules (name, description, percentage)
+VALUES ('Standard fee', 'Default platform fee on every sale', 10.00);
+
+-- The rule that priced each transaction; NULL for free items and sales
+-- made before fee rules existed
+ALTER TABLE transactions ADD COLUMN fee_rule_id UUID REFERENCES fee_rules(id);
@@ -0,0 +1,64 @@
+-- Where creators receive their earnings. Removed methods are kept for the
+-- payouts that used them.
+CREATE TABLE payout_methods (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL, -- bank_account, paypal
+    label VARCHAR(100),
+    destination VARCHAR(255) NOT NULL,
+    destination_hint VARCHAR(20) NOT NULL, -- last characters of destination, safe to display
+    is_default BOOLEAN DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    removed_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX uniq_payout_methods_default
+    ON payout_methods(user_id)
+    WHERE is_default AND removed_at IS NULL;
+
+CREATE INDEX idx_payout_methods_user_id ON payout_methods(user_id);
+
+-- Per-creator payout preferences; creators without a row use the defaults
+CREATE TABLE payout_settings (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    minimum_amount BIGINT NOT NULL CHECK (minimum_amount > 0), -- minor units
+    on_hold BOOLEAN NOT NULL DEFAULT FALSE, -- set by admins to stop payouts
+    hold_reason TEXT,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Scheduled runs that turn eligible balances into payouts
+CREATE TABLE payout_batches (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    payout_count INTEGER NOT NULL DEFAULT 0,
+    total_amount BIGINT NOT NULL DEFAULT 0,
+    paid_count INTEGER NOT NULL DEFAULT 0,
+    failed_count INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    completed_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE TABLE payouts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id),
+    method_id UUID NOT NULL REFERENCES payout_methods(id),
+    batch_id UUID REFERENCES payout_batches(id),
+    amount BIGINT NOT NULL CHECK (amount > 0), -- minor units
+    currency VARCHAR(3) NOT NULL DEFAULT 'USD',
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, held, processing, paid, failed
+    provider_reference VARCHAR(255) UNIQUE,
+    failure_reason TEXT,
+    hold_reason TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    paid_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_payouts_user_id ON payouts(user_id);
+CREATE INDEX idx_payouts_status ON payouts(status);
+
+-- Paid payouts are posted to the ledger once
+ALTER TABLE ledger_journals ADD COLUMN payout_id UUID REFERENCES payouts(id);
+CREATE UNIQUE INDEX uniq_ledger_journals_payout
+    ON ledger_journals(payout_id)
+    WHERE payout_id IS NOT NULL;
@@ -0,0 +1,13 @@
+-- Per-buyer download limit of a product; NULL uses the platform default
+ALTER TABLE products ADD COLUMN download_limit INTEGER CHECK (download_limit > 0);
+
+-- Every file download by a buyer, counted against the download limit
+CREATE TABLE product_downloads (
+    id BIGSERIAL PRIMARY KEY,
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    file_index INTEGER NOT NULL,
+    downloaded_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_product_downloads_product_user ON product_downloads(product_id, user_id);
@@ -0,0 +1,28 @@
+-- Named collections a user can file saved items under
+CREATE TABLE saved_collections (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(user_id, name)
+);
+
+-- Posts, products and courses a user saved, optionally in a collection.
+-- Deleting a collection keeps its items saved.
+CREATE TABLE saved_items (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    saveable_type VARCHAR(50) NOT NULL CHECK (saveable_type IN ('post', 'product', 'course')),
+    saveable_id UUID NOT NULL,
+    collection_id UUID REFERENCES saved_collections(id) ON DELETE SET NULL,
+    -- Lowest product price the user was alerted about; NULL while not on sale
+    alerted_price DECIMAL(10,2),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(user_id, saveable_type, saveable_id)
+);
+
+CREATE INDEX idx_saved_items_user_created ON saved_items(user_id, created_at DESC);
+CREATE INDEX idx_saved_items_saveable ON saved_items(saveable_type, saveable_id);
+CREATE INDEX idx_saved_items_collection_id ON saved_items(collection_id);
@@ -0,0 +1,35 @@
+-- "Helpful" votes on reviews, counted in reviews.helpful_count on top of
+-- any count the review had before votes were recorded
+CREATE TABLE review_votes (
+    review_id UUID NOT NULL REFERENCES reviews(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (review_id, user_id)
+);
+
+CREATE INDEX idx_reviews_reviewable_created ON reviews(reviewable_type, reviewable_id, created_at DESC, id DESC);
+CREATE INDEX idx_reviews_reviewable_helpful ON reviews(reviewable_type, reviewable_id, helpful_count DESC, id DESC);
+
+-- Replace placeholder ratings with the aggregates of the actual reviews
+UPDATE products p SET
+    rating_average = COALESCE((
+        SELECT ROUND(AVG(r.rating), 2) FROM reviews r
+        WHERE r.reviewable_type = 'product' AND r.reviewable_id = p.id
+    ), 0),
+    rating_count = (
+        SELECT COUNT(*) FROM reviews r
+        WHERE r.reviewable_type = 'product' AND r.reviewable_id = p.id
+    );
+
+UPDATE courses c SET
+    rating_average = COALESCE((
+        SELECT ROUND(AVG(r.rating), 2) FROM reviews r
+        WHERE r.reviewable_type = 'course' AND r.reviewable_id = c.id
+    ), 0),
+    rating_count = (
+        SELECT COUNT(*) FROM reviews r
+        WHERE r.reviewable_type = 'course' AND r.reviewable_id = c.id
+    );
+
+-- Counts from before review_votes existed are kept; new votes add to them
+UPDATE reviews SET helpful_count = 0 WHERE helpful_count IS NULL;
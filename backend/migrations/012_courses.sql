@@ -0,0 +1,15 @@
+-- Lessons are ordered by a 1-based position within their course
+UPDATE lessons l SET sort_order = o.position
+FROM (
+    SELECT id, ROW_NUMBER() OVER (
+        PARTITION BY course_id ORDER BY sort_order NULLS LAST, created_at, id
+    ) AS position
+    FROM lessons
+) o
+WHERE l.id = o.id;
+
+ALTER TABLE lessons ALTER COLUMN sort_order SET DEFAULT 0;
+ALTER TABLE lessons ALTER COLUMN sort_order SET NOT NULL;
+
+CREATE INDEX idx_lessons_course_sort ON lessons(course_id, sort_order);
+CREATE INDEX idx_courses_status_created ON courses(status, created_at DESC);
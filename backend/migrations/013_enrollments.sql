@@ -0,0 +1,24 @@
+-- Lessons a student completed. Progress is completed over total lessons.
+CREATE TABLE lesson_completions (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    lesson_id UUID NOT NULL REFERENCES lessons(id) ON DELETE CASCADE,
+    course_id UUID NOT NULL REFERENCES courses(id) ON DELETE CASCADE,
+    completed_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (user_id, lesson_id)
+);
+
+CREATE INDEX idx_lesson_completions_user_course ON lesson_completions(user_id, course_id);
+
+-- Where a student left off, so deleting that lesson must not be blocked
+ALTER TABLE enrollments ADD COLUMN last_accessed_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE enrollments DROP CONSTRAINT enrollments_last_accessed_lesson_id_fkey;
+ALTER TABLE enrollments ADD CONSTRAINT enrollments_last_accessed_lesson_id_fkey
+    FOREIGN KEY (last_accessed_lesson_id) REFERENCES lessons(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_enrollments_course_id ON enrollments(course_id);
+CREATE INDEX idx_enrollments_user_accessed ON enrollments(user_id, last_accessed_at DESC NULLS LAST, enrollment_date DESC);
+
+-- Enrollments were granted without counting them
+UPDATE courses co SET enrollment_count = (
+    SELECT COUNT(*) FROM enrollments e WHERE e.course_id = co.id
+);
@@ -0,0 +1,15 @@
+-- Course completion certificates. Names are kept as issued so verification
+-- shows what the certificate says even after profiles or courses change.
+CREATE TABLE certificates (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    course_id UUID NOT NULL REFERENCES courses(id) ON DELETE CASCADE,
+    verification_code VARCHAR(32) NOT NULL UNIQUE,
+    learner_name VARCHAR(255) NOT NULL,
+    course_title VARCHAR(255) NOT NULL,
+    instructor_name VARCHAR(255) NOT NULL,
+    completed_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    url TEXT NOT NULL,
+    issued_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(user_id, course_id)
+);
@@ -0,0 +1,34 @@
+-- Graded quizzes attached to lessons, one per lesson. Questions, with their
+-- answers, are stored as JSON and scored by the server.
+CREATE TABLE quizzes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    lesson_id UUID NOT NULL UNIQUE REFERENCES lessons(id) ON DELETE CASCADE,
+    course_id UUID NOT NULL REFERENCES courses(id) ON DELETE CASCADE,
+    title VARCHAR(255) NOT NULL,
+    description TEXT,
+    pass_mark INTEGER NOT NULL DEFAULT 70 CHECK (pass_mark BETWEEN 1 AND 100),
+    -- NULL allows unlimited attempts
+    max_attempts INTEGER CHECK (max_attempts > 0),
+    -- Whether the lesson only counts towards progress once the quiz is passed
+    required_to_complete BOOLEAN NOT NULL DEFAULT FALSE,
+    questions JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_quizzes_course_id ON quizzes(course_id);
+
+CREATE TABLE quiz_attempts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    quiz_id UUID NOT NULL REFERENCES quizzes(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    answers JSONB NOT NULL DEFAULT '[]',
+    results JSONB NOT NULL DEFAULT '[]',
+    score INTEGER NOT NULL,
+    max_score INTEGER NOT NULL,
+    percentage INTEGER NOT NULL,
+    passed BOOLEAN NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_quiz_attempts_quiz_user ON quiz_attempts(quiz_id, user_id, created_at DESC);
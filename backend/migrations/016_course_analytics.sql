@@ -0,0 +1,6 @@
+-- Course analytics group a course's completions by lesson and its
+-- enrollments by date
+CREATE INDEX idx_lesson_completions_course_lesson ON lesson_completions(course_id, lesson_id);
+
+DROP INDEX idx_enrollments_course_id;
+CREATE INDEX idx_enrollments_course_enrolled ON enrollments(course_id, enrollment_date);
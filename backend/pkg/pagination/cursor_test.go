@@ -0,0 +1,73 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestCodecRoundTrip(t *testing.T) {
+	codec := NewCodec("secret")
+	cursor := Cursor{Kind: "posts", SortBy: "created_at", SortOrder: "desc", Value: "2024-01-02T03:04:05Z", ID: "abc", Backward: true}
+
+	got, err := codec.Decode(codec.Encode(cursor))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if *got != cursor {
+		t.Errorf("Decode() = %+v, want %+v", *got, cursor)
+	}
+}
+
+func TestCodecDecodeInvalid(t *testing.T) {
+	codec := NewCodec("secret")
+	token := codec.Encode(Cursor{Kind: "posts", SortBy: "created_at", SortOrder: "desc", Value: "1", ID: "abc"})
+	body, signature, _ := strings.Cut(token, ".")
+
+	// A payload with a different kind, signed with the wrong secret
+	forged := NewCodec("other").Encode(Cursor{Kind: "users", SortBy: "created_at", Value: "1", ID: "abc"})
+	// A payload edited after signing
+	edited := base64.RawURLEncoding.EncodeToString([]byte(`{"k":"users","s":"created_at","o":"desc","v":"1","id":"abc"}`))
+
+	sign := func(payload string) string {
+		body := base64.RawURLEncoding.EncodeToString([]byte(payload))
+		return body + "." + base64.RawURLEncoding.EncodeToString(codec.sign(body))
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"empty", ""},
+		{"no signature", body},
+		{"signature of another body", edited + "." + signature},
+		{"truncated signature", body + "." + signature[:len(signature)-2]},
+		{"signature not base64", body + ".!!!"},
+		{"other secret", forged},
+		{"not JSON", sign(`not json`)},
+		{"missing kind", sign(`{"s":"created_at","o":"desc","v":"1","id":"abc"}`)},
+		{"missing sort", sign(`{"k":"posts","o":"desc","v":"1","id":"abc"}`)},
+		{"missing id", sign(`{"k":"posts","s":"created_at","o":"desc","v":"1"}`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := codec.Decode(tt.token); err != ErrInvalidCursor {
+				t.Errorf("Decode() error = %v, want %v", err, ErrInvalidCursor)
+			}
+		})
+	}
+}
+
+func TestCodecKeepsKind(t *testing.T) {
+	codec := NewCodec("secret")
+	for _, kind := range []string{"users", "posts", "followers"} {
+		cursor, err := codec.Decode(codec.Encode(Cursor{Kind: kind, SortBy: "created_at", ID: "abc"}))
+		if err != nil {
+			t.Fatalf("Decode() error = %v", err)
+		}
+		if cursor.Kind != kind {
+			t.Errorf("Decode() kind = %q, want %q", cursor.Kind, kind)
+		}
+	}
+}
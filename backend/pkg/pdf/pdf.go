@@ -0,0 +1,247 @@
+// Package pdf writes simple PDF documents: pages of text in the standard
+// Helvetica fonts, lines and rectangles. It needs no font files, as every
+// PDF reader ships the standard fonts.
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Font is one of the standard fonts.
+type Font int
+
+const (
+	Helvetica Font = iota
+	HelveticaBold
+	HelveticaOblique
+)
+
+var fontNames = [...]string{"Helvetica", "Helvetica-Bold", "Helvetica-Oblique"}
+
+// Page sizes in points, landscape.
+const (
+	A4Width  = 841.89
+	A4Height = 595.28
+)
+
+// Document is a PDF under construction.
+type Document struct {
+	title   string
+	created time.Time
+	pages   []*Page
+}
+
+// Page is one page of a document. Coordinates are in points with the
+// origin at the bottom left corner.
+type Page struct {
+	width, height float64
+	content       bytes.Buffer
+}
+
+func NewDocument(title string, created time.Time) *Document {
+	return &Document{title: title, created: created}
+}
+
+// AddPage appends a blank page of the given size.
+func (d *Document) AddPage(width, height float64) *Page {
+	page := &Page{width: width, height: height}
+	d.pages = append(d.pages, page)
+	return page
+}
+
+func (p *Page) Width() float64  { return p.width }
+func (p *Page) Height() float64 { return p.height }
+
+// SetFillColor sets the color of text and filled shapes, with components
+// from 0 to 1.
+func (p *Page) SetFillColor(r, g, b float64) {
+	fmt.Fprintf(&p.content, "%s %s %s rg\n", num(r), num(g), num(b))
+}
+
+// SetStrokeColor sets the color of lines and outlines.
+func (p *Page) SetStrokeColor(r, g, b float64) {
+	fmt.Fprintf(&p.content, "%s %s %s RG\n", num(r), num(g), num(b))
+}
+
+func (p *Page) SetLineWidth(width float64) {
+	fmt.Fprintf(&p.content, "%s w\n", num(width))
+}
+
+// Rect draws a rectangle from its bottom left corner, filled or outlined.
+func (p *Page) Rect(x, y, width, height float64, fill bool) {
+	op := "S"
+	if fill {
+		op = "f"
+	}
+	fmt.Fprintf(&p.content, "%s %s %s %s re %s\n", num(x), num(y), num(width), num(height), op)
+}
+
+func (p *Page) Line(x1, y1, x2, y2 float64) {
+	fmt.Fprintf(&p.content, "%s %s m %s %s l S\n", num(x1), num(y1), num(x2), num(y2))
+}
+
+// Text draws text with its baseline starting at x, y.
+func (p *Page) Text(x, y float64, font Font, size float64, text string) {
+	fmt.Fprintf(&p.content, "BT /F%d %s Tf %s %s Td (%s) Tj ET\n",
+		font+1, num(size), num(x), num(y), escape(encode(text)))
+}
+
+// CenteredText draws text centered on x.
+func (p *Page) CenteredText(x, y float64, font Font, size float64, text string) {
+	p.Text(x-TextWidth(font, size, text)/2, y, font, size, text)
+}
+
+// TextWidth returns the width of text in points.
+func TextWidth(font Font, size float64, text string) float64 {
+	widths := &helveticaWidths
+	if font == HelveticaBold {
+		widths = &helveticaBoldWidths
+	}
+
+	var total int
+	for _, c := range []byte(encode(text)) {
+		if c >= 32 && c <= 126 {
+			total += widths[c-32]
+		} else {
+			total += 556
+		}
+	}
+	return float64(total) * size / 1000
+}
+
+// Bytes renders the document.
+func (d *Document) Bytes() ([]byte, error) {
+	var out bytes.Buffer
+	var offsets []int
+
+	object := func(body string) int {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+		return len(offsets)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Objects 1 and 2 are the catalog and page tree, which refer forward to
+	// the pages, so the pages are numbered before they are written.
+	fontBase := 3
+	pageBase := fontBase + len(fontNames)
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", pageBase+2*i)
+	}
+
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+
+	var fonts []string
+	for i, name := range fontNames {
+		object(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", name))
+		fonts = append(fonts, fmt.Sprintf("/F%d %d 0 R", i+1, fontBase+i))
+	}
+
+	for i, page := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << /Font << %s >> >> /Contents %d 0 R >>",
+			num(page.width), num(page.height), strings.Join(fonts, " "), pageBase+2*i+1))
+
+		var stream bytes.Buffer
+		writer := zlib.NewWriter(&stream)
+		if _, err := writer.Write(page.content.Bytes()); err != nil {
+			return nil, err
+		}
+		if err := writer.Close(); err != nil {
+			return nil, err
+		}
+		object(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", stream.Len(), stream.String()))
+	}
+
+	info := object(fmt.Sprintf("<< /Title (%s) /Producer (viport) /CreationDate (D:%s) >>",
+		escape(encode(d.title)), d.created.UTC().Format("20060102150405Z")))
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n",
+		len(offsets)+1, info, xref)
+
+	return out.Bytes(), nil
+}
+
+// CanEncode reports whether every character of text can be drawn in the
+// standard fonts. Text that cannot is drawn with '?' in place of the
+// missing characters.
+func CanEncode(text string) bool {
+	for _, r := range text {
+		if _, ok := encodeRune(r); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// encode converts text to WinAnsi, the encoding of the standard fonts.
+// Characters outside it become '?'.
+func encode(text string) string {
+	encoded := make([]byte, 0, len(text))
+	for _, r := range text {
+		c, ok := encodeRune(r)
+		if !ok {
+			c = '?'
+		}
+		encoded = append(encoded, c)
+	}
+	return string(encoded)
+}
+
+func encodeRune(r rune) (byte, bool) {
+	switch {
+	case r >= 32 && r < 127, r >= 160 && r <= 255:
+		return byte(r), true
+	default:
+		c, ok := winAnsiExtras[r]
+		return c, ok
+	}
+}
+
+// winAnsiExtras maps the characters WinAnsi places at 128-159, where
+// Latin-1 has control codes.
+var winAnsiExtras = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87,
+	'ˆ': 0x88, '‰': 0x89, 'Š': 0x8a, '‹': 0x8b, 'Œ': 0x8c, 'Ž': 0x8e,
+	'‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97,
+	'˜': 0x98, '™': 0x99, 'š': 0x9a, '›': 0x9b, 'œ': 0x9c, 'ž': 0x9e, 'Ÿ': 0x9f,
+}
+
+func escape(text string) string {
+	return strings.NewReplacer(`\`, `\\`, `(`, `\(`, `)`, `\)`).Replace(text)
+}
+
+func num(value float64) string {
+	return strings.TrimRight(strings.TrimRight(fmt.Sprintf("%.2f", value), "0"), ".")
+}
+
+// Advance widths of the printable ASCII characters, per 1000 points of font
+// size, from the Adobe font metrics. Oblique shares the regular widths.
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+var helveticaBoldWidths = [95]int{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+	333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+}
@@ -0,0 +1,27 @@
+package pdf
+
+import "testing"
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		text      string
+		want      string
+		canEncode bool
+	}{
+		{"Ada Lovelace", "Ada Lovelace", true},
+		{"José Müller", "Jos\xe9 M\xfcller", true},
+		{"“Go” – Œuvre €5", "\x93Go\x94 \x96 \x8cuvre \x805", true},
+		{"李小龙", "???", false},
+		{"Ivan Петров", "Ivan ??????", false},
+		{"tab\there", "tab?here", false},
+	}
+
+	for _, tt := range tests {
+		if got := encode(tt.text); got != tt.want {
+			t.Errorf("encode(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+		if got := CanEncode(tt.text); got != tt.canEncode {
+			t.Errorf("CanEncode(%q) = %v, want %v", tt.text, got, tt.canEncode)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+var (
+	ErrFileNotFound    = errors.New("file not found")
+	ErrFileUnavailable = errors.New("file unavailable")
+)
+
+// File is an opened file being streamed to a client. Size is -1 when it is
+// not known up front.
+type File struct {
+	Body        io.ReadCloser
+	Size        int64
+	ContentType string
+}
+
+// Fetcher opens files by URL so they can be streamed to clients without
+// handing out the URL. Files kept in the local storage are read from disk;
+// any other URL is fetched over HTTP(S), from public addresses only so
+// stored URLs cannot reach into the server's network.
+type Fetcher struct {
+	local  *LocalStorage
+	client *http.Client
+}
+
+func NewFetcher(local *LocalStorage) *Fetcher {
+	dialer := &net.Dialer{Timeout: 10 * time.Second, Control: publicOnly}
+	return &Fetcher{
+		local: local,
+		client: &http.Client{
+			// No overall timeout, large files may take long to stream
+			Transport: &http.Transport{
+				DialContext:           dialer.DialContext,
+				TLSHandshakeTimeout:   10 * time.Second,
+				ResponseHeaderTimeout: 30 * time.Second,
+			},
+		},
+	}
+}
+
+// Open opens the file at rawURL. It returns ErrFileNotFound when there is
+// no file there and ErrFileUnavailable when it cannot be fetched.
+func (f *Fetcher) Open(rawURL string) (*File, error) {
+	if key, ok := f.local.key(rawURL); ok {
+		return f.local.open(key)
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return nil, ErrFileNotFound
+	}
+
+	resp, err := f.client.Get(parsed.String())
+	if err != nil {
+		return nil, ErrFileUnavailable
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		resp.Body.Close()
+		return nil, ErrFileNotFound
+	case resp.StatusCode != http.StatusOK:
+		resp.Body.Close()
+		return nil, ErrFileUnavailable
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	return &File{Body: resp.Body, Size: resp.ContentLength, ContentType: contentType}, nil
+}
+
+// publicOnly refuses connections to loopback, private and other
+// non-public addresses. It runs after name resolution, for every
+// connection including redirects.
+func publicOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return ErrFileUnavailable
+	}
+	return nil
+}
+
+// key returns the storage key of a URL returned by Put.
+func (s *LocalStorage) key(rawURL string) (string, bool) {
+	if !strings.HasPrefix(rawURL, s.baseURL+"/") {
+		return "", false
+	}
+	key := strings.TrimPrefix(rawURL, s.baseURL+"/")
+	if path.Clean("/" + key)[1:] != key {
+		return "", false
+	}
+	return key, true
+}
+
+func (s *LocalStorage) open(key string) (*File, error) {
+	file, err := os.Open(filepath.Join(s.dir, filepath.FromSlash(key)))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrFileNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if info.IsDir() {
+		file.Close()
+		return nil, ErrFileNotFound
+	}
+
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	return &File{Body: file, Size: info.Size(), ContentType: contentType}, nil
+}
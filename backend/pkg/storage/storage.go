@@ -0,0 +1,61 @@
+// Package storage keeps generated files and tells where they are served
+// from.
+package storage
+
+import (
+	"errors"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+var ErrInvalidKey = errors.New("invalid storage key")
+
+// Storage stores files under slash-separated keys such as
+// "certificates/ABCD.pdf".
+type Storage interface {
+	// Put stores data under key, replacing any file there, and returns the
+	// URL it is served from.
+	Put(key string, data []byte, contentType string) (string, error)
+}
+
+// LocalStorage keeps files in a directory on disk that is served at
+// baseURL, either by this server or by a CDN in front of it.
+type LocalStorage struct {
+	dir     string
+	baseURL string
+}
+
+func NewLocalStorage(dir, baseURL string) *LocalStorage {
+	return &LocalStorage{dir: dir, baseURL: strings.TrimRight(baseURL, "/")}
+}
+
+// Dir returns the directory the files are kept in.
+func (s *LocalStorage) Dir() string {
+	return s.dir
+}
+
+func (s *LocalStorage) Put(key string, data []byte, contentType string) (string, error) {
+	clean := path.Clean("/" + key)[1:]
+	if clean == "" || clean != key {
+		return "", ErrInvalidKey
+	}
+
+	file := filepath.Join(s.dir, filepath.FromSlash(clean))
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return "", err
+	}
+
+	// Write next to the target and rename so readers never see a partial file
+	tmp := file + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp, file); err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+
+	return s.baseURL + "/" + clean, nil
+}